@@ -0,0 +1,162 @@
+package swiss
+
+import (
+	"iter"
+	"math/rand"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
+)
+
+// ArenaMap is a map with string keys whose bytes are packed into a single
+// append-only arena. Slots hold the hash of the key and a reference into the
+// arena instead of a string header, so a table of millions of keys holds no
+// pointers for the garbage collector to scan as long as V holds none. The
+// slots are those of a Map keyed by the hash, using WithIdentityHash; the
+// rare keys whose 64-bit hash collides with another key are kept in a
+// built-in map. Space of deleted keys is reclaimed by compacting the arena
+// once it is mostly garbage. ArenaMap is not safe for concurrent use.
+type ArenaMap[V any] struct {
+	m        Map[uint64, arenaEntry[V]]
+	arena    []byte
+	garbage  int
+	collided map[string]V
+	hashfn   hash.HFunc
+	seed     uintptr
+}
+
+// arenaEntry refers to the key by its offset in the arena in the top 40
+// bits of ref and its length in the low 24 bits.
+type arenaEntry[V any] struct {
+	ref   uint64
+	value V
+}
+
+const (
+	arenaLenBits = 24
+	arenaMaxLen  = 1<<arenaLenBits - 1
+	arenaMaxSize = 1 << (64 - arenaLenBits)
+)
+
+// NewArenaMap creates a new ArenaMap with room for size keys.
+func NewArenaMap[V any](size int) *ArenaMap[V] {
+	return &ArenaMap[V]{
+		m:      *New[uint64, arenaEntry[V]](size, WithIdentityHash()),
+		hashfn: hash.GetHashFunc[string](),
+		seed:   uintptr(rand.Uint64()),
+	}
+}
+
+// Put inserts or updates a key-value pair, copying the key into the arena
+// if it is new.
+func (a *ArenaMap[V]) Put(key string, value V) {
+	h := a.hash(key)
+	if e := a.m.GetPtr(h); e != nil {
+		if a.key(e.ref) == key {
+			e.value = value
+			return
+		}
+		a.collide(key, value)
+		return
+	}
+	if _, ok := a.collided[key]; ok || len(key) > arenaMaxLen || uint64(len(a.arena)+len(key)) > arenaMaxSize {
+		a.collide(key, value)
+		return
+	}
+	ref := uint64(len(a.arena))<<arenaLenBits | uint64(len(key))
+	a.arena = append(a.arena, key...)
+	a.m.Put(h, arenaEntry[V]{ref: ref, value: value})
+}
+
+// Get retrieves the value associated with the key.
+func (a *ArenaMap[V]) Get(key string) (V, bool) {
+	if e := a.m.GetPtr(a.hash(key)); e != nil && a.key(e.ref) == key {
+		return e.value, true
+	}
+	v, ok := a.collided[key]
+	return v, ok
+}
+
+// Delete removes the key from the map.
+func (a *ArenaMap[V]) Delete(key string) {
+	h := a.hash(key)
+	if e := a.m.GetPtr(h); e != nil && a.key(e.ref) == key {
+		a.garbage += int(e.ref & arenaMaxLen)
+		a.m.Delete(h)
+		if a.garbage > len(a.arena)/2 {
+			a.compact()
+		}
+		return
+	}
+	delete(a.collided, key)
+}
+
+// Len returns the number of entries in the map.
+func (a *ArenaMap[V]) Len() int {
+	return a.m.Len() + len(a.collided)
+}
+
+// ArenaSize returns the number of bytes used by the arena, including the
+// bytes of deleted keys that have not been reclaimed yet.
+func (a *ArenaMap[V]) ArenaSize() int {
+	return len(a.arena)
+}
+
+// Clear removes all entries from the map and releases the arena.
+func (a *ArenaMap[V]) Clear() {
+	a.m.Clear()
+	a.arena, a.garbage, a.collided = nil, 0, nil
+}
+
+// All returns an iterator over all entries of the map. The keys refer to the
+// arena without copying and stay valid after the map is modified.
+func (a *ArenaMap[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for _, e := range a.m.All() {
+			if !yield(a.key(e.ref), e.value) {
+				return
+			}
+		}
+		for k, v := range a.collided {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (a *ArenaMap[V]) hash(key string) uint64 {
+	return uint64(a.hashfn(noescape(unsafe.Pointer(&key)), a.seed))
+}
+
+// key returns the key referenced by ref. The arena bytes are never
+// overwritten, so the string may share their memory.
+func (a *ArenaMap[V]) key(ref uint64) string {
+	off, n := ref>>arenaLenBits, ref&arenaMaxLen
+	return unsafe.String(unsafe.SliceData(a.arena[off:]), n)
+}
+
+func (a *ArenaMap[V]) collide(key string, value V) {
+	if a.collided == nil {
+		a.collided = make(map[string]V)
+	}
+	a.collided[key] = value
+}
+
+// compact copies the live keys into a new arena. The old arena is left
+// untouched for the strings still referring to it.
+func (a *ArenaMap[V]) compact() {
+	arena := make([]byte, 0, len(a.arena)-a.garbage)
+	for i := range a.m.grps {
+		g := &a.m.grps[i]
+		mask := g.maskFull()
+		for mask != 0 {
+			e := &g.slts[mask.first()].value
+			off, n := e.ref>>arenaLenBits, e.ref&arenaMaxLen
+			e.ref = uint64(len(arena))<<arenaLenBits | n
+			arena = append(arena, a.arena[off:off+n]...)
+			mask = mask.rmfirst()
+		}
+	}
+	a.arena, a.garbage = arena, 0
+}
@@ -0,0 +1,84 @@
+package swiss
+
+import (
+	"strconv"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaMap(t *testing.T) {
+	t.Parallel()
+	m := NewArenaMap[int](0)
+	for i := range 10000 {
+		m.Put("key-"+strconv.Itoa(i), i)
+	}
+	m.Put("", -1)
+	m.Put("key-5", 50)
+	require.Equal(t, 10001, m.Len())
+	v, ok := m.Get("key-5")
+	require.True(t, ok)
+	require.Equal(t, 50, v)
+	v, ok = m.Get("")
+	require.True(t, ok)
+	require.Equal(t, -1, v)
+	_, ok = m.Get("key-10000")
+	require.False(t, ok)
+
+	var keys []string
+	for k := range m.All() {
+		keys = append(keys, k)
+	}
+	size := m.ArenaSize()
+	for i := range 9000 {
+		m.Delete("key-" + strconv.Itoa(i))
+	}
+	require.Less(t, m.ArenaSize(), size/2)
+	require.Equal(t, 1001, m.Len())
+	for i := 9000; i < 10000; i++ {
+		v, ok := m.Get("key-" + strconv.Itoa(i))
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	// Keys returned before compaction are still intact.
+	for _, k := range keys {
+		require.True(t, k == "" || k[:4] == "key-")
+	}
+	m.Clear()
+	require.Zero(t, m.Len())
+	require.Zero(t, m.ArenaSize())
+}
+
+func TestArenaMapCollisions(t *testing.T) {
+	t.Parallel()
+	m := NewArenaMap[int](0)
+	// Hash keys by their length only.
+	m.hashfn = func(p unsafe.Pointer, seed uintptr) uintptr {
+		return uintptr(len(*(*string)(p)))
+	}
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("c", 3)
+	m.Put("b", 20)
+	require.Equal(t, 3, m.Len())
+	for k, want := range map[string]int{"a": 1, "b": 20, "c": 3} {
+		v, ok := m.Get(k)
+		require.True(t, ok)
+		require.Equal(t, want, v)
+	}
+	m.Delete("a")
+	_, ok := m.Get("a")
+	require.False(t, ok)
+	m.Put("b", 200)
+	v, _ := m.Get("b")
+	require.Equal(t, 200, v)
+	m.Delete("b")
+	_, ok = m.Get("b")
+	require.False(t, ok)
+	got := make(map[string]int)
+	for k, v := range m.All() {
+		got[k] = v
+	}
+	require.Equal(t, map[string]int{"c": 3}, got)
+}
@@ -0,0 +1,68 @@
+package swiss
+
+import "iter"
+
+// Bag is a multiset: it holds keys together with their multiplicity. Keys
+// whose multiplicity drops to zero are removed. Bag is not safe for
+// concurrent use.
+type Bag[K comparable] struct {
+	m     Map[K, uint64]
+	total uint64
+}
+
+// NewBag creates a new bag with room for size distinct keys. It accepts the
+// same options as New.
+func NewBag[K comparable](size int, opts ...Option) *Bag[K] {
+	return &Bag[K]{m: *New[K, uint64](size, opts...)}
+}
+
+// Add adds n occurrences of the key.
+func (b *Bag[K]) Add(key K, n uint64) {
+	if n == 0 {
+		return
+	}
+	b.m.Do(key, func(v *uint64, _ bool) (uint64, bool, bool) {
+		return *v + n, true, false
+	})
+	b.total += n
+}
+
+// Remove removes up to n occurrences of the key and returns how many were
+// removed.
+func (b *Bag[K]) Remove(key K, n uint64) uint64 {
+	var removed uint64
+	b.m.Do(key, func(v *uint64, exists bool) (uint64, bool, bool) {
+		removed = min(*v, n)
+		return *v - removed, exists, *v == removed
+	})
+	b.total -= removed
+	return removed
+}
+
+// Count returns the number of occurrences of the key.
+func (b *Bag[K]) Count(key K) uint64 {
+	n, _ := b.m.Get(key)
+	return n
+}
+
+// Len returns the number of distinct keys in the bag.
+func (b *Bag[K]) Len() int {
+	return b.m.Len()
+}
+
+// TotalLen returns the number of occurrences of all keys in the bag.
+func (b *Bag[K]) TotalLen() uint64 {
+	return b.total
+}
+
+// Clear removes all keys from the bag.
+func (b *Bag[K]) Clear() {
+	b.m.Clear()
+	b.total = 0
+}
+
+// All returns an iterator over the distinct keys of the bag and their
+// multiplicity.
+func (b *Bag[K]) All() iter.Seq2[K, uint64] {
+	return b.m.All()
+}
@@ -0,0 +1,36 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBag(t *testing.T) {
+	t.Parallel()
+	b := NewBag[string](0)
+	b.Add("apple", 3)
+	b.Add("pear", 1)
+	b.Add("apple", 2)
+	b.Add("plum", 0)
+	require.Equal(t, uint64(5), b.Count("apple"))
+	require.Zero(t, b.Count("plum"))
+	require.Equal(t, 2, b.Len())
+	require.Equal(t, uint64(6), b.TotalLen())
+
+	require.Equal(t, uint64(2), b.Remove("apple", 2))
+	require.Equal(t, uint64(3), b.Count("apple"))
+	require.Equal(t, uint64(1), b.Remove("pear", 5))
+	require.Zero(t, b.Remove("plum", 1))
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, uint64(3), b.TotalLen())
+
+	counts := make(map[string]uint64)
+	for k, n := range b.All() {
+		counts[k] = n
+	}
+	require.Equal(t, map[string]uint64{"apple": 3}, counts)
+	b.Clear()
+	require.Zero(t, b.Len())
+	require.Zero(t, b.TotalLen())
+}
@@ -0,0 +1,171 @@
+// Package benchmarks compares crn4/swiss with other hash map implementations
+// on the same workloads. It is a separate module so that the comparison
+// dependencies do not leak into the main module. dolthub/swiss is not
+// compared yet. Run it with
+//
+//	go test -run=NONE -bench=. -count=10 | tee new.txt
+//	benchstat -col /impl new.txt
+package benchmarks
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+var sizes = []int{1 << 10, 1 << 16, 1 << 20}
+
+func intKeys(n int) []int64 {
+	r := rand.New(rand.NewPCG(1, 2))
+	keys := make([]int64, n)
+	for i := range keys {
+		keys[i] = r.Int64()
+	}
+	return keys
+}
+
+func stringKeys(n int) []string {
+	r := rand.New(rand.NewPCG(1, 2))
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.FormatUint(r.Uint64(), 36) + "-key"
+	}
+	return keys
+}
+
+func BenchmarkPutGrow(b *testing.B) {
+	runInt(b, benchPutGrow[int64])
+	runString(b, benchPutGrow[string])
+}
+
+func BenchmarkGetHit(b *testing.B) {
+	runInt(b, benchGetHit[int64])
+	runString(b, benchGetHit[string])
+}
+
+func BenchmarkGetMiss(b *testing.B) {
+	runInt(b, benchGetMiss[int64])
+	runString(b, benchGetMiss[string])
+}
+
+func BenchmarkPutDelete(b *testing.B) {
+	runInt(b, benchPutDelete[int64])
+	runString(b, benchPutDelete[string])
+}
+
+func BenchmarkIterate(b *testing.B) {
+	runInt(b, benchIterate[int64])
+	runString(b, benchIterate[string])
+}
+
+// BenchmarkFootprint reports the heap memory held by a table of each size,
+// in bytes per entry.
+func BenchmarkFootprint(b *testing.B) {
+	runInt(b, benchFootprint[int64])
+	runString(b, benchFootprint[string])
+}
+
+func runInt(b *testing.B, bench func(*testing.B, impl[int64, int64], []int64)) {
+	run(b, "int64", impls[int64, int64](), intKeys, bench)
+}
+
+func runString(b *testing.B, bench func(*testing.B, impl[string, int64], []string)) {
+	run(b, "string", impls[string, int64](), stringKeys, bench)
+}
+
+func run[K comparable](b *testing.B, kind string, impls []impl[K, int64], keys func(int) []K,
+	bench func(*testing.B, impl[K, int64], []K)) {
+	for _, size := range sizes {
+		// Twice the keys, the second half is used for misses.
+		all := keys(2 * size)
+		for _, im := range impls {
+			b.Run(fmt.Sprintf("key=%s/size=%d/impl=%s", kind, size, im.name), func(b *testing.B) {
+				bench(b, im, all)
+			})
+		}
+	}
+}
+
+func fill[K comparable](im impl[K, int64], keys []K) table[K, int64] {
+	t := im.new(0)
+	for i, k := range keys {
+		t.Put(k, int64(i))
+	}
+	return t
+}
+
+func benchPutGrow[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	keys = keys[:len(keys)/2]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i += len(keys) {
+		fill(im, keys)
+	}
+}
+
+func benchGetHit[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	keys = keys[:len(keys)/2]
+	t := fill(im, keys)
+	b.ResetTimer()
+	for i := range b.N {
+		if _, ok := t.Get(keys[i%len(keys)]); !ok {
+			b.Fatal("missing key")
+		}
+	}
+}
+
+func benchGetMiss[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	t := fill(im, keys[:len(keys)/2])
+	miss := keys[len(keys)/2:]
+	b.ResetTimer()
+	for i := range b.N {
+		if _, ok := t.Get(miss[i%len(miss)]); ok {
+			b.Fatal("unexpected key")
+		}
+	}
+}
+
+func benchPutDelete[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	half := len(keys) / 2
+	t := fill(im, keys[:half])
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		j := i % half
+		t.Delete(keys[j])
+		t.Put(keys[half+j], int64(j))
+		keys[j], keys[half+j] = keys[half+j], keys[j]
+	}
+}
+
+func benchIterate[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	keys = keys[:len(keys)/2]
+	t := fill(im, keys)
+	b.ResetTimer()
+	var sum int64
+	for i := 0; i < b.N; i += len(keys) {
+		t.All(func(_ K, v int64) bool {
+			sum += v
+			return true
+		})
+	}
+	runtime.KeepAlive(sum)
+}
+
+func benchFootprint[K comparable](b *testing.B, im impl[K, int64], keys []K) {
+	keys = keys[:len(keys)/2]
+	var before, after runtime.MemStats
+	var t table[K, int64]
+	for range b.N {
+		t = nil
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		t = fill(im, keys)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+	}
+	runtime.KeepAlive(t)
+	b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/float64(len(keys)), "B/entry")
+	b.ReportMetric(0, "ns/op")
+}
@@ -0,0 +1,10 @@
+module github.com/crn4/swiss/benchmarks
+
+go 1.23.0
+
+replace github.com/crn4/swiss => ../
+
+require (
+	github.com/cockroachdb/swiss v0.0.0-20260820225851-333444432258
+	github.com/crn4/swiss v0.0.0-00010101000000-000000000000
+)
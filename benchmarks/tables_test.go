@@ -0,0 +1,65 @@
+package benchmarks
+
+import (
+	cockroach "github.com/cockroachdb/swiss"
+
+	"github.com/crn4/swiss"
+)
+
+// table is the subset of map operations exercised by the benchmarks.
+type table[K comparable, V any] interface {
+	Put(key K, value V)
+	Get(key K) (V, bool)
+	Delete(key K)
+	All(yield func(K, V) bool)
+}
+
+type impl[K comparable, V any] struct {
+	name string
+	new  func(size int) table[K, V]
+}
+
+func impls[K comparable, V any]() []impl[K, V] {
+	return []impl[K, V]{
+		{"crn4", func(size int) table[K, V] { return crn4Table[K, V]{swiss.New[K, V](size)} }},
+		{"cockroachdb", func(size int) table[K, V] { return cockroachTable[K, V]{cockroach.New[K, V](size)} }},
+		{"runtime", func(size int) table[K, V] { return runtimeTable[K, V](make(map[K]V, size)) }},
+	}
+}
+
+type crn4Table[K comparable, V any] struct {
+	m *swiss.Map[K, V]
+}
+
+func (t crn4Table[K, V]) Put(key K, value V)        { t.m.Put(key, value) }
+func (t crn4Table[K, V]) Get(key K) (V, bool)       { return t.m.Get(key) }
+func (t crn4Table[K, V]) Delete(key K)              { t.m.Delete(key) }
+func (t crn4Table[K, V]) All(yield func(K, V) bool) { t.m.All()(yield) }
+
+type cockroachTable[K comparable, V any] struct {
+	m *cockroach.Map[K, V]
+}
+
+func (t cockroachTable[K, V]) Put(key K, value V)        { t.m.Put(key, value) }
+func (t cockroachTable[K, V]) Get(key K) (V, bool)       { return t.m.Get(key) }
+func (t cockroachTable[K, V]) Delete(key K)              { t.m.Delete(key) }
+func (t cockroachTable[K, V]) All(yield func(K, V) bool) { t.m.All(yield) }
+
+type runtimeTable[K comparable, V any] map[K]V
+
+func (t runtimeTable[K, V]) Put(key K, value V) { t[key] = value }
+
+func (t runtimeTable[K, V]) Get(key K) (V, bool) {
+	v, ok := t[key]
+	return v, ok
+}
+
+func (t runtimeTable[K, V]) Delete(key K) { delete(t, key) }
+
+func (t runtimeTable[K, V]) All(yield func(K, V) bool) {
+	for k, v := range t {
+		if !yield(k, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package swiss
+
+import "iter"
+
+// Boxed is a map storing its values out of line, behind pointers. Slots only
+// hold the key and a pointer, so groups stay small and probes touch fewer
+// cache lines, at the cost of an allocation per entry and one indirection on
+// every hit. It pays off for large values, typically above a few cache
+// lines. It is a separate type rather than an option of Map because the
+// slot layout of a Map[K, V] is fixed by its type parameters, so a map
+// cannot switch to storing *V at run time.
+type Boxed[K comparable, V any] struct {
+	m Map[K, *V]
+}
+
+// NewBoxed creates a new Boxed map with the specified initial size. It
+// accepts the same options as New.
+func NewBoxed[K comparable, V any](size int, opts ...Option) *Boxed[K, V] {
+	return &Boxed[K, V]{m: *New[K, *V](size, opts...)}
+}
+
+// Put inserts or updates a key-value pair. Updates reuse the existing box.
+func (b *Boxed[K, V]) Put(key K, value V) {
+	if p, ok := b.m.Get(key); ok {
+		*p = value
+		return
+	}
+	b.m.Put(key, &value)
+}
+
+// Get retrieves the value associated with the key.
+func (b *Boxed[K, V]) Get(key K) (V, bool) {
+	if p, ok := b.m.Get(key); ok {
+		return *p, true
+	}
+	var res V
+	return res, false
+}
+
+// GetPtr returns a pointer to the boxed value associated with the key, or
+// nil if the key is not present. Unlike Map.GetPtr, the pointer stays valid
+// across later modifications of the map, until the key is deleted.
+func (b *Boxed[K, V]) GetPtr(key K) *V {
+	p, _ := b.m.Get(key)
+	return p
+}
+
+// Delete removes the key from the map.
+func (b *Boxed[K, V]) Delete(key K) {
+	b.m.Delete(key)
+}
+
+// Clear removes all entries from the map.
+func (b *Boxed[K, V]) Clear() {
+	b.m.Clear()
+}
+
+// Len returns the number of entries in the map.
+func (b *Boxed[K, V]) Len() int {
+	return b.m.Len()
+}
+
+// All returns an iterator over all entries of the map.
+func (b *Boxed[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, p := range b.m.All() {
+			if !yield(k, *p) {
+				return
+			}
+		}
+	}
+}
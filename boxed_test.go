@@ -0,0 +1,41 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoxed(t *testing.T) {
+	t.Parallel()
+	type value struct {
+		n   int
+		pad [1024]byte
+	}
+	m := NewBoxed[int, value](0)
+	for i := range 1000 {
+		m.Put(i, value{n: i})
+	}
+	p := m.GetPtr(10)
+	for i := range 1000 {
+		m.Put(i, value{n: -i})
+	}
+	require.Equal(t, -10, p.n)
+	for i := 0; i < 1000; i += 2 {
+		m.Delete(i)
+	}
+	require.Equal(t, 500, m.Len())
+	for i := range 1000 {
+		v, ok := m.Get(i)
+		require.Equal(t, i%2 == 1, ok)
+		if ok {
+			require.Equal(t, -i, v.n)
+		}
+	}
+	for k, v := range m.All() {
+		require.Equal(t, -k, v.n)
+	}
+	require.Nil(t, m.GetPtr(0))
+	m.Clear()
+	require.Zero(t, m.Len())
+}
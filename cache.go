@@ -0,0 +1,153 @@
+package swiss
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is a read-through cache in front of a backing store, and
+// optionally a write-through one, safe for concurrent use. Get loads missing
+// keys with the loader of the cache, at most once per key at a time like
+// Memo, and Put writes values to the store before caching them, see
+// WriteThrough. Errors of the loader are not cached unless CacheErrors is
+// set. Entries stay cached until they are invalidated.
+type Cache[K comparable, V any] struct {
+	m      *SafeMap[K, *cacheEntry[V]]
+	loader func(K) (V, error)
+	writer func(K, V) error
+	// errTTL is how long errors accepted by cacheable are cached.
+	errTTL    time.Duration
+	cacheable func(error) bool
+	now       func() time.Time
+
+	hits, misses atomic.Uint64
+}
+
+type cacheEntry[V any] struct {
+	once  sync.Once
+	value V
+	err   error
+	// expires is when a cached error expires, in unix nanoseconds.
+	expires int64
+	done    bool
+}
+
+// NewCache creates a Cache loading missing keys with loader, with room for
+// size keys. It accepts the same options as New.
+func NewCache[K comparable, V any](size int, loader func(key K) (V, error), opts ...Option) *Cache[K, V] {
+	return &Cache[K, V]{
+		m:      NewSafeMap[K, *cacheEntry[V]](size, opts...),
+		loader: loader,
+		now:    time.Now,
+	}
+}
+
+// WriteThrough makes Put store values with writer before caching them. It
+// must be called before the cache is used.
+func (c *Cache[K, V]) WriteThrough(writer func(key K, value V) error) {
+	c.writer = writer
+}
+
+// CacheErrors makes Get cache errors of the loader for ttl, so that a key
+// failing to load, such as one missing from the store, does not reach the
+// store on every Get. Only errors for which cacheable returns true are
+// cached, or all of them if it is nil. It must be called before the cache
+// is used.
+func (c *Cache[K, V]) CacheErrors(ttl time.Duration, cacheable func(error) bool) {
+	c.errTTL, c.cacheable = ttl, cacheable
+}
+
+// Get returns the value of the key, loading and caching it first if it is
+// not cached. Concurrent calls for a key being loaded wait for the load and
+// share its result. If the loader fails, Get returns its error, which is
+// cached according to CacheErrors. If the loader panics, the panic
+// propagates to the caller that ran it and nothing is cached.
+func (c *Cache[K, V]) Get(key K) (V, error) {
+	for {
+		e, ok := c.m.Get(key)
+		if !ok {
+			c.m.Do(key, func(v **cacheEntry[V], exists bool) (*cacheEntry[V], bool, bool) {
+				if !exists {
+					*v = &cacheEntry[V]{}
+				}
+				e = *v
+				return e, !exists, false
+			})
+		}
+		loaded := false
+		e.once.Do(func() {
+			loaded = true
+			defer func() {
+				if !e.done {
+					c.drop(key, e)
+				}
+			}()
+			e.value, e.err = c.loader(key)
+			e.done = true
+			if e.err == nil {
+				return
+			}
+			if c.errTTL > 0 && (c.cacheable == nil || c.cacheable(e.err)) {
+				e.expires = c.now().Add(c.errTTL).UnixNano()
+			} else {
+				c.drop(key, e)
+			}
+		})
+		if !e.done {
+			// The loader panicked in another goroutine, load again.
+			continue
+		}
+		if !loaded && e.err != nil && e.expires <= c.now().UnixNano() {
+			c.drop(key, e)
+			continue
+		}
+		if loaded {
+			c.misses.Add(1)
+		} else {
+			c.hits.Add(1)
+		}
+		return e.value, e.err
+	}
+}
+
+// Put stores the value in the backing store with the writer of the cache,
+// if any, and then caches it, replacing a cached value or error of the key
+// and the result of a load in progress. If the writer fails, Put returns its
+// error and the cache is left as is.
+func (c *Cache[K, V]) Put(key K, value V) error {
+	if c.writer != nil {
+		if err := c.writer(key, value); err != nil {
+			return err
+		}
+	}
+	e := &cacheEntry[V]{value: value, done: true}
+	e.once.Do(func() {})
+	c.m.Put(key, e)
+	return nil
+}
+
+// Invalidate removes the cached value or error of the key, so that the next
+// Get loads it again. Calls of Get already waiting for a load of the key
+// still receive its result.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.m.Delete(key)
+}
+
+// Len returns the number of cached keys, including those being loaded.
+func (c *Cache[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// Stats returns the numbers of Gets answered from the cache and of those
+// that called the loader. Only Hits and Misses are counted.
+func (c *Cache[K, V]) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// drop removes the entry of the key unless it was replaced already.
+func (c *Cache[K, V]) drop(key K, e *cacheEntry[V]) {
+	c.m.Do(key, func(v **cacheEntry[V], exists bool) (*cacheEntry[V], bool, bool) {
+		return nil, false, exists && *v == e
+	})
+}
@@ -0,0 +1,139 @@
+package swiss
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheReadThrough(t *testing.T) {
+	t.Parallel()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	c := NewCache[int, string](0, func(key int) (string, error) {
+		loads.Add(1)
+		<-release
+		return strconv.Itoa(key), nil
+	})
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Get(7)
+			require.NoError(t, err)
+			require.Equal(t, "7", v)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), loads.Load())
+	require.Equal(t, uint64(10), c.Stats().Hits+c.Stats().Misses)
+	require.Equal(t, uint64(1), c.Stats().Misses)
+
+	v, err := c.Get(8)
+	require.NoError(t, err)
+	require.Equal(t, "8", v)
+	require.Equal(t, 2, c.Len())
+	c.Invalidate(7)
+	_, _ = c.Get(7)
+	require.Equal(t, int32(3), loads.Load())
+}
+
+func TestCacheWriteThrough(t *testing.T) {
+	t.Parallel()
+	store := map[string]int{"a": 1}
+	errFull := errors.New("store full")
+	c := NewCache[string, int](0, func(key string) (int, error) {
+		v, ok := store[key]
+		if !ok {
+			return 0, errors.New("not found")
+		}
+		return v, nil
+	})
+	c.WriteThrough(func(key string, value int) error {
+		if len(store) >= 2 {
+			return errFull
+		}
+		store[key] = value
+		return nil
+	})
+
+	require.NoError(t, c.Put("b", 2))
+	require.Equal(t, 2, store["b"])
+	v, err := c.Get("b")
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+	require.Equal(t, uint64(1), c.Stats().Hits)
+
+	require.ErrorIs(t, c.Put("c", 3), errFull)
+	_, err = c.Get("c")
+	require.ErrorContains(t, err, "not found")
+	require.Equal(t, 1, c.Len(), "errors are not cached by default")
+}
+
+func TestCacheErrors(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	errNotFound := errors.New("not found")
+	errTimeout := errors.New("timeout")
+	var loads atomic.Int32
+	fail := errNotFound
+	c := NewCache[int, int](0, func(key int) (int, error) {
+		loads.Add(1)
+		if fail != nil {
+			return 0, fail
+		}
+		return key, nil
+	})
+	c.now = clock.Now
+	c.CacheErrors(time.Minute, func(err error) bool { return errors.Is(err, errNotFound) })
+
+	for range 3 {
+		_, err := c.Get(1)
+		require.ErrorIs(t, err, errNotFound)
+	}
+	require.Equal(t, int32(1), loads.Load())
+	clock.Advance(time.Minute)
+	fail = errTimeout
+	for range 3 {
+		_, err := c.Get(1)
+		require.ErrorIs(t, err, errTimeout)
+	}
+	require.Equal(t, int32(4), loads.Load())
+	fail = nil
+	v, err := c.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	// Put replaces a cached error.
+	fail = errNotFound
+	_, err = c.Get(2)
+	require.ErrorIs(t, err, errNotFound)
+	require.NoError(t, c.Put(2, 20))
+	v, err = c.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, 20, v)
+}
+
+func TestCacheLoaderPanic(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	c := NewCache[int, int](0, func(key int) (int, error) {
+		if calls++; calls == 1 {
+			panic("boom")
+		}
+		return key, nil
+	})
+	require.Panics(t, func() { c.Get(1) })
+	require.Zero(t, c.Len())
+	v, err := c.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+}
@@ -0,0 +1,127 @@
+package swiss
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"unsafe"
+)
+
+// Checkpoint is a snapshot of a map taken without stopping its writers for
+// the whole duration of a Save. Starting a checkpoint captures the groups of
+// the map; from then on every modification first copies the group it is
+// about to change, unless the checkpoint has already processed it, and a
+// rehash simply leaves the captured groups to the checkpoint. The owner of
+// the map advances the checkpoint with Step between its own operations, and
+// Finish writes the state of the map at the time the checkpoint started in
+// the snapshot format, readable by Load and OpenReadOnly.
+type Checkpoint[K comparable, V any] struct {
+	m      *Map[K, V]
+	grps   []group[K, V]
+	gens   []uint32
+	gen    uint32
+	saved  map[int]group[K, V] // a built-in map, a Map here would be an instantiation cycle
+	cursor int
+	t      *Map[K, V]
+}
+
+var (
+	errCheckpointActive  = errors.New("swiss: checkpoint already in progress")
+	errCheckpointOffHeap = errors.New("swiss: checkpoint of an off-heap map")
+)
+
+// Checkpoint starts a checkpoint of the map. It returns ErrUnsupportedType
+// if the map cannot be saved as a snapshot and an error if another
+// checkpoint is in progress or if the map is off-heap, since a rehash frees
+// the groups of an off-heap map instead of leaving them to the checkpoint.
+func (m *Map[K, V]) Checkpoint() (*Checkpoint[K, V], error) {
+	hashfn, err := snapshotHashFunc[K, V]()
+	if err != nil {
+		return nil, err
+	}
+	if m.cp != nil {
+		return nil, errCheckpointActive
+	}
+	if m.release != nil {
+		return nil, errCheckpointOffHeap
+	}
+	c := &Checkpoint[K, V]{
+		m:     m,
+		grps:  m.grps,
+		gens:  slices.Clone(m.gens),
+		gen:   m.gen,
+		saved: make(map[int]group[K, V]),
+		t:     newMap[K, V](m.Len(), hashfn, defaultOptions()),
+	}
+	m.cp = c
+	return c, nil
+}
+
+// Step copies up to n captured groups into the checkpoint and reports
+// whether all of them have been copied.
+func (c *Checkpoint[K, V]) Step(n int) bool {
+	for ; n > 0 && c.cursor < len(c.grps); n-- {
+		i := c.cursor
+		g := &c.grps[i]
+		if s, ok := c.saved[i]; ok {
+			g = &s
+			delete(c.saved, i)
+		}
+		if c.gens == nil || c.gens[i] == c.gen {
+			mask := g.maskFull()
+			for mask != 0 {
+				s := &g.slts[mask.first()]
+				c.t.Put(s.key, s.value)
+				mask = mask.rmfirst()
+			}
+		}
+		c.cursor++
+	}
+	if c.cursor < len(c.grps) {
+		return false
+	}
+	c.detach()
+	return true
+}
+
+// Finish completes the checkpoint and writes it to w.
+func (c *Checkpoint[K, V]) Finish(w io.Writer) error {
+	c.Step(len(c.grps))
+	return c.t.writeSnapshot(w)
+}
+
+// Abort stops the checkpoint, so the map no longer copies groups for it.
+func (c *Checkpoint[K, V]) Abort() {
+	c.cursor = len(c.grps)
+	c.detach()
+}
+
+func (c *Checkpoint[K, V]) detach() {
+	if c.m.cp == c {
+		c.m.cp = nil
+	}
+	c.saved = nil
+}
+
+// preserve saves a copy of g before it is modified, if it is one of the
+// captured groups and has not been processed or saved yet.
+func (c *Checkpoint[K, V]) preserve(g *group[K, V]) {
+	size := unsafe.Sizeof(*g)
+	off := uintptr(unsafe.Pointer(g)) - uintptr(unsafe.Pointer(unsafe.SliceData(c.grps)))
+	if off >= uintptr(len(c.grps))*size {
+		return
+	}
+	i := int(off / size)
+	if i < c.cursor {
+		return
+	}
+	if _, ok := c.saved[i]; !ok {
+		c.saved[i] = *g
+	}
+}
+
+// groupOf returns the group holding the slot.
+func (m *Map[K, V]) groupOf(s *slot[K, V]) *group[K, V] {
+	off := uintptr(unsafe.Pointer(s)) - uintptr(unsafe.Pointer(unsafe.SliceData(m.grps)))
+	return &m.grps[off/unsafe.Sizeof(group[K, V]{})]
+}
@@ -0,0 +1,68 @@
+package swiss
+
+import (
+	"bytes"
+	randn "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+	for _, opts := range [][]Option{nil, {WithLazyClear()}} {
+		m := New[uint64, uint64](0, opts...)
+		for i := range 5000 {
+			m.Put(uint64(i), uint64(i))
+		}
+		expected := m.Clone()
+		cp, err := m.Checkpoint()
+		require.NoError(t, err)
+		_, err = m.Checkpoint()
+		require.Error(t, err)
+		for step := 0; !cp.Step(10); step++ {
+			for range 20 {
+				k := uint64(randn.Intn(20_000))
+				switch randn.Intn(4) {
+				case 0:
+					m.Delete(k)
+				case 1:
+					if p := m.GetPtr(k); p != nil {
+						*p = 0
+					}
+				default:
+					m.Put(k, k+1)
+				}
+			}
+			if step == 50 {
+				m.Clear()
+			}
+		}
+		require.Nil(t, m.cp)
+		var buf bytes.Buffer
+		require.NoError(t, cp.Finish(&buf))
+		loaded, err := Load[uint64, uint64](&buf)
+		require.NoError(t, err)
+		require.Equal(t, expected.Len(), loaded.Len())
+		for k, v := range expected.All() {
+			value, ok := loaded.Get(k)
+			require.True(t, ok)
+			require.Equal(t, v, value)
+		}
+	}
+
+	m := New[uint64, uint64](0)
+	cp, err := m.Checkpoint()
+	require.NoError(t, err)
+	cp.Abort()
+	cp, err = m.Checkpoint()
+	require.NoError(t, err)
+	cp.Abort()
+	_, err = New[string, int](0).Checkpoint()
+	require.ErrorIs(t, err, ErrUnsupportedType)
+	o, err := NewOffHeap[uint64, uint64](0)
+	require.NoError(t, err)
+	defer o.Free()
+	_, err = o.m.Checkpoint()
+	require.ErrorIs(t, err, errCheckpointOffHeap)
+}
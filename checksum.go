@@ -0,0 +1,94 @@
+package swiss
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"unsafe"
+)
+
+// ErrCorrupted is returned by VerifyIntegrity, and panicked with by Get on
+// maps created with WithChecksums(true), when the memory of a group no
+// longer matches its checksum.
+var ErrCorrupted = errors.New("swiss: group checksum mismatch")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// WithChecksums makes the map keep a CRC-32C checksum of the memory of every
+// group, to detect silent memory corruption: keys and values stored in the
+// slots are covered, memory they point to is not. Checksums are updated by
+// writes and checked by VerifyIntegrity, and if verifyReads is set also by
+// Get for every group it visits. It costs 4 bytes per group and a checksum
+// of a group per write, and per group visited by Get with verifyReads.
+func WithChecksums(verifyReads bool) Option {
+	return func(o *options) {
+		o.checksums = true
+		o.verifyReads = verifyReads
+	}
+}
+
+// checksums holds the checksum of every group. The checksum of the group
+// last passed to modifying is only computed when the next group is modified
+// or the checksums are verified, so that writes through pointers returned by
+// GetPtr are covered, and reads skip that group.
+type checksums struct {
+	sums        []uint32
+	pending     int // index of the group modified last, or -1
+	verifyReads bool
+}
+
+func groupSum[K comparable, V any](g *group[K, V]) uint32 {
+	return crc32.Checksum(unsafe.Slice((*byte)(unsafe.Pointer(g)), unsafe.Sizeof(*g)), castagnoli)
+}
+
+// resetSums computes the checksums of all groups.
+func (m *Map[K, V]) resetSums() {
+	c := m.sums
+	c.sums = make([]uint32, len(m.grps))
+	for i := range m.grps {
+		c.sums[i] = groupSum(&m.grps[i])
+	}
+	c.pending = -1
+}
+
+// modified updates the checksum of the pending group, which is about to be
+// replaced by group i.
+func (m *Map[K, V]) modified(i int) {
+	c := m.sums
+	if c.pending >= 0 && c.pending != i {
+		c.sums[c.pending] = groupSum(&m.grps[c.pending])
+	}
+	c.pending = i
+}
+
+// checkRead panics with ErrCorrupted if reads are verified and group ngrp
+// does not match its checksum.
+func (m *Map[K, V]) checkRead(ngrp uint32) {
+	c := m.sums
+	if c.verifyReads && int(ngrp) != c.pending && groupSum(&m.grps[ngrp]) != c.sums[ngrp] {
+		panic(fmt.Errorf("%w in group %d", ErrCorrupted, ngrp))
+	}
+}
+
+// VerifyIntegrity checks the memory of every group against its checksum and
+// returns an error wrapping ErrCorrupted if any of them differ. It returns
+// nil for maps created without WithChecksums.
+func (m *Map[K, V]) VerifyIntegrity() error {
+	c := m.sums
+	if c == nil {
+		return nil
+	}
+	m.modified(-1)
+	bad, first := 0, -1
+	for i := range m.grps {
+		if groupSum(&m.grps[i]) != c.sums[i] {
+			if bad++; first < 0 {
+				first = i
+			}
+		}
+	}
+	if bad > 0 {
+		return fmt.Errorf("%w in %d of %d groups, first at %d", ErrCorrupted, bad, len(m.grps), first)
+	}
+	return nil
+}
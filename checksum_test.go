@@ -0,0 +1,117 @@
+package swiss
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// corrupt flips a bit of the value of a full slot outside the group
+// modified last, and returns its key.
+func corrupt(m *Map[int, int]) int {
+	for i := range m.grps {
+		if i == m.sums.pending {
+			continue
+		}
+		if mask := m.grps[i].maskFull(); mask != 0 {
+			s := &m.grps[i].slts[mask.first()]
+			s.value ^= 1 << 20
+			return s.key
+		}
+	}
+	panic("no full slot")
+}
+
+func TestChecksums(t *testing.T) {
+	t.Parallel()
+	for _, opts := range [][]Option{
+		{WithChecksums(false)},
+		{WithChecksums(false), WithLazyClear(), WithMetadata()},
+	} {
+		m := New[int, int](0, opts...)
+		ref := make(map[int]int)
+		for i := range 20000 {
+			k := rand.Intn(5000)
+			switch rand.Intn(5) {
+			case 0:
+				m.Delete(k)
+				delete(ref, k)
+			case 1:
+				if p := m.GetPtr(k); p != nil {
+					*p = -i
+					ref[k] = -i
+				}
+			case 2:
+				m.Do(k, func(v *int, exists bool) (int, bool, bool) { return i, true, false })
+				ref[k] = i
+			default:
+				m.Put(k, i)
+				ref[k] = i
+			}
+			if i%5000 == 4999 {
+				require.NoError(t, m.VerifyIntegrity())
+			}
+		}
+		require.NoError(t, m.VerifyIntegrity())
+		require.Equal(t, len(ref), m.Len())
+		for k, v := range ref {
+			got, _ := m.Get(k)
+			require.Equal(t, v, got)
+		}
+		c := m.Clone()
+		require.NoError(t, c.VerifyIntegrity())
+		require.NoError(t, m.Union(c, nil).VerifyIntegrity())
+		m.Clear()
+		require.NoError(t, m.VerifyIntegrity())
+		m.Put(1, 1)
+		require.NoError(t, m.VerifyIntegrity())
+
+		corrupt(c)
+		require.ErrorIs(t, c.VerifyIntegrity(), ErrCorrupted)
+	}
+	require.NoError(t, New[int, int](0).VerifyIntegrity())
+}
+
+func TestChecksumsVerifyReads(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithChecksums(true))
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	for i := range 1000 {
+		v, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	key := corrupt(m)
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			require.True(t, errors.Is(err, ErrCorrupted), "%v", err)
+		}()
+		m.Get(key)
+	}()
+	require.ErrorIs(t, m.VerifyIntegrity(), ErrCorrupted)
+
+	// Without verifyReads, reads do not check the groups.
+	m = New[int, int](0, WithChecksums(false))
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	key = corrupt(m)
+	_, ok := m.Get(key)
+	require.True(t, ok)
+}
+
+func TestChecksumsExtendible(t *testing.T) {
+	t.Parallel()
+	e := NewExtendible[int, int](64, WithChecksums(false))
+	for i := range 10000 {
+		e.Put(i%3000, i)
+	}
+	for _, seg := range e.dir {
+		require.NoError(t, seg.m.VerifyIntegrity())
+	}
+}
@@ -0,0 +1,148 @@
+// Command swissdump inspects snapshot files written by Map.Save.
+//
+// Usage:
+//
+//	swissdump [flags] file...
+//
+// For every file it prints the recorded key and value types, their sizes,
+// the number of entries, tombstones and groups, and the load factor. With
+// -entries it also lists the entries, decoding keys and values of basic
+// numeric types and printing all others as hex. The listed entries can be
+// narrowed with -match and -limit.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crn4/swiss"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "swissdump:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("swissdump", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: swissdump [flags] file...")
+		fs.PrintDefaults()
+	}
+	entries := fs.Bool("entries", false, "list the entries")
+	match := fs.String("match", "", "only list entries whose formatted key contains `substring`")
+	limit := fs.Int("limit", 0, "list at most `n` entries, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := swiss.InspectSnapshot(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		printInfo(stdout, path, info)
+		if *entries {
+			printEntries(stdout, info, *match, *limit)
+		}
+	}
+	return nil
+}
+
+func printInfo(w io.Writer, path string, info *swiss.SnapshotInfo) {
+	fmt.Fprintf(w, "%s:\n", path)
+	fmt.Fprintf(w, "  key type:    %s (%d bytes)\n", info.KeyType, info.KeySize)
+	fmt.Fprintf(w, "  value type:  %s (%d bytes)\n", info.ValueType, info.ValueSize)
+	fmt.Fprintf(w, "  entries:     %d\n", info.Len)
+	fmt.Fprintf(w, "  tombstones:  %d\n", info.Tombstones)
+	fmt.Fprintf(w, "  capacity:    %d\n", info.Cap())
+	fmt.Fprintf(w, "  groups:      %d (%d bytes each)\n", info.Groups, info.GroupSize)
+	fmt.Fprintf(w, "  load factor: %.3f\n", info.LoadFactor())
+	fmt.Fprintf(w, "  seed:        %#016x\n", info.Seed)
+}
+
+func printEntries(w io.Writer, info *swiss.SnapshotInfo, match string, limit int) {
+	var n int
+	for k, v := range info.Entries() {
+		key := format(info.KeyType, k)
+		if !strings.Contains(key, match) {
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %s\n", key, format(info.ValueType, value(info, v)))
+		n++
+		if n == limit {
+			return
+		}
+	}
+}
+
+// value strips the padding in front of values of basic types, whose
+// alignment equals their size. The layout of other types is unknown, so
+// they are printed with their padding.
+func value(info *swiss.SnapshotInfo, v []byte) []byte {
+	size, ok := basicSizes[info.ValueType]
+	if !ok || size != info.ValueSize {
+		return v
+	}
+	off := (info.KeySize+size-1)/size*size - info.KeySize
+	return v[off : off+size]
+}
+
+var basicSizes = map[string]int{
+	"bool": 1, "int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4, "float32": 4,
+	"int64": 8, "uint64": 8, "float64": 8,
+	"int": strconv.IntSize / 8, "uint": strconv.IntSize / 8, "uintptr": strconv.IntSize / 8,
+}
+
+// format decodes b as a value of the named basic type, or returns it as hex.
+func format(typ string, b []byte) string {
+	if size, ok := basicSizes[typ]; !ok || size != len(b) {
+		return hex.EncodeToString(b)
+	}
+	var u uint64
+	switch len(b) {
+	case 1:
+		u = uint64(b[0])
+	case 2:
+		u = uint64(binary.NativeEndian.Uint16(b))
+	case 4:
+		u = uint64(binary.NativeEndian.Uint32(b))
+	case 8:
+		u = binary.NativeEndian.Uint64(b)
+	}
+	switch typ {
+	case "bool":
+		return strconv.FormatBool(u != 0)
+	case "int8", "int16", "int32", "int64", "int":
+		shift := 64 - 8*len(b)
+		return strconv.FormatInt(int64(u<<shift)>>shift, 10)
+	case "float32":
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(u))), 'g', -1, 32)
+	case "float64":
+		return strconv.FormatFloat(math.Float64frombits(u), 'g', -1, 64)
+	default:
+		return strconv.FormatUint(u, 10)
+	}
+}
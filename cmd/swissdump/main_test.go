@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crn4/swiss"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pair struct {
+	A uint16
+	B [3]byte
+}
+
+func save[K comparable, V any](t *testing.T, m *swiss.Map[K, V]) string {
+	var buf bytes.Buffer
+	require.NoError(t, m.Save(&buf))
+	path := filepath.Join(t.TempDir(), "snapshot")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	m := swiss.New[int32, int64](0)
+	for i := range int32(20) {
+		m.Put(i-10, int64(i+1)*1000)
+	}
+	path := save(t, m)
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{path}, &out, &out))
+	require.Contains(t, out.String(), "key type:    int32 (4 bytes)")
+	require.Contains(t, out.String(), "value type:  int64 (8 bytes)")
+	require.Contains(t, out.String(), "entries:     20\n")
+	require.NotContains(t, out.String(), "-3:")
+
+	out.Reset()
+	require.NoError(t, run([]string{"-entries", "-match", "-3", path}, &out, &out))
+	require.Contains(t, out.String(), "  -3: 8000\n")
+	require.Equal(t, 1, strings.Count(out.String(), ": 8000"))
+
+	out.Reset()
+	require.NoError(t, run([]string{"-entries", "-limit", "5", path}, &out, &out))
+	require.Equal(t, 5, strings.Count(out.String(), "000\n"))
+}
+
+func TestRunHex(t *testing.T) {
+	t.Parallel()
+	m := swiss.New[uint64, pair](0)
+	m.Put(1, pair{A: 0x0102, B: [3]byte{0xaa, 0xbb, 0xcc}})
+	path := save(t, m)
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"-entries", path}, &out, &out))
+	require.Contains(t, out.String(), "main.pair (6 bytes)")
+	require.Contains(t, out.String(), "aabbcc")
+}
+
+func TestRunInvalid(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "garbage")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{1}, 128), 0o644))
+	var out bytes.Buffer
+	require.ErrorIs(t, run([]string{path}, &out, &out), swiss.ErrInvalidSnapshot)
+	require.Error(t, run(nil, &out, &out))
+}
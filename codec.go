@@ -0,0 +1,112 @@
+package swiss
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"iter"
+	"slices"
+)
+
+// Codec converts values to the bytes stored by a CodecMap and back. Decode
+// only ever sees the output of Encode, so codecs whose decoding can fail on
+// corrupt input may panic in that case.
+type Codec[V any] struct {
+	Encode func(V) []byte
+	Decode func([]byte) V
+}
+
+// CodecMap is a map storing its values encoded by a Codec, typically
+// compressed. Values are encoded on Put and decoded on every Get, trading CPU
+// time for resident memory, which pays off for large, compressible values
+// that are read rarely compared to how long they are kept.
+type CodecMap[K comparable, V any] struct {
+	m     Map[K, []byte]
+	codec Codec[V]
+}
+
+// NewCodecMap creates a new CodecMap with the specified initial size and
+// codec. It accepts the same options as New.
+func NewCodecMap[K comparable, V any](size int, codec Codec[V], opts ...Option) *CodecMap[K, V] {
+	return &CodecMap[K, V]{m: *New[K, []byte](size, opts...), codec: codec}
+}
+
+// Put encodes the value and inserts or updates it. The encoded bytes are
+// copied if their slice has spare capacity, so the map never holds on to
+// more memory than the encoding needs.
+func (c *CodecMap[K, V]) Put(key K, value V) {
+	b := c.codec.Encode(value)
+	if cap(b) > len(b) {
+		b = slices.Clone(b)
+	}
+	c.m.Put(key, b)
+}
+
+// Get retrieves and decodes the value associated with the key.
+func (c *CodecMap[K, V]) Get(key K) (V, bool) {
+	if b, ok := c.m.Get(key); ok {
+		return c.codec.Decode(b), true
+	}
+	var res V
+	return res, false
+}
+
+// Delete removes the key from the map.
+func (c *CodecMap[K, V]) Delete(key K) {
+	c.m.Delete(key)
+}
+
+// Clear removes all entries from the map.
+func (c *CodecMap[K, V]) Clear() {
+	c.m.Clear()
+}
+
+// Len returns the number of entries in the map.
+func (c *CodecMap[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// EncodedSize returns the total size in bytes of the encoded values.
+func (c *CodecMap[K, V]) EncodedSize() int {
+	var n int
+	for _, b := range c.m.All() {
+		n += len(b)
+	}
+	return n
+}
+
+// All returns an iterator over all entries of the map, decoding each value.
+func (c *CodecMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, b := range c.m.All() {
+			if !yield(k, c.codec.Decode(b)) {
+				return
+			}
+		}
+	}
+}
+
+// FlateCodec returns a Codec compressing byte slices with DEFLATE at the
+// given level, as accepted by compress/flate.NewWriter. It panics if the
+// level is invalid.
+func FlateCodec(level int) Codec[[]byte] {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		panic(err)
+	}
+	return Codec[[]byte]{
+		Encode: func(v []byte) []byte {
+			var buf bytes.Buffer
+			w, _ := flate.NewWriter(&buf, level)
+			w.Write(v)
+			w.Close()
+			return buf.Bytes()
+		},
+		Decode: func(b []byte) []byte {
+			v, err := io.ReadAll(flate.NewReader(bytes.NewReader(b)))
+			if err != nil {
+				panic(err)
+			}
+			return v
+		},
+	}
+}
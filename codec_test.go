@@ -0,0 +1,69 @@
+package swiss
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecMap(t *testing.T) {
+	t.Parallel()
+	value := func(i int) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("event %d;", i)), 100)
+	}
+	m := NewCodecMap[uint64, []byte](0, FlateCodec(flate.BestSpeed))
+	var raw int
+	for i := range 1000 {
+		v := value(i)
+		raw += len(v)
+		m.Put(uint64(i), v)
+	}
+	require.Equal(t, 1000, m.Len())
+	require.Less(t, m.EncodedSize()*4, raw)
+	for i := range 1000 {
+		v, ok := m.Get(uint64(i))
+		require.True(t, ok)
+		require.Equal(t, value(i), v)
+	}
+	m.Delete(0)
+	_, ok := m.Get(0)
+	require.False(t, ok)
+	for k, v := range m.All() {
+		require.Equal(t, value(int(k)), v)
+	}
+	m.Clear()
+	require.Zero(t, m.Len())
+
+	require.Panics(t, func() { FlateCodec(42) })
+
+	delta := NewCodecMap[string, []int64](0, Codec[[]int64]{
+		Encode: func(v []int64) []byte {
+			var b []byte
+			var prev int64
+			for _, x := range v {
+				b = binary.AppendVarint(b, x-prev)
+				prev = x
+			}
+			return b
+		},
+		Decode: func(b []byte) []int64 {
+			var v []int64
+			var prev int64
+			for len(b) > 0 {
+				d, n := binary.Varint(b)
+				prev += d
+				v = append(v, prev)
+				b = b[n:]
+			}
+			return v
+		},
+	})
+	delta.Put("a", []int64{1000, 1001, 1003, 1010})
+	v, ok := delta.Get("a")
+	require.True(t, ok)
+	require.Equal(t, []int64{1000, 1001, 1003, 1010}, v)
+}
@@ -0,0 +1,147 @@
+package swiss
+
+// CostCache is a cache whose capacity is a budget in arbitrary units,
+// typically bytes, rather than a number of entries. Every entry is charged
+// the cost computed by a user function when it is put, and least recently
+// used entries are evicted until the total cost fits the budget again.
+// CostCache is not safe for concurrent use.
+type CostCache[K comparable, V any] struct {
+	items   *Map[K, *costEntry[K, V]]
+	head    costEntry[K, V] // head.next is the least recently used entry
+	cost    func(K, V) int64
+	onEvict func(K, V)
+	budget  int64
+	total   int64
+	stats   CacheStats
+}
+
+type costEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	cost       int64
+	prev, next *costEntry[K, V]
+}
+
+// NewCostCache creates a CostCache holding entries of a total cost of at
+// most budget, as computed by cost. If onEvict is not nil it is called for
+// every entry evicted to make room for another one.
+func NewCostCache[K comparable, V any](budget int64, cost func(key K, value V) int64, onEvict func(key K, value V)) *CostCache[K, V] {
+	c := &CostCache[K, V]{
+		items:   New[K, *costEntry[K, V]](0),
+		cost:    cost,
+		onEvict: onEvict,
+		budget:  budget,
+	}
+	c.head.prev, c.head.next = &c.head, &c.head
+	return c
+}
+
+// Get returns the value cached for the key and marks it as most recently
+// used.
+func (c *CostCache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.items.Get(key)
+	c.stats.lookup(ok)
+	if !ok {
+		var res V
+		return res, false
+	}
+	e.unlink()
+	c.pushBack(e)
+	return e.value, true
+}
+
+// Peek returns the value cached for the key without marking it as used or
+// counting the lookup in the statistics.
+func (c *CostCache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.items.Get(key)
+	if !ok {
+		var res V
+		return res, false
+	}
+	return e.value, true
+}
+
+// Put caches the value for the key as the most recently used entry and
+// evicts least recently used entries until the total cost is within the
+// budget. An entry costing more than the whole budget is not cached, and a
+// previous value of the key is removed; Put reports whether the value has
+// been stored.
+func (c *CostCache[K, V]) Put(key K, value V) bool {
+	cost := c.cost(key, value)
+	e, ok := c.items.Get(key)
+	if cost > c.budget {
+		if ok {
+			c.remove(e)
+		}
+		return false
+	}
+	if ok {
+		e.unlink()
+		c.total += cost - e.cost
+		e.value, e.cost = value, cost
+	} else {
+		e = &costEntry[K, V]{key: key, value: value, cost: cost}
+		c.items.Put(key, e)
+		c.total += cost
+	}
+	c.pushBack(e)
+	for c.total > c.budget {
+		victim := c.head.next
+		c.remove(victim)
+		c.stats.Evictions++
+		if c.onEvict != nil {
+			c.onEvict(victim.key, victim.value)
+		}
+	}
+	return true
+}
+
+// Delete removes the key from the cache without calling the eviction
+// callback.
+func (c *CostCache[K, V]) Delete(key K) {
+	if e, ok := c.items.Get(key); ok {
+		c.remove(e)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *CostCache[K, V]) Len() int {
+	return c.items.Len()
+}
+
+// Cost returns the total cost of the cached entries.
+func (c *CostCache[K, V]) Cost() int64 {
+	return c.total
+}
+
+// Budget returns the maximum total cost of the cached entries.
+func (c *CostCache[K, V]) Budget() int64 {
+	return c.budget
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (c *CostCache[K, V]) Stats() CacheStats {
+	return c.stats
+}
+
+// ResetStats sets all counters of the cache to zero.
+func (c *CostCache[K, V]) ResetStats() {
+	c.stats = CacheStats{}
+}
+
+func (c *CostCache[K, V]) remove(e *costEntry[K, V]) {
+	c.items.Delete(e.key)
+	e.unlink()
+	c.total -= e.cost
+}
+
+func (c *CostCache[K, V]) pushBack(e *costEntry[K, V]) {
+	e.prev, e.next = c.head.prev, &c.head
+	c.head.prev.next = e
+	c.head.prev = e
+}
+
+func (e *costEntry[K, V]) unlink() {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil
+}
@@ -0,0 +1,44 @@
+package swiss
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostCache(t *testing.T) {
+	t.Parallel()
+	var evicted []string
+	c := NewCostCache[string, string](100, func(key, value string) int64 {
+		return int64(len(value))
+	}, func(key, value string) {
+		evicted = append(evicted, key)
+	})
+	require.True(t, c.Put("a", strings.Repeat("a", 40)))
+	require.True(t, c.Put("b", strings.Repeat("b", 40)))
+	require.EqualValues(t, 80, c.Cost())
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	require.True(t, c.Put("c", strings.Repeat("c", 30)))
+	require.Equal(t, []string{"b"}, evicted)
+	require.EqualValues(t, 70, c.Cost())
+
+	// Growing a value evicts others, but never the updated entry.
+	require.True(t, c.Put("c", strings.Repeat("c", 70)))
+	require.Equal(t, []string{"b", "a"}, evicted)
+	require.EqualValues(t, 70, c.Cost())
+	require.Equal(t, 1, c.Len())
+
+	require.False(t, c.Put("c", strings.Repeat("c", 101)))
+	require.Zero(t, c.Len())
+	require.Zero(t, c.Cost())
+
+	require.True(t, c.Put("d", "d"))
+	c.Delete("d")
+	_, ok = c.Get("d")
+	require.False(t, ok)
+	require.Zero(t, c.Cost())
+	require.EqualValues(t, 100, c.Budget())
+	require.Equal(t, []string{"b", "a"}, evicted)
+}
@@ -0,0 +1,58 @@
+package swiss
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// ExportCSV writes the entries of the map to w as CSV, one key,value row
+// per entry in iteration order and without a header, formatting keys with
+// keyFmt and values with valFmt. Rows are written as the map is iterated,
+// through a buffer flushed before ExportCSV returns.
+func (m *Map[K, V]) ExportCSV(w io.Writer, keyFmt func(K) string, valFmt func(V) string) error {
+	cw := csv.NewWriter(w)
+	row := make([]string, 2)
+	for k, v := range m.All() {
+		row[0], row[1] = keyFmt(k), valFmt(v)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV reads key,value rows as written by ExportCSV from r, one at a
+// time, and puts them into the map, parsing keys with keyParse and values
+// with valParse. Existing entries are kept unless overwritten. It stops at
+// the first malformed row or parse error, returning an error with its line
+// number; the rows before it have been put. If the growth gate of the map
+// denies growth, ImportCSV returns ErrFull.
+func (m *Map[K, V]) ImportCSV(r io.Reader, keyParse func(string) (K, error), valParse func(string) (V, error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.ReuseRecord = true
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key, err := keyParse(row[0])
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return fmt.Errorf("swiss: CSV key on line %d: %w", line, err)
+		}
+		value, err := valParse(row[1])
+		if err != nil {
+			line, _ := cr.FieldPos(1)
+			return fmt.Errorf("swiss: CSV value on line %d: %w", line, err)
+		}
+		if err := m.TryPut(key, value); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package swiss
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSV(t *testing.T) {
+	t.Parallel()
+	m := New[string, float64](0)
+	m.Put("plain", 1.5)
+	m.Put(`with "quotes", commas`, -2)
+	m.Put("multi\nline", 3e10)
+	var buf bytes.Buffer
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	identity := func(s string) (string, error) { return s, nil }
+	parseFloat := func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	require.NoError(t, m.ExportCSV(&buf, func(k string) string { return k }, formatFloat))
+	require.Contains(t, buf.String(), "plain,1.5\n")
+	require.Contains(t, buf.String(), `"with ""quotes"", commas",-2`)
+
+	c := New[string, float64](0)
+	c.Put("other", 0)
+	require.NoError(t, c.ImportCSV(&buf, identity, parseFloat))
+	require.Equal(t, 4, c.Len())
+	for k, v := range m.All() {
+		got, ok := c.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+
+	err := c.ImportCSV(strings.NewReader("a,1\nb,x\n"), identity, parseFloat)
+	require.ErrorContains(t, err, "line 2")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	require.Error(t, c.ImportCSV(strings.NewReader("a,1,2\n"), identity, parseFloat))
+
+	full := New[string, float64](0, WithGrowthGate(BudgetGate(0)))
+	var rows strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&rows, "k%d,%d\n", i, i)
+	}
+	require.ErrorIs(t, full.ImportCSV(strings.NewReader(rows.String()), identity, parseFloat), ErrFull)
+	require.Equal(t, full.Cap(), full.Len())
+}
@@ -0,0 +1,109 @@
+package swiss
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DecayCounter keeps a score per key that decays exponentially with time,
+// halving every half-life, so that recent events weigh more than old ones,
+// as needed to rank trending keys or to detect abusive clients. Scores are
+// decayed lazily when their key is used, and Prune drops keys whose score
+// has become negligible. DecayCounter is safe for concurrent use.
+type DecayCounter[K comparable] struct {
+	mu     sync.Mutex
+	scores *Map[K, decayScore]
+	rate   float64 // decay rate per nanosecond
+	now    func() time.Time
+	cursor int // next group to be examined by Prune
+}
+
+type decayScore struct {
+	score float64
+	last  int64 // unix nanoseconds of the last decay
+}
+
+// NewDecayCounter creates a DecayCounter whose scores halve every halfLife.
+func NewDecayCounter[K comparable](halfLife time.Duration) *DecayCounter[K] {
+	return &DecayCounter[K]{
+		scores: New[K, decayScore](0),
+		rate:   math.Ln2 / float64(max(halfLife, 1)),
+		now:    time.Now,
+	}
+}
+
+// Add adds n to the score of the key at the current time and returns the
+// new score.
+func (c *DecayCounter[K]) Add(key K, n float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now().UnixNano()
+	var score float64
+	c.scores.Do(key, func(s *decayScore, exists bool) (decayScore, bool, bool) {
+		score = n
+		if exists {
+			score += c.decay(*s, now)
+		}
+		return decayScore{score: score, last: now}, true, false
+	})
+	return score
+}
+
+// Score returns the current score of the key, or 0 if it has none.
+func (c *DecayCounter[K]) Score(key K) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.scores.Get(key)
+	if !ok {
+		return 0
+	}
+	return c.decay(s, c.now().UnixNano())
+}
+
+// Len returns the number of keys with a score, including those that Prune
+// would remove.
+func (c *DecayCounter[K]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.scores.Len()
+}
+
+// Prune examines up to budget keys, continuing from where the previous call
+// stopped, and removes those whose score has decayed below threshold. It
+// returns the number of removed keys.
+func (c *DecayCounter[K]) Prune(budget int, threshold float64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now().UnixNano()
+	var removed, examined int
+	for visited := 0; examined < budget && visited < len(c.scores.grps); visited++ {
+		if c.cursor >= len(c.scores.grps) {
+			c.cursor = 0
+		}
+		group := &c.scores.grps[c.cursor]
+		mask := group.maskFull()
+		for mask != 0 {
+			i := mask.first()
+			if c.decay(group.slts[i].value, now) < threshold {
+				c.scores.deleteAt(group, i)
+				removed++
+			}
+			examined++
+			mask = mask.rmfirst()
+		}
+		c.cursor++
+	}
+	return removed
+}
+
+// Reset removes all scores.
+func (c *DecayCounter[K]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scores.Clear()
+}
+
+func (c *DecayCounter[K]) decay(s decayScore, now int64) float64 {
+	return s.score * math.Exp(-c.rate*float64(max(now-s.last, 0)))
+}
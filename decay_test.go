@@ -0,0 +1,60 @@
+package swiss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDecayCounter[K comparable](halfLife time.Duration) (*DecayCounter[K], *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := NewDecayCounter[K](halfLife)
+	c.now = clock.Now
+	return c, clock
+}
+
+func TestDecayCounter(t *testing.T) {
+	t.Parallel()
+	c, clock := newTestDecayCounter[string](time.Minute)
+	require.Zero(t, c.Score("a"))
+	require.Equal(t, 4.0, c.Add("a", 4))
+	require.Equal(t, 4.0, c.Score("a"))
+
+	clock.Advance(time.Minute)
+	require.InDelta(t, 2, c.Score("a"), 1e-9)
+	require.InDelta(t, 3, c.Add("a", 1), 1e-9)
+	clock.Advance(2 * time.Minute)
+	require.InDelta(t, 0.75, c.Score("a"), 1e-9)
+
+	// A recent burst outweighs an older, larger one.
+	c.Add("b", 10)
+	clock.Advance(5 * time.Minute)
+	c.Add("c", 1)
+	require.Greater(t, c.Score("c"), c.Score("b"))
+	require.Equal(t, 3, c.Len())
+
+	c.Reset()
+	require.Zero(t, c.Len())
+	require.Zero(t, c.Score("c"))
+}
+
+func TestDecayCounterPrune(t *testing.T) {
+	t.Parallel()
+	c, clock := newTestDecayCounter[int](time.Second)
+	for i := range 1000 {
+		c.Add(i, 1)
+	}
+	clock.Advance(10 * time.Second)
+	for i := range 100 {
+		c.Add(i, 1)
+	}
+	var removed int
+	for range 100 {
+		removed += c.Prune(50, 0.01)
+	}
+	require.Equal(t, 900, removed)
+	require.Equal(t, 100, c.Len())
+	require.InDelta(t, 1+1.0/1024, c.Score(5), 1e-9)
+	require.Zero(t, c.Score(500))
+}
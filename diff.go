@@ -0,0 +1,65 @@
+package swiss
+
+// Diff compares m with a newer version of it and returns the change-set
+// turning m into newer: the entries of newer whose keys are missing from m,
+// the keys of m missing from newer, and the entries of newer whose values
+// differ from m according to eq. A nil eq compares values with ==, which
+// panics if the dynamic type of V is not comparable. The results can be
+// passed to ApplyDiff.
+func (m *Map[K, V]) Diff(newer *Map[K, V], eq func(a, b V) bool) (added *Map[K, V], removed *Set[K], changed *Map[K, V]) {
+	if eq == nil {
+		eq = func(a, b V) bool { return any(a) == any(b) }
+	}
+	added, removed, changed = New[K, V](0), NewSet[K](0), New[K, V](0)
+	m.settle()
+	newer.settle()
+	for i := range newer.grps {
+		group := &newer.grps[i]
+		mask := group.maskFull()
+		for mask != 0 {
+			j := mask.first()
+			s := &group.slts[j]
+			if v, ok := m.Get(s.key); !ok {
+				added.Put(s.key, s.value)
+			} else if !eq(v, s.value) {
+				changed.Put(s.key, s.value)
+			}
+			mask = mask.rmfirst()
+		}
+	}
+	for i := range m.grps {
+		group := &m.grps[i]
+		mask := group.maskFull()
+		for mask != 0 {
+			j := mask.first()
+			if _, ok := newer.Get(group.slts[j].key); !ok {
+				removed.Add(group.slts[j].key)
+			}
+			mask = mask.rmfirst()
+		}
+	}
+	return added, removed, changed
+}
+
+// ApplyDiff applies a change-set as returned by Diff: it deletes the removed
+// keys, then stores the added and changed entries. The map is grown once up
+// front, so applying a large change-set does not rehash repeatedly. Any of
+// the arguments may be nil.
+func (m *Map[K, V]) ApplyDiff(added *Map[K, V], removed *Set[K], changed *Map[K, V]) {
+	if removed != nil {
+		for k := range removed.All() {
+			m.Delete(k)
+		}
+	}
+	if added != nil {
+		m.reserve(m.len + added.Len())
+		for k, v := range added.All() {
+			m.Put(k, v)
+		}
+	}
+	if changed != nil {
+		for k, v := range changed.All() {
+			m.Put(k, v)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffApplyDiff(t *testing.T) {
+	t.Parallel()
+	eq := func(a, b int) bool { return a == b }
+	older, newer := New[int, int](0), New[int, int](0)
+	for i := range 10_000 {
+		older.Put(i, i)
+		newer.Put(i, i)
+	}
+	for range 5000 {
+		switch k := randn.Intn(20_000); randn.Intn(3) {
+		case 0:
+			newer.Delete(k)
+		case 1:
+			newer.Put(k, -k)
+		default:
+			newer.Put(k+20_000, k)
+		}
+	}
+	added, removed, changed := older.Diff(newer, eq)
+	for k := range removed.All() {
+		_, ok := newer.Get(k)
+		require.False(t, ok)
+	}
+	for k, v := range changed.All() {
+		old, ok := older.Get(k)
+		require.True(t, ok)
+		require.NotEqual(t, old, v)
+	}
+	for k := range added.All() {
+		_, ok := older.Get(k)
+		require.False(t, ok)
+	}
+
+	older.ApplyDiff(added, removed, changed)
+	require.Equal(t, newer.Len(), older.Len())
+	for k, v := range newer.All() {
+		value, ok := older.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, value)
+	}
+	added, removed, changed = older.Diff(newer, eq)
+	require.Zero(t, added.Len()+removed.Len()+changed.Len())
+	older.ApplyDiff(nil, nil, nil)
+	require.Equal(t, newer.Len(), older.Len())
+}
+
+func TestDiffNilEq(t *testing.T) {
+	t.Parallel()
+	older, newer := New[int, string](0), New[int, string](0)
+	older.Put(1, "a")
+	older.Put(2, "b")
+	newer.Put(1, "a")
+	newer.Put(2, "c")
+	newer.Put(3, "d")
+	added, removed, changed := older.Diff(newer, nil)
+	require.Equal(t, 1, added.Len())
+	require.Zero(t, removed.Len())
+	require.Equal(t, 1, changed.Len())
+	v, _ := changed.Get(2)
+	require.Equal(t, "c", v)
+
+	f := New[int, any](0)
+	f.Put(1, func() {})
+	require.Panics(t, func() { f.Diff(f, nil) })
+}
@@ -0,0 +1,274 @@
+package swiss
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted snapshots wrap a snapshot, or any other stream, in a sequence of
+// chunks sealed with an AEAD provided by the caller, such as AES-GCM from
+// crypto/cipher or chacha20poly1305. The layout is:
+//
+//	header  magic, version, chunk size and nonce prefix, see encryptHeader
+//	chunks  a uint32 length followed by a sealed chunk of at most ChunkSize
+//	        plaintext bytes
+//
+// The nonce of a chunk is the random nonce prefix of the stream, 8 bytes
+// for the usual 12-byte nonces, followed by the number of the chunk as a
+// 32-bit counter, and the header together with a flag marking the last
+// chunk is authenticated with every chunk, so that reordered, dropped,
+// truncated or appended chunks are detected while reading. A stream holds at
+// most 2^32 chunks, 256 TiB. Two streams encrypted with the same key reuse a
+// nonce, which breaks AES-GCM, with a probability of about n^2/2^65 after n
+// streams: a key should be used for at most 2^24 streams, for a probability
+// below 2^-16, and rotated before.
+
+const (
+	encryptVersion   = 2
+	encryptChunkSize = 64 << 10
+	// encryptCounter is the size of the chunk counter of a nonce.
+	encryptCounter = 4
+)
+
+var errChunkCounter = errors.New("swiss: too many chunks in encrypted stream")
+
+var encryptMagic = [8]byte{'S', 'W', 'I', 'S', 'S', 'E', 'N', 'C'}
+
+var errEncryptedChunk = fmt.Errorf("%w: chunk authentication failed", ErrInvalidSnapshot)
+
+type encryptHeader struct {
+	Magic     [8]byte
+	Version   uint32
+	ChunkSize uint32
+}
+
+// SaveEncrypted writes the map to w like Save, encrypted and authenticated
+// with aead. The nonce size of aead must be at least 12 bytes.
+func (m *Map[K, V]) SaveEncrypted(w io.Writer, aead cipher.AEAD) error {
+	ew, err := NewEncryptWriter(w, aead)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(ew); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+// LoadEncrypted reads a snapshot written by SaveEncrypted with the same
+// AEAD key. It returns an error wrapping ErrInvalidSnapshot if the data has
+// been modified, truncated or encrypted with another key.
+func LoadEncrypted[K comparable, V any](r io.Reader, aead cipher.AEAD) (*Map[K, V], error) {
+	dr, err := NewDecryptReader(r, aead)
+	if err != nil {
+		return nil, err
+	}
+	return Load[K, V](dr)
+}
+
+// encryptWriter seals the data written to it in chunks.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	ad     []byte // header followed by the last-chunk flag
+	nonce  []byte // nonce prefix followed by the chunk number
+	buf    []byte
+	out    []byte
+	closed bool
+	// exhausted is set once the chunk counter has been used up.
+	exhausted bool
+}
+
+// NewEncryptWriter returns a writer encrypting the data written to it onto
+// w in the format of SaveEncrypted, for streams other than Save, such as
+// Checkpoint.Finish and SaveIncremental. It writes the header immediately.
+// Close must be called to write the last chunk; it does not close w.
+func NewEncryptWriter(w io.Writer, aead cipher.AEAD) (io.WriteCloser, error) {
+	ns := aead.NonceSize()
+	if ns < 12 {
+		return nil, errors.New("swiss: AEAD nonce size must be at least 12 bytes")
+	}
+	h := encryptHeader{Magic: encryptMagic, Version: encryptVersion, ChunkSize: encryptChunkSize}
+	hdr, err := binary.Append(nil, binary.LittleEndian, &h)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, ns)
+	if _, err := rand.Read(nonce[:ns-encryptCounter]); err != nil {
+		return nil, err
+	}
+	hdr = append(hdr, nonce[:ns-encryptCounter]...)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:     w,
+		aead:  aead,
+		ad:    append(hdr, 0),
+		nonce: nonce,
+		buf:   make([]byte, 0, encryptChunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("swiss: write to closed encrypt writer")
+	}
+	var n int
+	for len(p) > 0 {
+		if len(e.buf) == cap(e.buf) {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the buffered data as the last chunk, which may be empty.
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.exhausted {
+		return errChunkCounter
+	}
+	if last {
+		e.ad[len(e.ad)-1] = 1
+	}
+	e.out = binary.LittleEndian.AppendUint32(e.out[:0], uint32(len(e.buf)+e.aead.Overhead()))
+	e.out = e.aead.Seal(e.out, e.nonce, e.buf, e.ad)
+	e.buf = e.buf[:0]
+	e.exhausted = !nextChunk(e.nonce)
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// nextChunk increments the chunk counter of the nonce and reports false if
+// it wraps around, since the nonce would then repeat.
+func nextChunk(nonce []byte) bool {
+	counter := nonce[len(nonce)-encryptCounter:]
+	n := binary.BigEndian.Uint32(counter) + 1
+	binary.BigEndian.PutUint32(counter, n)
+	return n != 0
+}
+
+// decryptReader opens the chunks written by an encryptWriter.
+type decryptReader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	ad    []byte
+	nonce []byte
+	max   int // largest sealed chunk
+	in    []byte
+	out   []byte
+	buf   []byte // plaintext not read yet
+	done  bool
+	// exhausted is set once the chunk counter has been used up.
+	exhausted bool
+}
+
+// NewDecryptReader returns a reader decrypting a stream written by
+// NewEncryptWriter or SaveEncrypted from r. It reads the header immediately.
+// Reads fail with an error wrapping ErrInvalidSnapshot once the stream turns
+// out to be modified or truncated, so data read before the end of the
+// stream is only authenticated if it is followed by io.EOF.
+func NewDecryptReader(r io.Reader, aead cipher.AEAD) (io.Reader, error) {
+	ns := aead.NonceSize()
+	if ns < 12 {
+		return nil, errors.New("swiss: AEAD nonce size must be at least 12 bytes")
+	}
+	br := bufio.NewReader(r)
+	hdr := make([]byte, binary.Size(encryptHeader{})+ns-encryptCounter)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	var h encryptHeader
+	if _, err := binary.Decode(hdr, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	switch {
+	case h.Magic != encryptMagic:
+		return nil, fmt.Errorf("%w: not an encrypted snapshot", ErrInvalidSnapshot)
+	case h.Version != encryptVersion:
+		return nil, fmt.Errorf("%w: unsupported encryption version %d", ErrInvalidSnapshot, h.Version)
+	case h.ChunkSize == 0 || h.ChunkSize > 64<<20:
+		return nil, fmt.Errorf("%w: invalid chunk size %d", ErrInvalidSnapshot, h.ChunkSize)
+	}
+	nonce := make([]byte, ns)
+	copy(nonce, hdr[binary.Size(encryptHeader{}):])
+	return &decryptReader{
+		r:     br,
+		aead:  aead,
+		ad:    append(hdr, 0),
+		nonce: nonce,
+		max:   int(h.ChunkSize) + aead.Overhead(),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// open reads and opens the next chunk.
+func (d *decryptReader) open() error {
+	if d.exhausted {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, errChunkCounter)
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return fmt.Errorf("%w: truncated stream", ErrInvalidSnapshot)
+	}
+	n := int(binary.LittleEndian.Uint32(size[:]))
+	if n > d.max || n < d.aead.Overhead() {
+		return fmt.Errorf("%w: invalid chunk length %d", ErrInvalidSnapshot, n)
+	}
+	if cap(d.in) < n {
+		d.in = make([]byte, d.max)
+	}
+	d.in = d.in[:n]
+	if _, err := io.ReadFull(d.r, d.in); err != nil {
+		return fmt.Errorf("%w: truncated stream", ErrInvalidSnapshot)
+	}
+	// A chunk is the last one if it opens with the flag set. Open may clear
+	// its output on failure, so it must not decrypt in place.
+	d.ad[len(d.ad)-1] = 0
+	plain, err := d.aead.Open(d.out[:0], d.nonce, d.in, d.ad)
+	if err != nil {
+		d.ad[len(d.ad)-1] = 1
+		if plain, err = d.aead.Open(d.out[:0], d.nonce, d.in, d.ad); err != nil {
+			return errEncryptedChunk
+		}
+		if _, err := d.r.ReadByte(); err != io.EOF {
+			return fmt.Errorf("%w: data after the last chunk", ErrInvalidSnapshot)
+		}
+		d.done = true
+	}
+	d.out, d.buf = plain, plain
+	d.exhausted = !nextChunk(d.nonce)
+	return nil
+}
@@ -0,0 +1,111 @@
+package swiss
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testAEAD(t *testing.T, key byte) cipher.AEAD {
+	t.Helper()
+	block, err := aes.NewCipher(bytes.Repeat([]byte{key}, 32))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead
+}
+
+func TestSaveLoadEncrypted(t *testing.T) {
+	t.Parallel()
+	aead := testAEAD(t, 1)
+	for _, size := range []int{0, 100, 100_000} {
+		m := New[int, int](0)
+		for i := range size {
+			m.Put(i, -i)
+		}
+		var plain, buf bytes.Buffer
+		require.NoError(t, m.Save(&plain))
+		require.NoError(t, m.SaveEncrypted(&buf, aead))
+		require.False(t, bytes.Contains(buf.Bytes(), plain.Bytes()[:64]))
+		loaded, err := LoadEncrypted[int, int](bytes.NewReader(buf.Bytes()), aead)
+		require.NoError(t, err)
+		requireSameEntries(t, m, loaded)
+	}
+}
+
+func TestLoadEncryptedInvalid(t *testing.T) {
+	t.Parallel()
+	aead := testAEAD(t, 1)
+	m := New[int, int](0)
+	for i := range 20_000 {
+		m.Put(i, i)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, m.SaveEncrypted(&buf, aead))
+	data := buf.Bytes()
+	require.Greater(t, len(data), 3*encryptChunkSize)
+
+	load := func(data []byte, aead cipher.AEAD) error {
+		_, err := LoadEncrypted[int, int](bytes.NewReader(data), aead)
+		return err
+	}
+	require.ErrorIs(t, load(data, testAEAD(t, 2)), ErrInvalidSnapshot)
+	for _, n := range []int{10, len(data) / 2, len(data) - 1} {
+		require.ErrorIs(t, load(data[:n], aead), ErrInvalidSnapshot)
+	}
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)/2] ^= 1
+	require.ErrorIs(t, load(flipped, aead), ErrInvalidSnapshot)
+	require.ErrorIs(t, load(append(bytes.Clone(data), 0), aead), ErrInvalidSnapshot)
+
+	// Dropping a whole chunk breaks the chunk numbering.
+	hdr := 16 + aead.NonceSize() - encryptCounter
+	chunk := 4 + encryptChunkSize + aead.Overhead()
+	dropped := append(bytes.Clone(data[:hdr+chunk]), data[hdr+2*chunk:]...)
+	require.ErrorIs(t, load(dropped, aead), ErrInvalidSnapshot)
+	// Truncating at a chunk boundary loses the last-chunk flag.
+	require.ErrorIs(t, load(data[:hdr+2*chunk], aead), ErrInvalidSnapshot)
+}
+
+func TestEncryptWriterStream(t *testing.T) {
+	t.Parallel()
+	aead := testAEAD(t, 3)
+	m := New[int, int](0)
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, aead)
+	require.NoError(t, err)
+	require.NoError(t, m.SaveIncremental(w))
+	require.NoError(t, w.Close())
+
+	r, err := NewDecryptReader(bytes.NewReader(buf.Bytes()), aead)
+	require.NoError(t, err)
+	loaded, err := LoadIncremental[int, int](r)
+	require.NoError(t, err)
+	requireSameEntries(t, m, loaded)
+	n, err := r.Read(make([]byte, 1))
+	require.Zero(t, n)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestEncryptChunkCounter(t *testing.T) {
+	t.Parallel()
+	aead := testAEAD(t, 4)
+	w, err := NewEncryptWriter(io.Discard, aead)
+	require.NoError(t, err)
+	ew := w.(*encryptWriter)
+	// Every nonce has an 8-byte random prefix.
+	require.Len(t, ew.ad, 16+8+1)
+	// The two last chunk numbers are left, the third chunk must fail.
+	binary.BigEndian.PutUint32(ew.nonce[len(ew.nonce)-encryptCounter:], 1<<32-2)
+	_, err = w.Write(make([]byte, 2*encryptChunkSize+1))
+	require.NoError(t, err)
+	require.ErrorIs(t, w.Close(), errChunkCounter)
+}
@@ -0,0 +1,188 @@
+package swiss
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// Extendible is a map built from fixed-size segments, each an ordinary
+// table, addressed through a directory by the top bits of the hash, as in
+// extendible hashing. When a segment fills up only that segment is split in
+// two, doubling the directory of segment pointers if needed, so the cost of
+// growth is bounded by the size of one segment instead of the whole table
+// and no allocation is ever larger than a segment. Extendible is not safe
+// for concurrent use.
+type Extendible[K comparable, V any] struct {
+	dir   []*extSegment[K, V]
+	depth uint8 // number of hash bits used to index dir
+	proto *Map[K, V]
+	size  int
+	len   int
+}
+
+// extMaxDepth bounds the directory to 16M entries. Segments whose keys share
+// more hash bits, which only happens with a broken hash function, grow like
+// an ordinary map instead of splitting.
+const extMaxDepth = 24
+
+type extSegment[K comparable, V any] struct {
+	m     *Map[K, V]
+	depth uint8 // number of hash bits shared by all keys of the segment
+}
+
+// NewExtendible creates an Extendible map whose segments hold up to
+// segmentSize entries. A non-positive segmentSize selects 64K entries. It
+// accepts the same options as New, which apply to every segment, except
+// that a ProbeMonitor never reseeds.
+func NewExtendible[K comparable, V any](segmentSize int, opts ...Option) *Extendible[K, V] {
+	if segmentSize <= 0 {
+		segmentSize = 1 << 16
+	}
+	proto := New[K, V](0, opts...)
+	if pm := proto.monitor; pm != nil && pm.Reseed {
+		// Segments must keep the seed the directory is built on.
+		c := *pm
+		c.Reseed = false
+		proto.monitor = &c
+	}
+	return &Extendible[K, V]{
+		dir:   []*extSegment[K, V]{{m: proto.emptyLike(segmentSize)}},
+		proto: proto,
+		size:  segmentSize,
+	}
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (e *Extendible[K, V]) Put(key K, value V) {
+	if e.proto.normalize != nil {
+		key = e.proto.normalize(key)
+	}
+	hash := e.hash(key)
+	seg := e.segment(hash)
+	if s := seg.m.lookupHash(key, hash); s != nil {
+		seg.m.modifying(seg.m.groupOf(s))
+		s.value = value
+		return
+	}
+	for seg.m.len >= seg.m.cap && seg.depth < extMaxDepth {
+		if seg.m.tombstones >= seg.m.cap/2 {
+			seg.m.rehash()
+			continue
+		}
+		e.split(seg)
+		seg = e.segment(hash)
+	}
+	if err := seg.m.putHash(key, value, hash); err != nil {
+		panic(err)
+	}
+	e.len++
+}
+
+// Get retrieves the value associated with the key.
+func (e *Extendible[K, V]) Get(key K) (V, bool) {
+	if e.proto.normalize != nil {
+		key = e.proto.normalize(key)
+	}
+	hash := e.hash(key)
+	if s := e.segment(hash).m.lookupHash(key, hash); s != nil {
+		return s.value, true
+	}
+	var res V
+	return res, false
+}
+
+// Delete removes the key from the map.
+func (e *Extendible[K, V]) Delete(key K) {
+	if e.proto.normalize != nil {
+		key = e.proto.normalize(key)
+	}
+	hash := e.hash(key)
+	if e.segment(hash).m.deleteHash(key, hash) {
+		e.len--
+	}
+}
+
+// Len returns the number of entries in the map.
+func (e *Extendible[K, V]) Len() int {
+	return e.len
+}
+
+// Segments returns the number of segments of the map.
+func (e *Extendible[K, V]) Segments() int {
+	var n int
+	for range e.segments() {
+		n++
+	}
+	return n
+}
+
+// All returns an iterator over all entries of the map.
+func (e *Extendible[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for seg := range e.segments() {
+			for k, v := range seg.m.All() {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// segments iterates over the distinct segments. A segment of local depth d
+// occupies 2^(depth-d) consecutive directory entries.
+func (e *Extendible[K, V]) segments() iter.Seq[*extSegment[K, V]] {
+	return func(yield func(*extSegment[K, V]) bool) {
+		for i := 0; i < len(e.dir); i += 1 << (e.depth - e.dir[i].depth) {
+			if !yield(e.dir[i]) {
+				return
+			}
+		}
+	}
+}
+
+func (e *Extendible[K, V]) hash(key K) uintptr {
+	return e.proto.hashKey(key)
+}
+
+func (e *Extendible[K, V]) segment(hash uintptr) *extSegment[K, V] {
+	return e.dir[hash>>(bits.UintSize-uint(e.depth))]
+}
+
+// split distributes the entries of seg over two segments by the next hash
+// bit and updates the directory, doubling it if seg was the only segment
+// using all of its bits.
+func (e *Extendible[K, V]) split(seg *extSegment[K, V]) {
+	if seg.depth == e.depth {
+		dir := make([]*extSegment[K, V], 2*len(e.dir))
+		for i, s := range e.dir {
+			dir[2*i], dir[2*i+1] = s, s
+		}
+		e.dir = dir
+		e.depth++
+	}
+	var halves [2]*extSegment[K, V]
+	for i := range halves {
+		halves[i] = &extSegment[K, V]{m: e.proto.emptyLike(e.size), depth: seg.depth + 1}
+	}
+	bit := bits.UintSize - 1 - uint(seg.depth)
+	seg.m.settle()
+	for i := range seg.m.grps {
+		group := &seg.m.grps[i]
+		mask := group.maskFull()
+		for mask != 0 {
+			s := &group.slts[mask.first()]
+			hash := e.hash(s.key)
+			if err := halves[hash>>bit&1].m.putHash(s.key, s.value, hash); err != nil {
+				panic(err)
+			}
+			mask = mask.rmfirst()
+		}
+	}
+	shift := e.depth - 1 - seg.depth
+	for i, s := range e.dir {
+		if s == seg {
+			e.dir[i] = halves[i>>shift&1]
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendible(t *testing.T) {
+	t.Parallel()
+	e := NewExtendible[int, int](100)
+	expected := make(map[int]int)
+	for range 100_000 {
+		k := randn.Intn(50_000)
+		switch randn.Intn(4) {
+		case 0:
+			e.Delete(k)
+			delete(expected, k)
+		default:
+			e.Put(k, -k)
+			expected[k] = -k
+		}
+	}
+	require.Equal(t, len(expected), e.Len())
+	require.Greater(t, e.Segments(), len(expected)/100)
+	for k := range 50_000 {
+		v, ok := e.Get(k)
+		ev, eok := expected[k]
+		require.Equal(t, eok, ok)
+		require.Equal(t, ev, v)
+	}
+	var cnt int
+	for k, v := range e.All() {
+		require.Equal(t, -k, v)
+		cnt++
+	}
+	require.Equal(t, len(expected), cnt)
+	for seg := range e.segments() {
+		require.LessOrEqual(t, seg.m.Len(), seg.m.Cap())
+		require.Equal(t, groupsnum(100), len(seg.m.grps))
+	}
+}
+
+func TestExtendibleDefaultSize(t *testing.T) {
+	t.Parallel()
+	e := NewExtendible[string, int](0, WithProbeMonitor(ProbeMonitor{Threshold: 1, Reseed: true}))
+	for i := range 1000 {
+		e.Put(genRandomString(8), i)
+	}
+	require.Equal(t, 1, e.Segments())
+	require.Equal(t, 1000, e.Len())
+}
+
+func TestExtendibleEqualHashes(t *testing.T) {
+	t.Parallel()
+	e := NewExtendible[int, int](10)
+	e.proto.hashfn = func(unsafe.Pointer, uintptr) uintptr { return 0 }
+	e.proto.hkind = hashIndirect
+	for seg := range e.segments() {
+		seg.m.hashfn, seg.m.hkind = e.proto.hashfn, hashIndirect
+	}
+	for i := range 100 {
+		e.Put(i, i)
+	}
+	require.Equal(t, 100, e.Len())
+	for i := range 100 {
+		v, ok := e.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	require.Equal(t, uint8(extMaxDepth), e.depth)
+}
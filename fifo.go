@@ -0,0 +1,155 @@
+package swiss
+
+// FIFO is a fixed-capacity cache evicting entries in insertion order. Keys
+// are kept in a ring buffer and indexed by a swiss map, so inserting a new
+// key and evicting the oldest one are both O(1). Updating the value of a
+// cached key does not change its position. FIFO is not safe for concurrent
+// use.
+type FIFO[K comparable, V any] struct {
+	items    *Map[K, fifoEntry[V]]
+	ring     []fifoSlot[K] // twice the capacity, see compact
+	capacity int
+	onEvict  func(K, V)
+	head     int // position of the oldest slot
+	n        int // number of used ring positions, including deleted ones
+	stats    CacheStats
+}
+
+type fifoEntry[V any] struct {
+	value V
+	pos   int
+}
+
+type fifoSlot[K comparable] struct {
+	key  K
+	live bool
+}
+
+// NewFIFO creates a FIFO cache holding at most capacity entries. If onEvict
+// is not nil it is called for every entry evicted to make room for a new
+// one. A non-positive capacity is treated as 1.
+func NewFIFO[K comparable, V any](capacity int, onEvict func(key K, value V)) *FIFO[K, V] {
+	capacity = max(capacity, 1)
+	return &FIFO[K, V]{
+		items:    New[K, fifoEntry[V]](capacity),
+		ring:     make([]fifoSlot[K], 2*capacity),
+		capacity: capacity,
+		onEvict:  onEvict,
+	}
+}
+
+// Get returns the value cached for the key.
+func (c *FIFO[K, V]) Get(key K) (V, bool) {
+	e, ok := c.items.Get(key)
+	c.stats.lookup(ok)
+	return e.value, ok
+}
+
+// Peek returns the value cached for the key without counting the lookup in
+// the statistics.
+func (c *FIFO[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.items.Get(key)
+	return e.value, ok
+}
+
+// Put caches the value for the key. A new key evicts the oldest entry if
+// the cache is full.
+func (c *FIFO[K, V]) Put(key K, value V) {
+	if e, ok := c.items.Get(key); ok {
+		e.value = value
+		c.items.Put(key, e)
+		return
+	}
+	if c.items.Len() == c.capacity {
+		c.evict()
+	}
+	if c.n == len(c.ring) {
+		c.compact()
+	}
+	pos := c.head + c.n
+	if pos >= len(c.ring) {
+		pos -= len(c.ring)
+	}
+	c.ring[pos] = fifoSlot[K]{key: key, live: true}
+	c.n++
+	c.items.Put(key, fifoEntry[V]{value: value, pos: pos})
+}
+
+// Delete removes the key from the cache without calling the eviction
+// callback.
+func (c *FIFO[K, V]) Delete(key K) {
+	e, ok := c.items.Get(key)
+	if !ok {
+		return
+	}
+	c.items.Delete(key)
+	c.ring[e.pos] = fifoSlot[K]{}
+}
+
+// Len returns the number of cached entries.
+func (c *FIFO[K, V]) Len() int {
+	return c.items.Len()
+}
+
+// Cap returns the maximum number of cached entries.
+func (c *FIFO[K, V]) Cap() int {
+	return c.capacity
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (c *FIFO[K, V]) Stats() CacheStats {
+	return c.stats
+}
+
+// ResetStats sets all counters of the cache to zero.
+func (c *FIFO[K, V]) ResetStats() {
+	c.stats = CacheStats{}
+}
+
+// evict removes the oldest live entry, dropping the deleted slots in front
+// of it.
+func (c *FIFO[K, V]) evict() {
+	for c.n > 0 {
+		s := c.ring[c.head]
+		c.ring[c.head] = fifoSlot[K]{}
+		c.head++
+		if c.head == len(c.ring) {
+			c.head = 0
+		}
+		c.n--
+		if s.live {
+			e, _ := c.items.Get(s.key)
+			c.items.Delete(s.key)
+			c.stats.Evictions++
+			if c.onEvict != nil {
+				c.onEvict(s.key, e.value)
+			}
+			return
+		}
+	}
+}
+
+// compact moves the live slots to the front of the used range, reclaiming
+// the positions of deleted entries. The ring is full when it is called, and
+// since it has twice as many positions as the cache has entries at least
+// half of them are deleted, which amortizes the cost of compaction over
+// the deletes.
+func (c *FIFO[K, V]) compact() {
+	var w int
+	for i := range c.n {
+		s := c.ring[(c.head+i)%len(c.ring)]
+		if !s.live {
+			continue
+		}
+		pos := (c.head + w) % len(c.ring)
+		c.ring[pos] = s
+		e, _ := c.items.Get(s.key)
+		e.pos = pos
+		c.items.Put(s.key, e)
+		w++
+	}
+	for i := w; i < c.n; i++ {
+		c.ring[(c.head+i)%len(c.ring)] = fifoSlot[K]{}
+	}
+	c.n = w
+}
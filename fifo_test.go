@@ -0,0 +1,105 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFIFOEvictionOrder(t *testing.T) {
+	t.Parallel()
+	var evicted []int
+	c := NewFIFO[int, int](3, func(key, value int) {
+		require.Equal(t, key, value)
+		evicted = append(evicted, key)
+	})
+	for key := 1; key <= 5; key++ {
+		c.Put(key, key)
+	}
+	require.Equal(t, []int{1, 2}, evicted)
+	c.Put(3, 3)
+	require.Equal(t, 3, c.Len())
+	c.Put(6, 6)
+	require.Equal(t, []int{1, 2, 3}, evicted)
+	for _, key := range []int{4, 5, 6} {
+		value, ok := c.Get(key)
+		require.True(t, ok)
+		require.Equal(t, key, value)
+	}
+	require.Equal(t, 3, c.Cap())
+}
+
+func TestFIFODeleteKeepsCapacity(t *testing.T) {
+	t.Parallel()
+	var evicted []int
+	c := NewFIFO[int, int](4, func(key, _ int) {
+		evicted = append(evicted, key)
+	})
+	for key := 1; key <= 4; key++ {
+		c.Put(key, key)
+	}
+	c.Delete(2)
+	c.Delete(2)
+	c.Put(5, 5)
+	require.Empty(t, evicted)
+	require.Equal(t, 4, c.Len())
+	c.Put(6, 6)
+	require.Equal(t, []int{1}, evicted)
+	c.Put(7, 7)
+	require.Equal(t, []int{1, 3}, evicted)
+}
+
+func TestFIFORandomActions(t *testing.T) {
+	t.Parallel()
+	capacity := 100
+	c := NewFIFO[int, int](capacity, nil)
+	var order []int
+	for range 100_000 {
+		key := randn.Intn(300)
+		if randn.Intn(4) == 0 {
+			c.Delete(key)
+			for i, k := range order {
+				if k == key {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		if _, ok := c.Get(key); !ok {
+			order = append(order, key)
+			if len(order) > capacity {
+				order = order[1:]
+			}
+		}
+		c.Put(key, key)
+		require.Equal(t, len(order), c.Len())
+	}
+	for _, key := range order {
+		_, ok := c.Get(key)
+		require.True(t, ok)
+	}
+}
+
+func TestFIFOCompactionAmortized(t *testing.T) {
+	t.Parallel()
+	capacity := 100
+	c := NewFIFO[int, int](capacity, nil)
+	for key := range capacity {
+		c.Put(key, key)
+	}
+	// Deleting the newest key and adding another fills the ring with deleted
+	// slots; compactions must be rare enough to cost O(1) per Put.
+	var compactions int
+	for key := capacity; key < 100*capacity; key++ {
+		c.Delete(key - 1)
+		n := c.n
+		c.Put(key, key)
+		if c.n <= n {
+			compactions++
+		}
+	}
+	require.LessOrEqual(t, compactions, 100)
+	require.Equal(t, capacity, c.Len())
+}
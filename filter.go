@@ -0,0 +1,57 @@
+package swiss
+
+import "math/bits"
+
+// filter is a blocked Bloom filter with one 64-bit word per group. A key
+// sets two bits in the word of its home group, the group its probe sequence
+// starts from, so a lookup that finds any of them clear can return without
+// touching the groups. Bits are taken from the part of the hash that is not
+// used for group selection on 64-bit platforms.
+//
+// Deleted keys cannot be removed from the filter, their bits stay set until
+// it is rebuilt: on rehash, on Clear, and by rebuildFilter once there have
+// been more deletes than the map has capacity, since deletes that leave
+// empty slots never trigger a rehash and would let the filter saturate.
+type filter []uint64
+
+func newFilter(ngroups int) filter {
+	return make(filter, ngroups)
+}
+
+func filterBits(hash uintptr) uint64 {
+	return 1<<((hash>>(bits.UintSize-24))&63) | 1<<((hash>>(bits.UintSize-18))&63)
+}
+
+func (f filter) add(ngrp uint32, hash uintptr) {
+	f[ngrp] |= filterBits(hash)
+}
+
+// mayContain reports false if a key with the given hash is definitely not
+// present in the map.
+func (f filter) mayContain(ngrp uint32, hash uintptr) bool {
+	fb := filterBits(hash)
+	return f[ngrp]&fb == fb
+}
+
+func (f filter) reset() {
+	clear(f)
+}
+
+// rebuildFilter recomputes the filter from the keys of the map, dropping the
+// bits of deleted keys. Its cost of hashing every key again is amortized
+// over the deletes that trigger it.
+func (m *Map[K, V]) rebuildFilter() {
+	m.filter.reset()
+	m.filterDeletes = 0
+	for i := range m.grps {
+		if m.stale(uint32(i)) {
+			continue
+		}
+		mask := m.grps[i].maskFull()
+		for mask != 0 {
+			hash := m.hashKey(m.grps[i].slts[mask.first()].key)
+			m.filter.add(uint32(m.h1(hash))%m.ngroups, hash)
+			mask = mask.rmfirst()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterNoFalseNegatives(t *testing.T) {
+	t.Parallel()
+	size := 100_000
+	actual := New[int, int](size/10, WithFilter())
+	expected := make(map[int]int, size)
+	for range size {
+		switch rnd := randn.Intn(100); {
+		case rnd < 70:
+			k, v := randn.Int(), randn.Int()
+			actual.Put(k, v)
+			expected[k] = v
+		default:
+			var k int
+			for k = range expected {
+				break
+			}
+			delete(expected, k)
+			actual.Delete(k)
+		}
+	}
+	require.Len(t, actual.filter, len(actual.grps))
+	for k, v := range expected {
+		value, ok := actual.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, value)
+	}
+	for range size {
+		k := randn.Int()
+		_, ok := actual.Get(k)
+		_, exp := expected[k]
+		require.Equal(t, exp, ok)
+	}
+}
+
+func TestFilterRejectsAbsentKeys(t *testing.T) {
+	t.Parallel()
+	size := 100_000
+	m := New[int, int](size, WithFilter())
+	for _, key := range genIntKeys(size) {
+		m.Put(key, key)
+	}
+	var rejected int
+	for _, key := range genIntKeys(size) {
+		hash := uintptr(m.Hash(key))
+		if !m.filter.mayContain(uint32(m.h1(hash))%m.ngroups, hash) {
+			rejected++
+		}
+	}
+	assert.Greater(t, rejected, size*9/10)
+	m.Clear()
+	for _, word := range m.filter {
+		require.Zero(t, word)
+	}
+}
+
+func TestFilterChurn(t *testing.T) {
+	t.Parallel()
+	// Replacing the keys over and over leaves empty slots rather than
+	// tombstones, so the map never rehashes; the filter must not saturate.
+	live := 1000
+	m := New[int, int](live, WithFilter())
+	for key := range 100 * live {
+		m.Put(key, key)
+		if key >= live {
+			m.Delete(key - live)
+		}
+	}
+	require.Equal(t, live, m.Len())
+	var rejected int
+	for key := -1; key >= -live; key-- {
+		hash := uintptr(m.Hash(key))
+		if !m.filter.mayContain(uint32(m.h1(hash))%m.ngroups, hash) {
+			rejected++
+		}
+	}
+	assert.Greater(t, rejected, live*3/4)
+	for key := 99 * live; key < 100*live; key++ {
+		_, ok := m.Get(key)
+		require.True(t, ok)
+	}
+}
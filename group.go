@@ -0,0 +1,20 @@
+package swiss
+
+import (
+	"unsafe"
+
+	"github.com/crn4/swiss/internal/layout"
+)
+
+func init() {
+	layout.Groups = func(m any) any {
+		return m.(interface{ layoutGroups() any }).layoutGroups()
+	}
+}
+
+// layoutGroups returns the groups of the map as a []layout.Group[K, V] for
+// x/group, which has no access to the group type.
+func (m *Map[K, V]) layoutGroups() any {
+	m.settle()
+	return unsafe.Slice((*layout.Group[K, V])(unsafe.Pointer(unsafe.SliceData(m.grps))), len(m.grps))
+}
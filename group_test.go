@@ -0,0 +1,43 @@
+package swiss
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/crn4/swiss/internal/layout"
+	"github.com/stretchr/testify/require"
+)
+
+func requireSameLayout[K comparable, V any](t *testing.T) {
+	t.Helper()
+	var g group[K, V]
+	var l layout.Group[K, V]
+	require.Equal(t, unsafe.Sizeof(g), unsafe.Sizeof(l))
+	require.Equal(t, unsafe.Offsetof(g.slts), unsafe.Offsetof(l.Slots))
+	require.Equal(t, unsafe.Offsetof(g.slts[0].value), unsafe.Offsetof(l.Slots[0].Value))
+	require.Equal(t, unsafe.Sizeof(g.slts[0]), unsafe.Sizeof(l.Slots[0]))
+}
+
+func TestLayoutGroups(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, grpssz, layout.GroupSize)
+	require.Equal(t, kEmpty, layout.Empty)
+	require.Equal(t, kDeleted, layout.Deleted)
+	requireSameLayout[int, int](t)
+	requireSameLayout[uint8, string](t)
+	requireSameLayout[string, struct{}](t)
+	requireSameLayout[[3]byte, [5]uint16](t)
+
+	m := New[int, int](100)
+	for i := range 100 {
+		m.Put(i, -i)
+	}
+	grps := layout.Groups(m).([]layout.Group[int, int])
+	require.Len(t, grps, len(m.grps))
+	for i := range grps {
+		require.Equal(t, uint64(m.grps[i].cntrl), grps[i].Control)
+		for j := range grpssz {
+			require.Equal(t, m.grps[i].slts[j].key, grps[i].Slots[j].Key)
+		}
+	}
+}
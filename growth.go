@@ -0,0 +1,63 @@
+package swiss
+
+import (
+	"errors"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"unsafe"
+)
+
+// ErrFull is returned by TryPut, and Put panics with it, when a new entry
+// needs the map to grow and the growth gate denies it.
+var ErrFull = errors.New("swiss: map is full")
+
+// GrowthGate decides whether a map may grow from currentBytes to nextBytes
+// of group memory. Both tables are alive while the entries are moved.
+type GrowthGate func(currentBytes, nextBytes uint64) bool
+
+// BudgetGate returns a GrowthGate allowing a map to grow as long as its
+// groups take at most budget bytes.
+func BudgetGate(budget uint64) GrowthGate {
+	return func(_, next uint64) bool {
+		return next <= budget
+	}
+}
+
+// MemoryLimitGate returns a GrowthGate denying growth when allocating the
+// new table would bring the memory used by the Go runtime within headroom
+// bytes of the limit set by GOMEMLIMIT or debug.SetMemoryLimit. Without a
+// limit growth is always allowed. The gate may be shared by maps growing
+// concurrently.
+func MemoryLimitGate(headroom uint64) GrowthGate {
+	return func(_, next uint64) bool {
+		limit := debug.SetMemoryLimit(-1)
+		if limit == math.MaxInt64 {
+			return true
+		}
+		samples := []metrics.Sample{
+			{Name: "/memory/classes/total:bytes"},
+			{Name: "/memory/classes/heap/released:bytes"},
+		}
+		metrics.Read(samples)
+		used := samples[0].Value.Uint64() - samples[1].Value.Uint64()
+		return used+next+headroom <= uint64(limit)
+	}
+}
+
+// allowGrowth consults the growth gate before a rehash that grows the map.
+// Rehashes draining tombstones without growing are always allowed.
+func (m *Map[K, V]) allowGrowth() bool {
+	next, _ := m.growTarget()
+	return m.allowGrowthTo(next)
+}
+
+// allowGrowthTo consults the growth gate before growing the map to ngroups
+// groups.
+func (m *Map[K, V]) allowGrowthTo(ngroups int) bool {
+	if m.gate == nil || ngroups <= len(m.grps) {
+		return true
+	}
+	size := uint64(unsafe.Sizeof(group[K, V]{}))
+	return m.gate(uint64(len(m.grps))*size, uint64(ngroups)*size)
+}
@@ -0,0 +1,107 @@
+package swiss
+
+import (
+	"math"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrowthGate(t *testing.T) {
+	t.Parallel()
+	gsize := uint64(unsafe.Sizeof(group[int, int]{}))
+	var calls int
+	budget := BudgetGate(4 * gsize)
+	m := New[int, int](0, WithGrowthGate(func(cur, next uint64) bool {
+		calls++
+		return budget(cur, next)
+	}))
+	var n int
+	for ; ; n++ {
+		if err := m.TryPut(n, n); err != nil {
+			require.ErrorIs(t, err, ErrFull)
+			break
+		}
+	}
+	require.Equal(t, m.Cap(), n)
+	require.LessOrEqual(t, uint64(len(m.grps))*gsize, 4*gsize)
+	require.NotZero(t, calls)
+	assert.PanicsWithValue(t, ErrFull, func() { m.Put(n, n) })
+	require.NoError(t, m.TryPut(0, -1))
+	for i := 1; i < n; i++ {
+		v, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	v, _ := m.Get(0)
+	require.Equal(t, -1, v)
+}
+
+func TestGrowthGateBulkInserts(t *testing.T) {
+	t.Parallel()
+	gsize := uint64(unsafe.Sizeof(group[int, int]{}))
+	src := make(map[int]int)
+	added := New[int, int](0)
+	for i := range 1000 {
+		src[i] = i
+		added.Put(i, i)
+	}
+	m := New[int, int](0, WithGrowthGate(BudgetGate(4*gsize)))
+	assert.PanicsWithValue(t, ErrFull, func() { m.MergeFrom(src) })
+	require.LessOrEqual(t, uint64(len(m.grps))*gsize, 4*gsize)
+	require.Equal(t, m.Cap(), m.Len())
+
+	m = New[int, int](0, WithGrowthGate(BudgetGate(4*gsize)))
+	assert.PanicsWithValue(t, ErrFull, func() { m.ApplyDiff(added, nil, nil) })
+	require.LessOrEqual(t, uint64(len(m.grps))*gsize, 4*gsize)
+}
+
+func TestMemoryLimitGate(t *testing.T) {
+	t.Parallel()
+	require.True(t, MemoryLimitGate(0)(0, math.MaxInt64))
+}
+
+func TestMemoryLimitGateConcurrent(t *testing.T) {
+	// Not parallel: the memory limit is global.
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(math.MaxInt64 - 1))
+	gate := MemoryLimitGate(0)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				require.True(t, gate(0, 1<<20))
+			}
+		}()
+	}
+	wg.Wait()
+	require.False(t, gate(0, math.MaxInt64))
+}
+
+func TestGrowCallback(t *testing.T) {
+	t.Parallel()
+	var caps [][2]int
+	m := New[int, int](0, WithGrowCallback(func(oldCap, newCap int, dur time.Duration) {
+		require.GreaterOrEqual(t, dur, time.Duration(0))
+		caps = append(caps, [2]int{oldCap, newCap})
+	}))
+	initial := m.Cap()
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	require.NotEmpty(t, caps)
+	require.Equal(t, initial, caps[0][0])
+	for i, c := range caps {
+		require.Greater(t, c[1], c[0])
+		if i > 0 {
+			require.Equal(t, caps[i-1][1], c[0])
+		}
+	}
+	require.Equal(t, m.Cap(), caps[len(caps)-1][1])
+}
@@ -0,0 +1,58 @@
+package hash
+
+import (
+	"encoding"
+	"sync"
+	"unsafe"
+)
+
+// binaryAppender is encoding.BinaryAppender, declared here to support Go
+// versions predating it.
+type binaryAppender interface {
+	AppendBinary(b []byte) ([]byte, error)
+}
+
+var scratch = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// GetHashFuncBinary returns a hash function hashing keys over their binary
+// encoding, produced by AppendBinary if K implements it or MarshalBinary
+// otherwise, into scratch buffers taken from a pool. It suits keys that are
+// comparable but whose memory is not what identifies them. The encoding must
+// be deterministic, and equal keys must encode identically. The hash
+// function panics if encoding fails. It returns nil if K implements neither
+// method.
+func GetHashFuncBinary[K comparable]() HFunc {
+	var k K
+	switch any(k).(type) {
+	case binaryAppender:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return hashEncoded(seed, any(*(*K)(p)).(binaryAppender).AppendBinary)
+		}
+	case encoding.BinaryMarshaler:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return hashEncoded(seed, func(b []byte) ([]byte, error) {
+				data, err := any(*(*K)(p)).(encoding.BinaryMarshaler).MarshalBinary()
+				return append(b, data...), err
+			})
+		}
+	default:
+		return nil
+	}
+}
+
+func hashEncoded(seed uintptr, encode func([]byte) ([]byte, error)) uintptr {
+	buf := scratch.Get().(*[]byte)
+	b, err := encode((*buf)[:0])
+	if err != nil {
+		panic("hash: encoding key: " + err.Error())
+	}
+	h := runtime_memhash(unsafe.Pointer(unsafe.SliceData(b)), seed, uintptr(len(b)))
+	*buf = b
+	scratch.Put(buf)
+	return h
+}
@@ -1,6 +1,9 @@
 package hash
 
-import "unsafe"
+import (
+	"reflect"
+	"unsafe"
+)
 
 type HFunc func(unsafe.Pointer, uintptr) uintptr
 
@@ -55,6 +58,23 @@ func GetHashFuncRnt[K comparable]() HFunc {
 //go:linkname runtime_memhash runtime.memhash
 func runtime_memhash(p unsafe.Pointer, seed, s uintptr) uintptr
 
+//go:linkname runtime_strhash runtime.strhash
+func runtime_strhash(p unsafe.Pointer, seed uintptr) uintptr
+
+// Word hashes the 8-byte integer at p with the runtime's memhash, so that
+// calling it directly instead of through an HFunc saves the indirect call
+// without changing the hash algorithm. The runtime's memhash64 would be
+// slightly faster, but the linker rejects references to it.
+func Word(p unsafe.Pointer, seed uintptr) uintptr {
+	return runtime_memhash(p, seed, 8)
+}
+
+// String hashes the string at p like the function returned by GetHashFunc
+// for strings.
+func String(p unsafe.Pointer, seed uintptr) uintptr {
+	return runtime_strhash(p, seed)
+}
+
 func GetHashFuncMemhash[K comparable]() HFunc {
 	var key K
 	sz := unsafe.Sizeof(key)
@@ -63,13 +83,62 @@ func GetHashFuncMemhash[K comparable]() HFunc {
 	}
 }
 
+// GetHashFunc returns the hash function used by maps with keys of type K.
+// Keys that can be compared as plain memory are hashed with memhash, all
+// other keys, such as strings, floats and interfaces, with the runtime's
+// hasher for the type, which hashes interfaces by their dynamic type and
+// panics on unhashable dynamic types like the built-in map. Pair and Triple
+// keys use their own mixing of the components.
+//
+// Both memhash and the runtime's hashers for strings are the runtime's
+// aeshash on amd64 and arm64 CPUs with AES instructions, which the runtime
+// detects at startup, and fall back to a wyhash variant elsewhere. Long
+// string and byte-array keys therefore already get AES-based hashing by
+// default.
 func GetHashFunc[K comparable]() HFunc {
 	var k K
+	if t, ok := any(k).(tuple); ok {
+		return t.tupleHash()
+	}
 	switch any(k).(type) {
 	case int, int8, int16, int32, int64, uint, uint8, uint16,
-		uint32, uint64, uintptr, float32, float64:
+		uint32, uint64, uintptr, float32, float64, string:
 		return GetHashFuncRnt[K]()
+	}
+	if !RegularMemory(reflect.TypeFor[K]()) {
+		return GetHashFuncRnt[K]()
+	}
+	return GetHashFuncMemhash[K]()
+}
+
+// GetHashFuncIdentity returns a hash function using integer keys directly as
+// their hash, folded with the seed. It is only suitable for keys that are
+// already uniformly distributed, such as random identifiers. It returns nil
+// if K is not an integer type.
+func GetHashFuncIdentity[K comparable]() HFunc {
+	var k K
+	switch any(k).(type) {
+	case int8, uint8:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return uintptr(*(*uint8)(p)) ^ seed
+		}
+	case int16, uint16:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return uintptr(*(*uint16)(p)) ^ seed
+		}
+	case int32, uint32:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return uintptr(*(*uint32)(p)) ^ seed
+		}
+	case int, uint, uintptr:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return *(*uintptr)(p) ^ seed
+		}
+	case int64, uint64:
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			return uintptr(*(*uint64)(p)) ^ seed
+		}
 	default:
-		return GetHashFuncMemhash[K]()
+		return nil
 	}
 }
@@ -0,0 +1,58 @@
+package hash
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// GetHashFuncFNV1a returns a hash function computing the 64-bit FNV-1a hash
+// of the key, with the offset basis folded with the seed. The algorithm is
+// trivial to audit but processes one byte at a time, so it is only
+// competitive for small keys. Strings are hashed over their bytes, other
+// keys over their memory. It returns nil if K is neither a string nor a
+// plain-memory type as defined by RegularMemory.
+func GetHashFuncFNV1a[K comparable]() HFunc {
+	return bytewise[K](func(b []byte, seed uintptr) uintptr {
+		h := uint64(0xcbf29ce484222325) ^ uint64(seed)
+		for _, c := range b {
+			h ^= uint64(c)
+			h *= 0x100000001b3
+		}
+		return uintptr(h)
+	})
+}
+
+// GetHashFuncDJB2 returns a hash function based on Bernstein's djb2 (the
+// xor variant, h = h*33 ^ c) started from the seed. Since djb2 barely mixes
+// the low bits, from which the map takes the control byte, the result goes
+// through the splitmix64 finalizer. It supports the same keys as
+// GetHashFuncFNV1a.
+func GetHashFuncDJB2[K comparable]() HFunc {
+	return bytewise[K](func(b []byte, seed uintptr) uintptr {
+		h := 5381 ^ uint64(seed)
+		for _, c := range b {
+			h = h*33 ^ uint64(c)
+		}
+		h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+		h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+		return uintptr(h ^ (h >> 31))
+	})
+}
+
+// bytewise adapts a hash function over bytes to keys of type K.
+func bytewise[K comparable](fn func(b []byte, seed uintptr) uintptr) HFunc {
+	var k K
+	if _, ok := any(k).(string); ok {
+		return func(p unsafe.Pointer, seed uintptr) uintptr {
+			s := *(*string)(p)
+			return fn(unsafe.Slice(unsafe.StringData(s), len(s)), seed)
+		}
+	}
+	if !RegularMemory(reflect.TypeFor[K]()) {
+		return nil
+	}
+	sz := unsafe.Sizeof(k)
+	return func(p unsafe.Pointer, seed uintptr) uintptr {
+		return fn(unsafe.Slice((*byte)(p), sz), seed)
+	}
+}
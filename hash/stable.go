@@ -0,0 +1,153 @@
+package hash
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"reflect"
+	"unsafe"
+)
+
+// GetHashFuncStable returns a hash function whose results only depend on the
+// key's memory and the seed, so they are identical across processes and
+// runs, unlike the runtime hashes which are randomized at startup. Results
+// still depend on the platform's byte order. It returns nil if equality of
+// K is not equivalent to equality of its memory, i.e. if K contains
+// pointers to data compared by value (strings, interfaces), floating point
+// numbers or padding.
+func GetHashFuncStable[K comparable]() HFunc {
+	if !RegularMemory(reflect.TypeFor[K]()) {
+		return nil
+	}
+	var key K
+	sz := unsafe.Sizeof(key)
+	return func(p unsafe.Pointer, seed uintptr) uintptr {
+		return uintptr(stableHash(unsafe.Slice((*byte)(p), sz), uint64(seed)))
+	}
+}
+
+// RegularMemory reports whether two values of type t are equal if and only
+// if their memory is equal. Such values can be hashed as plain bytes.
+func RegularMemory(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr, reflect.Pointer, reflect.UnsafePointer,
+		reflect.Chan:
+		return true
+	case reflect.Array:
+		return RegularMemory(t.Elem())
+	case reflect.Struct:
+		var end uintptr
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if f.Offset != end || !RegularMemory(f.Type) {
+				return false
+			}
+			end = f.Offset + f.Type.Size()
+		}
+		return end == t.Size()
+	default:
+		return false
+	}
+}
+
+const (
+	prime1 = 0x9e3779b97f4a7c15
+	prime2 = 0xc2b2ae3d27d4eb4f
+)
+
+// stableHash is a simple multiply-rotate hash over 8-byte words finished
+// with the splitmix64 finalizer. The algorithm is part of the snapshot
+// format and must not change.
+func stableHash(b []byte, seed uint64) uint64 {
+	h := seed ^ uint64(len(b))*prime1
+	for ; len(b) >= 8; b = b[8:] {
+		h = bits.RotateLeft64((h^binary.LittleEndian.Uint64(b))*prime1, 31) * prime2
+	}
+	if len(b) > 0 {
+		var w uint64
+		for i, c := range b {
+			w |= uint64(c) << (8 * i)
+		}
+		h = bits.RotateLeft64((h^w)*prime1, 31) * prime2
+	}
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
+}
+
+// GetHashFuncDeterministic returns a hash function that, like the one
+// returned by GetHashFuncStable, does not depend on the process, but supports
+// every comparable type. Types that are not plain memory are hashed by
+// walking their values with reflection, which is considerably slower.
+// Pointers and channels are hashed by address and are therefore only
+// deterministic within a process.
+func GetHashFuncDeterministic[K comparable]() HFunc {
+	if hashfn := GetHashFuncStable[K](); hashfn != nil {
+		return hashfn
+	}
+	t := reflect.TypeFor[K]()
+	return func(p unsafe.Pointer, seed uintptr) uintptr {
+		return uintptr(stableValue(reflect.NewAt(t, p).Elem(), uint64(seed)))
+	}
+}
+
+func stableValue(v reflect.Value, seed uint64) uint64 {
+	var buf [16]byte
+	switch v.Kind() {
+	case reflect.String:
+		return stableHash(unsafe.Slice(unsafe.StringData(v.String()), v.Len()), seed)
+	case reflect.Float32, reflect.Float64:
+		return stableHash(binary.LittleEndian.AppendUint64(buf[:0], stableFloat(v.Float())), seed)
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		b := binary.LittleEndian.AppendUint64(buf[:0], stableFloat(real(c)))
+		return stableHash(binary.LittleEndian.AppendUint64(b, stableFloat(imag(c))), seed)
+	case reflect.Bool:
+		if v.Bool() {
+			return stableHash([]byte{1}, seed)
+		}
+		return stableHash([]byte{0}, seed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return stableHash(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Int())), seed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return stableHash(binary.LittleEndian.AppendUint64(buf[:0], v.Uint()), seed)
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+		return stableHash(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Pointer())), seed)
+	case reflect.Array:
+		h := seed
+		for i := range v.Len() {
+			h = stableValue(v.Index(i), h)
+		}
+		return h
+	case reflect.Struct:
+		h := seed
+		for i := range v.NumField() {
+			if v.Type().Field(i).Name != "_" {
+				h = stableValue(v.Field(i), h)
+			}
+		}
+		return h
+	case reflect.Interface:
+		if v.IsNil() {
+			return stableHash(nil, seed)
+		}
+		e := v.Elem()
+		if !e.Comparable() {
+			panic("runtime error: hash of unhashable type " + e.Type().String())
+		}
+		return stableValue(e, stableHash([]byte(e.Type().String()), seed))
+	default:
+		panic("hash: unsupported type " + v.Type().String())
+	}
+}
+
+// stableFloat returns the bits of f, mapping -0 to +0 since they compare
+// equal.
+func stableFloat(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
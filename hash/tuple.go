@@ -0,0 +1,94 @@
+package hash
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// Pair is a composite key of two values. Maps with Pair keys use a hash
+// function mixing the two components directly, which for 64-bit integer
+// components is a couple of multiplications instead of a memhash call.
+type Pair[A, B comparable] struct {
+	First  A
+	Second B
+}
+
+// Triple is a composite key of three values, hashed like Pair.
+type Triple[A, B, C comparable] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// NewPair returns the Pair of a and b.
+func NewPair[A, B comparable](a A, b B) Pair[A, B] {
+	return Pair[A, B]{First: a, Second: b}
+}
+
+// NewTriple returns the Triple of a, b and c.
+func NewTriple[A, B, C comparable](a A, b B, c C) Triple[A, B, C] {
+	return Triple[A, B, C]{First: a, Second: b, Third: c}
+}
+
+// tuple is implemented by Pair and Triple, which provide their own hash
+// function to GetHashFunc.
+type tuple interface {
+	tupleHash() HFunc
+}
+
+func (Pair[A, B]) tupleHash() HFunc {
+	a, b := word[A](), word[B]()
+	off := unsafe.Offsetof(Pair[A, B]{}.Second)
+	return func(p unsafe.Pointer, seed uintptr) uintptr {
+		s := uint64(seed)
+		h := mum(a(p, seed)^s^prime1, b(unsafe.Add(p, off), seed)^s^prime2)
+		return uintptr(mum(h, s^prime3))
+	}
+}
+
+func (Triple[A, B, C]) tupleHash() HFunc {
+	a, b, c := word[A](), word[B](), word[C]()
+	var t Triple[A, B, C]
+	offb, offc := unsafe.Offsetof(t.Second), unsafe.Offsetof(t.Third)
+	return func(p unsafe.Pointer, seed uintptr) uintptr {
+		s := uint64(seed)
+		h := mum(a(p, seed)^s^prime1, b(unsafe.Add(p, offb), seed)^s^prime2)
+		h = mum(h^c(unsafe.Add(p, offc), seed), s^prime3)
+		return uintptr(mum(h, prime1))
+	}
+}
+
+const prime3 = 0x165667b19e3779f9
+
+// mum is the folded 128-bit multiplication used as mixing step by wyhash.
+func mum(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+// word returns a function reducing a component of type T to 64 bits: 64-bit
+// integers are used as they are and other types are hashed with the hash
+// function GetHashFunc selects for them.
+func word[T comparable]() func(p unsafe.Pointer, seed uintptr) uint64 {
+	var t T
+	switch any(t).(type) {
+	case int64, uint64:
+		return func(p unsafe.Pointer, _ uintptr) uint64 {
+			return *(*uint64)(p)
+		}
+	case int, uint, uintptr:
+		if unsafe.Sizeof(t) == 8 {
+			return func(p unsafe.Pointer, _ uintptr) uint64 {
+				return *(*uint64)(p)
+			}
+		}
+	case int32, uint32:
+		return func(p unsafe.Pointer, _ uintptr) uint64 {
+			return uint64(*(*uint32)(p))
+		}
+	}
+	hashfn := GetHashFunc[T]()
+	return func(p unsafe.Pointer, seed uintptr) uint64 {
+		return uint64(hashfn(p, seed))
+	}
+}
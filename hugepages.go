@@ -0,0 +1,19 @@
+package swiss
+
+import "unsafe"
+
+// hugePageSize is the size of transparent huge pages on common platforms.
+const hugePageSize = 2 << 20
+
+// adviseHugePages asks the kernel to back the groups with huge pages if the
+// table is at least as large as the threshold set by WithHugePages.
+func (m *Map[K, V]) adviseHugePages() {
+	if m.hugepages == 0 {
+		return
+	}
+	size := uintptr(len(m.grps)) * unsafe.Sizeof(group[K, V]{})
+	if uint64(size) < m.hugepages {
+		return
+	}
+	madviseHugePages(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(m.grps))), size))
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package swiss
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// madviseHugePages marks the huge-page-aligned part of b with MADV_HUGEPAGE.
+// Errors are ignored, the advice is only a hint and transparent huge pages
+// may be disabled on the system.
+func madviseHugePages(b []byte) {
+	addr := uint64(uintptr(unsafe.Pointer(unsafe.SliceData(b))))
+	start := alignUp(addr, hugePageSize) - addr
+	end := (addr+uint64(len(b)))&^(hugePageSize-1) - addr
+	if start >= end || end > uint64(len(b)) {
+		return
+	}
+	_ = syscall.Madvise(b[start:end], syscall.MADV_HUGEPAGE)
+}
@@ -0,0 +1,248 @@
+package swiss
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"math/rand"
+	"unsafe"
+)
+
+// Incremental snapshots store the groups of a map as they are laid out in
+// memory, so that a later snapshot only has to store the groups modified in
+// the meantime. The first SaveIncremental of a map writes all groups and
+// starts tracking the modified ones; every further call writes the groups
+// modified since the previous one, or all of them again after the map has
+// been rehashed or cleared. LoadIncremental replays such a chain. The layout
+// of every snapshot is:
+//
+//	header     incrementalHeader
+//	type names key and value type names separated by a zero byte
+//	groups     Count records of a group index followed by the group
+//
+// Groups keep the hash function and seed of the map, so unlike Save the
+// snapshots cannot be opened by OpenReadOnly, and entries are reinserted
+// when loading. The type restrictions and platform dependence of Save apply.
+
+const incrementalVersion = 1
+
+var incrementalMagic = [8]byte{'S', 'W', 'I', 'S', 'S', 'I', 'N', 'C'}
+
+type incrementalHeader struct {
+	Magic     [8]byte
+	Version   uint32
+	Endian    uint32
+	KeySize   uint32
+	ValueSize uint32
+	GroupSize uint32
+	NamesLen  uint32
+	Full      uint32 // set if all groups are stored
+	_         uint32
+	Groups    uint64 // number of groups of the map
+	Count     uint64 // number of stored groups
+	Epoch     uint64 // identifies the chain of snapshots
+	Seq       uint64 // position in the chain, starting at 0
+}
+
+// dirtyGroups is a bitset of the groups modified since the last
+// incremental snapshot.
+type dirtyGroups struct {
+	bits  []uint64
+	full  bool
+	epoch uint64
+	seq   uint64
+}
+
+func (d *dirtyGroups) reset(ngroups int) {
+	d.bits = make([]uint64, (ngroups+63)/64)
+	d.full = true
+}
+
+func (d *dirtyGroups) has(i int) bool {
+	return d.full || d.bits[i/64]&(1<<(i%64)) != 0
+}
+
+// modifying must be called before g is modified, for the checkpoint in
+// progress, for incremental snapshots and for checksums.
+func (m *Map[K, V]) modifying(g *group[K, V]) {
+	if m.cp != nil {
+		m.cp.preserve(g)
+	}
+	if m.dirty != nil {
+		i := m.groupIndex(g)
+		m.dirty.bits[i/64] |= 1 << (i % 64)
+	}
+	if m.sums != nil {
+		m.modified(m.groupIndex(g))
+	}
+}
+
+// SaveIncremental writes the groups of the map modified since its previous
+// call to w, or all groups on the first call and after a rehash or Clear.
+// Writes through pointers returned by GetPtr count as modifications. It
+// returns ErrUnsupportedType if the key or value type cannot be stored in a
+// snapshot.
+func (m *Map[K, V]) SaveIncremental(w io.Writer) error {
+	if _, err := snapshotHashFunc[K, V](); err != nil {
+		return err
+	}
+	if m.dirty == nil {
+		m.dirty = &dirtyGroups{epoch: rand.Uint64()}
+		m.dirty.reset(len(m.grps))
+	}
+	d := m.dirty
+	var count uint64
+	for i := range m.grps {
+		if d.has(i) {
+			count++
+		}
+	}
+	var g group[K, V]
+	var k K
+	var v V
+	names := snapshotNames[K, V]()
+	h := incrementalHeader{
+		Magic:     incrementalMagic,
+		Version:   incrementalVersion,
+		Endian:    snapshotEndian,
+		KeySize:   uint32(unsafe.Sizeof(k)),
+		ValueSize: uint32(unsafe.Sizeof(v)),
+		GroupSize: uint32(unsafe.Sizeof(g)),
+		NamesLen:  uint32(len(names)),
+		Groups:    uint64(len(m.grps)),
+		Count:     count,
+		Epoch:     d.epoch,
+		Seq:       d.seq,
+	}
+	if d.full {
+		h.Full = 1
+	}
+	bw := bufio.NewWriter(w)
+	if err := binary.Write(bw, binary.NativeEndian, &h); err != nil {
+		return err
+	}
+	bw.WriteString(names)
+	empty := group[K, V]{cntrl: emptyContol}
+	for i := range m.grps {
+		if !d.has(i) {
+			continue
+		}
+		src := &m.grps[i]
+		if m.stale(uint32(i)) {
+			src = &empty
+		}
+		var idx [8]byte
+		binary.NativeEndian.PutUint64(idx[:], uint64(i))
+		bw.Write(idx[:])
+		bw.Write(unsafe.Slice((*byte)(unsafe.Pointer(src)), unsafe.Sizeof(*src)))
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	clear(d.bits)
+	d.full = false
+	d.seq++
+	return nil
+}
+
+// LoadIncremental reads a chain of snapshots written by SaveIncremental of
+// the same map, in the order they were written, into a new map. The chain
+// may start at any snapshot that stores all groups.
+func LoadIncremental[K comparable, V any](chain ...io.Reader) (*Map[K, V], error) {
+	if _, err := snapshotHashFunc[K, V](); err != nil {
+		return nil, err
+	}
+	var grps []group[K, V]
+	var epoch, seq uint64
+	for n, r := range chain {
+		br := bufio.NewReader(r)
+		var h incrementalHeader
+		if err := binary.Read(br, binary.NativeEndian, &h); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		if err := validateIncremental[K, V](&h, br); err != nil {
+			return nil, err
+		}
+		switch {
+		case n == 0 && h.Full == 0:
+			return nil, fmt.Errorf("%w: chain does not start with a full snapshot", ErrInvalidSnapshot)
+		case n > 0 && (h.Epoch != epoch || h.Seq != seq+1):
+			return nil, fmt.Errorf("%w: snapshot %d does not follow the previous one", ErrInvalidSnapshot, n)
+		case h.Full == 0 && h.Groups != uint64(len(grps)):
+			return nil, fmt.Errorf("%w: group count changed in a partial snapshot", ErrInvalidSnapshot)
+		case h.Groups > uint64(maxGroups[K, V]()) || h.Count > h.Groups:
+			return nil, fmt.Errorf("%w: too many groups", ErrInvalidSnapshot)
+		}
+		epoch, seq = h.Epoch, h.Seq
+		if h.Full != 0 {
+			grps = make([]group[K, V], h.Groups)
+			for i := range grps {
+				grps[i].cntrl = emptyContol
+			}
+		}
+		for range h.Count {
+			var idx [8]byte
+			if _, err := io.ReadFull(br, idx[:]); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+			}
+			i := binary.NativeEndian.Uint64(idx[:])
+			if i >= uint64(len(grps)) {
+				return nil, fmt.Errorf("%w: group index %d out of range", ErrInvalidSnapshot, i)
+			}
+			g := &grps[i]
+			if _, err := io.ReadFull(br, unsafe.Slice((*byte)(unsafe.Pointer(g)), unsafe.Sizeof(*g))); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+			}
+		}
+	}
+	if grps == nil {
+		return nil, fmt.Errorf("%w: empty chain", ErrInvalidSnapshot)
+	}
+	var size int
+	for i := range grps {
+		size += bits.OnesCount64(uint64(grps[i].maskFull()))
+	}
+	m := New[K, V](size)
+	for i := range grps {
+		mask := grps[i].maskFull()
+		for mask != 0 {
+			s := &grps[i].slts[mask.first()]
+			m.Put(s.key, s.value)
+			mask = mask.rmfirst()
+		}
+	}
+	return m, nil
+}
+
+// validateIncremental checks the header h and consumes the type names
+// following it.
+func validateIncremental[K comparable, V any](h *incrementalHeader, r io.Reader) error {
+	var g group[K, V]
+	var k K
+	var v V
+	switch {
+	case h.Magic != incrementalMagic:
+		return fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	case h.Version != incrementalVersion:
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, h.Version)
+	case h.Endian != snapshotEndian:
+		return fmt.Errorf("%w: byte order mismatch", ErrInvalidSnapshot)
+	case uintptr(h.KeySize) != unsafe.Sizeof(k) || uintptr(h.ValueSize) != unsafe.Sizeof(v) ||
+		uintptr(h.GroupSize) != unsafe.Sizeof(g):
+		return fmt.Errorf("%w: type sizes do not match", ErrInvalidSnapshot)
+	}
+	names := snapshotNames[K, V]()
+	if h.NamesLen != uint32(len(names)) {
+		return fmt.Errorf("%w: snapshot types do not match %q", ErrInvalidSnapshot, names)
+	}
+	stored := make([]byte, h.NamesLen)
+	if _, err := io.ReadFull(r, stored); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if string(stored) != names {
+		return fmt.Errorf("%w: snapshot types %q do not match %q", ErrInvalidSnapshot, stored, names)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package swiss
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireSameEntries[K comparable, V any](t *testing.T, expected, actual *Map[K, V]) {
+	t.Helper()
+	require.Equal(t, expected.Len(), actual.Len())
+	for k, v := range expected.All() {
+		got, ok := actual.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+}
+
+func TestIncrementalSnapshots(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithLazyClear())
+	for i := range 10000 {
+		m.Put(i, i)
+	}
+	var chain []*bytes.Buffer
+	save := func() int {
+		var buf bytes.Buffer
+		require.NoError(t, m.SaveIncremental(&buf))
+		chain = append(chain, &buf)
+		return buf.Len()
+	}
+	load := func(from int) *Map[int, int] {
+		readers := make([]io.Reader, 0, len(chain)-from)
+		for _, buf := range chain[from:] {
+			readers = append(readers, bytes.NewReader(buf.Bytes()))
+		}
+		l, err := LoadIncremental[int, int](readers...)
+		require.NoError(t, err)
+		return l
+	}
+	base := save()
+	requireSameEntries(t, m, load(0))
+
+	// A few modifications produce a small delta.
+	m.Put(5, -5)
+	m.Delete(6)
+	m.Put(-1, 1)
+	*m.GetPtr(7) = -7
+	require.Less(t, save()*50, base)
+	requireSameEntries(t, m, load(0))
+	require.Less(t, save()*50, base)
+	requireSameEntries(t, m, load(0))
+
+	// Growing or clearing the map stores all groups again.
+	for i := 10000; i < 30000; i++ {
+		m.Put(i, i)
+	}
+	require.Greater(t, save(), base)
+	requireSameEntries(t, m, load(0))
+	requireSameEntries(t, m, load(len(chain)-1))
+	m.Clear()
+	m.Put(1, 2)
+	save()
+	m.Put(3, 4)
+	save()
+	requireSameEntries(t, m, load(0))
+	requireSameEntries(t, m, load(len(chain)-2))
+}
+
+func TestIncrementalSnapshotsInvalid(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	m.Put(1, 1)
+	var base, delta1, delta2 bytes.Buffer
+	require.NoError(t, m.SaveIncremental(&base))
+	m.Put(2, 2)
+	require.NoError(t, m.SaveIncremental(&delta1))
+	m.Put(3, 3)
+	require.NoError(t, m.SaveIncremental(&delta2))
+
+	for _, chain := range [][]*bytes.Buffer{
+		nil,
+		{&delta1},
+		{&base, &delta2},
+		{&base, &delta1, &delta1},
+	} {
+		readers := make([]io.Reader, 0, len(chain))
+		for _, buf := range chain {
+			readers = append(readers, bytes.NewReader(buf.Bytes()))
+		}
+		_, err := LoadIncremental[int, int](readers...)
+		require.ErrorIs(t, err, ErrInvalidSnapshot)
+	}
+	_, err := LoadIncremental[int, uint](bytes.NewReader(base.Bytes()))
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = LoadIncremental[int, int](bytes.NewReader(base.Bytes()[:base.Len()-1]))
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	s := New[string, int](0)
+	require.ErrorIs(t, s.SaveIncremental(io.Discard), ErrUnsupportedType)
+}
@@ -0,0 +1,31 @@
+// Package layout mirrors the memory layout of the groups of swiss maps for
+// the packages under x, which cannot reach the unexported types of the root
+// package. The root package keeps its own types identical to these.
+package layout
+
+// GroupSize is the number of slots in a group.
+const GroupSize = 8
+
+// Control bytes of slots that do not hold an entry. A full slot holds the
+// h2 bits of the hash of its key, which leave the top bit clear.
+const (
+	Empty   = 0b10000000
+	Deleted = 0b11111110
+)
+
+// Slot has the layout of a slot of a map.
+type Slot[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Group has the layout of a group of a map: a control word holding the
+// control byte of slot i in bits 8i to 8i+7, followed by the slots.
+type Group[K comparable, V any] struct {
+	Control uint64
+	Slots   [GroupSize]Slot[K, V]
+}
+
+// Groups returns the groups of a *swiss.Map[K, V] as a []Group[K, V], after
+// resetting the groups cleared lazily. It is set by the root package.
+var Groups func(m any) any
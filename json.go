@@ -0,0 +1,132 @@
+package swiss
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// DecodeJSON reads a JSON object from dec and stores its members in the map,
+// one at a time, so the object never has to be held in memory as a whole.
+// Keys follow the rules of encoding/json for map keys: K must be a string or
+// an integer type, or implement encoding.TextUnmarshaler. Existing entries
+// are kept unless overwritten, and a JSON null leaves the map unchanged. If
+// the growth gate of the map denies growth, DecodeJSON returns ErrFull with
+// the members before it stored.
+func (m *Map[K, V]) DecodeJSON(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("swiss: expected JSON object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, err := parseJSONKey[K](tok.(string))
+		if err != nil {
+			return err
+		}
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if err := m.TryPut(key, value); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// EncodeJSON writes the map to w as a JSON object, one member at a time.
+// Keys are formatted following the rules of encoding/json for map keys: K
+// must be a string or an integer type, or implement encoding.TextMarshaler.
+// Unlike encoding/json, members are written in iteration order, not sorted.
+func (m *Map[K, V]) EncodeJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	var err error
+	first := true
+	for k, v := range m.All() {
+		var key string
+		if key, err = formatJSONKey(k); err != nil {
+			return err
+		}
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		var b []byte
+		if b, err = json.Marshal(key); err != nil {
+			return err
+		}
+		bw.Write(b)
+		bw.WriteByte(':')
+		if b, err = json.Marshal(v); err != nil {
+			return err
+		}
+		bw.Write(b)
+	}
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+func formatJSONKey[K comparable](key K) (string, error) {
+	rv := reflect.ValueOf(&key).Elem()
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if tm, ok := any(key).(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("swiss: unsupported JSON key type %v", rv.Type())
+	}
+}
+
+func parseJSONKey[K comparable](s string) (K, error) {
+	var key K
+	if tu, ok := any(&key).(encoding.TextUnmarshaler); ok {
+		if err := tu.UnmarshalText([]byte(s)); err != nil {
+			return key, err
+		}
+		return key, nil
+	}
+	rv := reflect.ValueOf(&key).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || rv.OverflowInt(n) {
+			return key, fmt.Errorf("swiss: invalid JSON key %q for type %v", s, rv.Type())
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || rv.OverflowUint(n) {
+			return key, fmt.Errorf("swiss: invalid JSON key %q for type %v", s, rv.Type())
+		}
+		rv.SetUint(n)
+	default:
+		return key, fmt.Errorf("swiss: unsupported JSON key type %v", rv.Type())
+	}
+	return key, nil
+}
@@ -0,0 +1,99 @@
+package swiss
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := range 1000 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"%d": {"n": %d}`, i-500, i)
+	}
+	sb.WriteString("}")
+	type value struct {
+		N int `json:"n"`
+	}
+	m := New[int16, value](0)
+	require.NoError(t, m.DecodeJSON(json.NewDecoder(strings.NewReader(sb.String()))))
+	require.Equal(t, 1000, m.Len())
+	for i := range 1000 {
+		v, ok := m.Get(int16(i - 500))
+		require.True(t, ok)
+		require.Equal(t, i, v.N)
+	}
+
+	s := New[string, int](0)
+	dec := json.NewDecoder(strings.NewReader(`{"a": 1, "b": 2} null {"a": 3}`))
+	for range 3 {
+		require.NoError(t, s.DecodeJSON(dec))
+	}
+	require.Equal(t, 2, s.Len())
+	v, _ := s.Get("a")
+	require.Equal(t, 3, v)
+
+	for _, input := range []string{`[1]`, `{"a": 1}`, `{"70000": 1}`, `{"1": "x"}`, `{"1": 1`} {
+		require.Error(t, New[int16, int](0).DecodeJSON(json.NewDecoder(strings.NewReader(input))), input)
+	}
+	require.Error(t, New[float64, int](0).DecodeJSON(json.NewDecoder(strings.NewReader(`{"1": 1}`))))
+
+	full := New[int16, value](0, WithGrowthGate(BudgetGate(0)))
+	err := full.DecodeJSON(json.NewDecoder(strings.NewReader(sb.String())))
+	require.ErrorIs(t, err, ErrFull)
+	require.Equal(t, full.Cap(), full.Len())
+}
+
+func TestEncodeJSON(t *testing.T) {
+	t.Parallel()
+	m := New[uint8, []string](0)
+	for i := range 200 {
+		m.Put(uint8(i), []string{fmt.Sprint(i), `"quoted"`})
+	}
+	var sb strings.Builder
+	require.NoError(t, m.EncodeJSON(&sb))
+	var expected map[uint8][]string
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &expected))
+	require.Len(t, expected, 200)
+	c := New[uint8, []string](0)
+	require.NoError(t, c.DecodeJSON(json.NewDecoder(strings.NewReader(sb.String()))))
+	for k, v := range m.All() {
+		require.Equal(t, v, expected[k])
+		got, ok := c.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+
+	sb.Reset()
+	require.NoError(t, New[string, int](0).EncodeJSON(&sb))
+	require.Equal(t, "{}", sb.String())
+	f := New[float32, int](0)
+	f.Put(1.5, 1)
+	require.Error(t, f.EncodeJSON(&sb))
+}
+
+func TestJSONTextKeys(t *testing.T) {
+	t.Parallel()
+	m := New[netip.Addr, int](0)
+	m.Put(netip.MustParseAddr("10.0.0.1"), 1)
+	m.Put(netip.MustParseAddr("::1"), 2)
+	var sb strings.Builder
+	require.NoError(t, m.EncodeJSON(&sb))
+	require.Contains(t, sb.String(), `"10.0.0.1":1`)
+	c := New[netip.Addr, int](0)
+	require.NoError(t, c.DecodeJSON(json.NewDecoder(strings.NewReader(sb.String()))))
+	require.Equal(t, 2, c.Len())
+	v, ok := c.Get(netip.MustParseAddr("::1"))
+	require.True(t, ok)
+	require.Equal(t, 2, v)
+	require.Error(t, c.DecodeJSON(json.NewDecoder(strings.NewReader(`{"not an ip": 1}`))))
+}
@@ -0,0 +1,41 @@
+package swiss
+
+// stale reports whether the group has been cleared lazily since it was last
+// written to.
+func (m *Map[K, V]) stale(ngrp uint32) bool {
+	return m.gens != nil && m.gens[ngrp] != m.gen
+}
+
+// refresh resets a stale group and moves it to the current generation.
+func (m *Map[K, V]) refresh(ngrp uint32) {
+	g := &m.grps[ngrp]
+	m.modifying(g)
+	g.cntrl = emptyContol
+	clear(g.slts[:])
+	if m.meta != nil {
+		clear(m.meta[ngrp*grpssz : (ngrp+1)*grpssz])
+	}
+	m.gens[ngrp] = m.gen
+}
+
+// settle refreshes all stale groups, for code working on the groups
+// directly.
+func (m *Map[K, V]) settle() {
+	for i := range m.gens {
+		if m.stale(uint32(i)) {
+			m.refresh(uint32(i))
+		}
+	}
+}
+
+// bumpGen clears the map lazily by starting a new generation. It reports
+// false when the counter wraps around, in which case generations are reset
+// and the groups must be cleared eagerly.
+func (m *Map[K, V]) bumpGen() bool {
+	m.gen++
+	if m.gen == 0 {
+		clear(m.gens)
+		return false
+	}
+	return true
+}
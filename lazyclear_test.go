@@ -0,0 +1,55 @@
+package swiss
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyClear(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithLazyClear(), WithFilter())
+	for round := range 20 {
+		n := 100 * (round + 1)
+		for i := range n {
+			m.Put(i+round*100_000, i)
+		}
+		for i := 0; i < n; i += 3 {
+			m.Delete(i + round*100_000)
+		}
+		expected := n - (n+2)/3
+		require.Equal(t, expected, m.Len())
+		var cnt int
+		for range m.All() {
+			cnt++
+		}
+		require.Equal(t, expected, cnt)
+		for i := range n {
+			v, ok := m.Get(i + round*100_000)
+			require.Equal(t, i%3 != 0, ok)
+			if ok {
+				require.Equal(t, i, v)
+			}
+		}
+		require.Equal(t, expected, m.KeySet().Len())
+		require.Equal(t, expected, m.Clone().Len())
+		m.Clear()
+		require.Zero(t, m.Len())
+		_, ok := m.Get(1 + round*100_000)
+		require.False(t, ok)
+		for range m.All() {
+			t.Fatal("iterated over a cleared map")
+		}
+	}
+	m.gen = math.MaxUint32
+	m.Put(1, 1)
+	m.Clear()
+	require.Zero(t, m.gen)
+	_, ok := m.Get(1)
+	require.False(t, ok)
+	m.Put(2, 2)
+	v, ok := m.Get(2)
+	require.True(t, ok)
+	require.Equal(t, 2, v)
+}
@@ -0,0 +1,235 @@
+package swiss
+
+// LFU is a fixed-capacity cache evicting the least frequently used entry.
+// Entries are kept in frequency buckets so every operation is O(1); ties
+// within a bucket are broken by evicting the least recently used entry.
+//
+// Admission follows TinyLFU: the frequency of every accessed key, cached or
+// not, is estimated by a count-min sketch, and once the cache is full a new
+// key only replaces the eviction candidate if it has been seen more often.
+// This keeps one-off keys from scans from flushing the frequently used
+// ones. LFU is not safe for concurrent use.
+type LFU[K comparable, V any] struct {
+	items   *Map[K, *lfuEntry[K, V]]
+	buckets *Map[uint64, *lfuBucket[K, V]]
+	sketch  sketch
+	onEvict func(K, V)
+	minfreq uint64
+	cap     int
+	stats   CacheStats
+}
+
+type lfuEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	freq       uint64
+	prev, next *lfuEntry[K, V]
+}
+
+// lfuBucket is a circular list of entries with the same frequency, from the
+// least (head.next) to the most (head.prev) recently used one.
+type lfuBucket[K comparable, V any] struct {
+	head lfuEntry[K, V]
+}
+
+// NewLFU creates an LFU cache holding at most capacity entries. If onEvict is
+// not nil it is called for every entry evicted to make room for a new one.
+// A non-positive capacity is treated as 1.
+func NewLFU[K comparable, V any](capacity int, onEvict func(key K, value V)) *LFU[K, V] {
+	capacity = max(capacity, 1)
+	return &LFU[K, V]{
+		items:   New[K, *lfuEntry[K, V]](capacity),
+		buckets: New[uint64, *lfuBucket[K, V]](0),
+		sketch:  newSketch(capacity),
+		onEvict: onEvict,
+		cap:     capacity,
+	}
+}
+
+// Get returns the value cached for the key and counts the access.
+func (c *LFU[K, V]) Get(key K) (V, bool) {
+	c.sketch.add(c.items.Hash(key))
+	e, ok := c.items.Get(key)
+	c.stats.lookup(ok)
+	if !ok {
+		var res V
+		return res, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Peek returns the value cached for the key without counting the access.
+func (c *LFU[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.items.Get(key)
+	if !ok {
+		var res V
+		return res, false
+	}
+	return e.value, true
+}
+
+// Put caches the value for the key and counts the access. If the cache is
+// full and the key is new, it is only admitted if it is estimated to be used
+// more often than the entry that would be evicted. Put reports whether the
+// value has been stored.
+func (c *LFU[K, V]) Put(key K, value V) bool {
+	hash := c.items.Hash(key)
+	c.sketch.add(hash)
+	if e, ok := c.items.Get(key); ok {
+		e.value = value
+		c.touch(e)
+		return true
+	}
+	if c.items.Len() >= c.cap {
+		victim := c.bucket(c.minfreq).head.next
+		if c.sketch.estimate(hash) <= c.sketch.estimate(c.items.Hash(victim.key)) {
+			return false
+		}
+		c.remove(victim)
+		c.stats.Evictions++
+		if c.onEvict != nil {
+			c.onEvict(victim.key, victim.value)
+		}
+	}
+	e := &lfuEntry[K, V]{key: key, value: value, freq: 1}
+	c.items.Put(key, e)
+	c.bucket(1).pushBack(e)
+	c.minfreq = 1
+	return true
+}
+
+// Delete removes the key from the cache without calling the eviction
+// callback.
+func (c *LFU[K, V]) Delete(key K) {
+	if e, ok := c.items.Get(key); ok {
+		c.remove(e)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *LFU[K, V]) Len() int {
+	return c.items.Len()
+}
+
+// Cap returns the maximum number of cached entries.
+func (c *LFU[K, V]) Cap() int {
+	return c.cap
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (c *LFU[K, V]) Stats() CacheStats {
+	return c.stats
+}
+
+// ResetStats sets all counters of the cache to zero.
+func (c *LFU[K, V]) ResetStats() {
+	c.stats = CacheStats{}
+}
+
+// touch moves the entry to the bucket of the next frequency.
+func (c *LFU[K, V]) touch(e *lfuEntry[K, V]) {
+	if e.unlink() && c.minfreq == e.freq {
+		c.minfreq++
+	}
+	c.dropIfEmpty(e.freq)
+	e.freq++
+	c.bucket(e.freq).pushBack(e)
+}
+
+func (c *LFU[K, V]) remove(e *lfuEntry[K, V]) {
+	c.items.Delete(e.key)
+	e.unlink()
+	c.dropIfEmpty(e.freq)
+}
+
+func (c *LFU[K, V]) bucket(freq uint64) *lfuBucket[K, V] {
+	b, ok := c.buckets.Get(freq)
+	if !ok {
+		b = &lfuBucket[K, V]{}
+		b.head.prev, b.head.next = &b.head, &b.head
+		c.buckets.Put(freq, b)
+	}
+	return b
+}
+
+func (c *LFU[K, V]) dropIfEmpty(freq uint64) {
+	if b, ok := c.buckets.Get(freq); ok && b.head.next == &b.head {
+		c.buckets.Delete(freq)
+	}
+}
+
+func (b *lfuBucket[K, V]) pushBack(e *lfuEntry[K, V]) {
+	e.prev, e.next = b.head.prev, &b.head
+	b.head.prev.next = e
+	b.head.prev = e
+}
+
+// unlink removes the entry from its bucket and reports whether the bucket
+// became empty.
+func (e *lfuEntry[K, V]) unlink() bool {
+	e.prev.next, e.next.prev = e.next, e.prev
+	empty := e.prev == e.next
+	e.prev, e.next = nil, nil
+	return empty
+}
+
+// sketch is a count-min sketch with 4 rows of saturating 8-bit counters.
+// Once the number of recorded events reaches the reset threshold all
+// counters are halved, so estimates favor recent history.
+type sketch struct {
+	rows    [4][]uint8
+	mask    uint64
+	events  int
+	resetAt int
+}
+
+// newSketch returns a sketch for a cache of the given capacity. It has four
+// counters per entry and at least 64, since in a sketch as narrow as a small
+// cache a new key may share all its counters with the eviction candidate
+// and could then never be admitted.
+func newSketch(capacity int) sketch {
+	width, minWidth := 1, max(4*capacity, 64)
+	for width < minWidth {
+		width <<= 1
+	}
+	s := sketch{
+		mask:    uint64(width - 1),
+		resetAt: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) index(hash uint64, row int) uint64 {
+	h := hash + uint64(row)*((hash>>32)|1)
+	h ^= h >> 29
+	return h & s.mask
+}
+
+func (s *sketch) add(hash uint64) {
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(hash, i)]; *c < 255 {
+			*c++
+		}
+	}
+	s.events++
+	if s.events >= s.resetAt {
+		for i := range s.rows {
+			for j := range s.rows[i] {
+				s.rows[i][j] >>= 1
+			}
+		}
+		s.events /= 2
+	}
+}
+
+func (s *sketch) estimate(hash uint64) uint8 {
+	res := uint8(255)
+	for i := range s.rows {
+		res = min(res, s.rows[i][s.index(hash, i)])
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLFUPutGetDelete(t *testing.T) {
+	t.Parallel()
+	c := NewLFU[int, string](2, nil)
+	require.True(t, c.Put(1, "one"))
+	require.True(t, c.Put(2, "two"))
+	value, ok := c.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "one", value)
+	require.True(t, c.Put(1, "uno"))
+	value, _ = c.Get(1)
+	require.Equal(t, "uno", value)
+	c.Delete(1)
+	_, ok = c.Get(1)
+	require.False(t, ok)
+	require.Equal(t, 1, c.Len())
+	require.Equal(t, 2, c.Cap())
+}
+
+func TestLFUEvictsLeastFrequent(t *testing.T) {
+	t.Parallel()
+	var evicted []int
+	c := NewLFU[int, int](3, func(key, value int) {
+		require.Equal(t, key*10, value)
+		evicted = append(evicted, key)
+	})
+	for key := 1; key <= 3; key++ {
+		c.Put(key, key*10)
+	}
+	for range 3 {
+		c.Get(1)
+		c.Get(3)
+	}
+	for range 5 {
+		c.Get(4)
+	}
+	require.True(t, c.Put(4, 40))
+	require.Equal(t, []int{2}, evicted)
+	_, ok := c.Get(2)
+	require.False(t, ok)
+	for _, key := range []int{1, 3, 4} {
+		_, ok := c.Get(key)
+		require.True(t, ok, "key %d", key)
+	}
+}
+
+func TestLFUScanResistance(t *testing.T) {
+	t.Parallel()
+	size := 100
+	c := NewLFU[int, int](size, nil)
+	for range 10 {
+		for key := range size {
+			c.Put(key, key)
+			c.Get(key)
+		}
+	}
+	for key := size; key < 100*size; key++ {
+		c.Put(key, key)
+	}
+	var hits int
+	for key := range size {
+		if _, ok := c.Get(key); ok {
+			hits++
+		}
+	}
+	assert.Greater(t, hits, size*9/10)
+	assert.Equal(t, size, c.Len())
+}
+
+func TestLFUBucketsConsistency(t *testing.T) {
+	t.Parallel()
+	c := NewLFU[int, int](50, nil)
+	for i := range 10_000 {
+		key := (i * 7919) % 300
+		if i%5 == 0 {
+			c.Delete(key)
+			continue
+		}
+		if _, ok := c.Get(key); !ok {
+			c.Put(key, key)
+		}
+	}
+	var cnt int
+	for freq, b := range c.buckets.All() {
+		require.NotEqual(t, &b.head, b.head.next, "empty bucket %d", freq)
+		for e := b.head.next; e != &b.head; e = e.next {
+			require.Equal(t, freq, e.freq)
+			cnt++
+		}
+	}
+	require.Equal(t, c.Len(), cnt)
+}
+
+func TestLFUSmallCacheAdmits(t *testing.T) {
+	t.Parallel()
+	// With a sketch as narrow as the cache, every key of a cache of one
+	// entry shares the counters of the victim and is never admitted.
+	for k := range 1000 {
+		c := NewLFU[int, int](1, nil)
+		c.Put(-1, -1)
+		admitLFU(t, c, k, k)
+		_, ok := c.Peek(k)
+		require.True(t, ok)
+	}
+}
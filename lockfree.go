@@ -0,0 +1,324 @@
+package swiss
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
+)
+
+// LockFree is an experimental concurrent map whose readers never block.
+// Entries are immutable and referenced from the slots by atomic pointers, so
+// a lookup is a plain probe over atomically loaded control words. Updates
+// and deletions swap the slot pointer with a compare-and-swap, and
+// insertions claim an empty slot with a compare-and-swap on the control word
+// of its group. Insertions of keys sharing a lock stripe, which includes all
+// insertions of the same key, are serialized so that a key is never inserted
+// twice.
+//
+// Deleted slots are only reclaimed when the table is rebuilt. Rebuilding
+// pauses writers until the new table is published, while readers keep
+// using the old one. Every Put allocates an entry, so LockFree suits
+// read-mostly workloads with too many readers for a lock. Deleted entries
+// are left to the garbage collector, which keeps them alive while a reader
+// still holds them, so values with pointers need no epoch-based
+// reclamation.
+//
+// Readers do not use sequence counters to validate optimistic reads of the
+// slots: copying a key or value that a writer may be modifying is a data
+// race in Go, even if the copy is discarded afterwards. Immutable entries
+// give the same lock-free reads without racing.
+type LockFree[K comparable, V any] struct {
+	cur     atomic.Pointer[lfTable[K, V]]
+	len     atomic.Int64
+	hashfn  hash.HFunc
+	seed    uintptr
+	mu      sync.Mutex // serializes rebuilds
+	stripes []lfStripe
+	shift   uint8 // shifts a hash to its stripe index
+}
+
+type lfStripe struct {
+	sync.Mutex
+	_ [64 - unsafe.Sizeof(sync.Mutex{})]byte
+}
+
+type lfTable[K comparable, V any] struct {
+	grps    []lfGroup[K, V]
+	ngroups uint32
+	used    atomic.Int64 // claimed slots, including deleted ones
+	limit   int64
+	writers atomic.Int64
+	frozen  atomic.Bool
+}
+
+type lfGroup[K comparable, V any] struct {
+	cntrl atomic.Uint64
+	slts  [grpssz]atomic.Pointer[lfEntry[K, V]]
+}
+
+type lfEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLockFree creates a LockFree map with room for size entries. The number
+// of insertion lock stripes is tuned to GOMAXPROCS, see NewLockFreeStripes.
+func NewLockFree[K comparable, V any](size int) *LockFree[K, V] {
+	return NewLockFreeStripes[K, V](size, 4*runtime.GOMAXPROCS(0))
+}
+
+// NewLockFreeStripes is like NewLockFree, with the number of lock stripes
+// serializing insertions rounded up to a power of two. More stripes let
+// more insertions of distinct keys run in parallel, at 64 bytes each.
+func NewLockFreeStripes[K comparable, V any](size, stripes int) *LockFree[K, V] {
+	shift := bits.Len(uint(max(stripes, 1) - 1))
+	l := &LockFree[K, V]{
+		hashfn:  hash.GetHashFunc[K](),
+		seed:    uintptr(rand.Uint64()),
+		stripes: make([]lfStripe, 1<<shift),
+		shift:   uint8(bits.UintSize - shift),
+	}
+	l.cur.Store(newLFTable[K, V](groupsnum(size)))
+	return l
+}
+
+func newLFTable[K comparable, V any](ngroups int) *lfTable[K, V] {
+	t := &lfTable[K, V]{
+		grps:    make([]lfGroup[K, V], ngroups),
+		ngroups: uint32(ngroups),
+		limit:   int64(ngroups * grpload),
+	}
+	for i := range t.grps {
+		t.grps[i].cntrl.Store(emptyContol)
+	}
+	return t
+}
+
+// Get retrieves the value associated with the key.
+func (l *LockFree[K, V]) Get(key K) (V, bool) {
+	hash := l.hash(key)
+	if g, i, ok := l.cur.Load().find(key, hash); ok {
+		if e := g.slts[i].Load(); e != nil && e.key == key {
+			return e.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (l *LockFree[K, V]) Put(key K, value V) {
+	hash := l.hash(key)
+	e := &lfEntry[K, V]{key: key, value: value}
+	for {
+		t := l.cur.Load()
+		if !t.enter() {
+			l.wait()
+			continue
+		}
+		if t.update(key, hash, e) {
+			t.exit()
+			return
+		}
+		stripe := &l.stripes[hash>>l.shift]
+		stripe.Lock()
+		if t.update(key, hash, e) {
+			stripe.Unlock()
+			t.exit()
+			return
+		}
+		ok := t.insert(hash, e)
+		stripe.Unlock()
+		t.exit()
+		if ok {
+			l.len.Add(1)
+			return
+		}
+		l.rebuild(t)
+	}
+}
+
+// Delete removes the key from the map.
+func (l *LockFree[K, V]) Delete(key K) {
+	hash := l.hash(key)
+	for {
+		t := l.cur.Load()
+		if !t.enter() {
+			l.wait()
+			continue
+		}
+		if t.remove(key, hash) {
+			l.len.Add(-1)
+		}
+		t.exit()
+		return
+	}
+}
+
+// Len returns the number of entries in the map.
+func (l *LockFree[K, V]) Len() int {
+	return int(l.len.Load())
+}
+
+// All returns an iterator over the entries of the map. It does not block
+// writers; entries inserted or deleted during the iteration may or may not
+// be visited, and a rebuild during the iteration is not observed.
+func (l *LockFree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t := l.cur.Load()
+		for i := range t.grps {
+			for j := range t.grps[i].slts {
+				if e := t.grps[i].slts[j].Load(); e != nil && !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *LockFree[K, V]) hash(key K) uintptr {
+	return l.hashfn(noescape(unsafe.Pointer(&key)), l.seed)
+}
+
+// wait blocks until the rebuild that froze the current table is done.
+func (l *LockFree[K, V]) wait() {
+	l.mu.Lock()
+	l.mu.Unlock()
+}
+
+// rebuild replaces the full table t, doubling it unless at least half of
+// its slots are tombstones. Writers are paused until the new table is
+// published.
+func (l *LockFree[K, V]) rebuild(t *lfTable[K, V]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cur.Load() != t {
+		return
+	}
+	t.frozen.Store(true)
+	for t.writers.Load() != 0 {
+		runtime.Gosched()
+	}
+	ngroups := len(t.grps)
+	if l.len.Load() > t.limit/2 {
+		ngroups *= 2
+	}
+	nt := newLFTable[K, V](ngroups)
+	for i := range t.grps {
+		for j := range t.grps[i].slts {
+			if e := t.grps[i].slts[j].Load(); e != nil {
+				nt.insert(l.hash(e.key), e)
+			}
+		}
+	}
+	l.cur.Store(nt)
+}
+
+// enter registers a writer on the table. It fails if the table is being
+// rebuilt.
+func (t *lfTable[K, V]) enter() bool {
+	t.writers.Add(1)
+	if t.frozen.Load() {
+		t.writers.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (t *lfTable[K, V]) exit() {
+	t.writers.Add(-1)
+}
+
+// find returns the group and the slot holding the key. The slot must be
+// loaded again by the caller, since it may have been deleted in the meantime.
+func (t *lfTable[K, V]) find(key K, hash uintptr) (*lfGroup[K, V], uint32, bool) {
+	ngrp := uint32(hash>>7) % t.ngroups
+	h2 := hash & 0x7f
+	for {
+		g := &t.grps[ngrp]
+		cntrl := control(g.cntrl.Load())
+		equal := cntrl.match(h2)
+		for equal != 0 {
+			i := equal.first()
+			if e := g.slts[i].Load(); e != nil && e.key == key {
+				return g, i, true
+			}
+			equal = equal.rmfirst()
+		}
+		if cntrl.maskEmpty() != 0 {
+			return nil, 0, false
+		}
+		ngrp++
+		if ngrp >= t.ngroups {
+			ngrp = 0
+		}
+	}
+}
+
+// update replaces the entry of the key with e and reports whether the key
+// was present.
+func (t *lfTable[K, V]) update(key K, hash uintptr, e *lfEntry[K, V]) bool {
+	for {
+		g, i, ok := t.find(key, hash)
+		if !ok {
+			return false
+		}
+		if old := g.slts[i].Load(); old != nil && old.key == key && g.slts[i].CompareAndSwap(old, e) {
+			return true
+		}
+	}
+}
+
+// insert claims the first empty slot of the probe sequence for e, which
+// must not be in the table. It reports false if the table is full.
+func (t *lfTable[K, V]) insert(hash uintptr, e *lfEntry[K, V]) bool {
+	if t.used.Add(1) > t.limit {
+		t.used.Add(-1)
+		return false
+	}
+	ngrp := uint32(hash>>7) % t.ngroups
+	for {
+		g := &t.grps[ngrp]
+		cntrl := control(g.cntrl.Load())
+		empty := cntrl.maskEmpty()
+		if empty == 0 {
+			ngrp++
+			if ngrp >= t.ngroups {
+				ngrp = 0
+			}
+			continue
+		}
+		i := empty.first()
+		if g.cntrl.CompareAndSwap(uint64(cntrl), uint64(cntrl.with(i, uint8(hash&0x7f)))) {
+			g.slts[i].Store(e)
+			return true
+		}
+	}
+}
+
+// remove deletes the key and reports whether it was present. The slot
+// becomes a tombstone until the next rebuild.
+func (t *lfTable[K, V]) remove(key K, hash uintptr) bool {
+	for {
+		g, i, ok := t.find(key, hash)
+		if !ok {
+			return false
+		}
+		old := g.slts[i].Load()
+		if old == nil || old.key != key || !g.slts[i].CompareAndSwap(old, nil) {
+			continue
+		}
+		for {
+			cntrl := g.cntrl.Load()
+			if g.cntrl.CompareAndSwap(cntrl, uint64(control(cntrl).with(i, kDeleted))) {
+				return true
+			}
+		}
+	}
+}
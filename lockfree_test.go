@@ -0,0 +1,159 @@
+package swiss
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFree(t *testing.T) {
+	t.Parallel()
+	m := NewLockFree[int, string](0)
+	for i := range 1000 {
+		m.Put(i, "a")
+	}
+	for i := range 1000 {
+		m.Put(i, "b")
+	}
+	require.Equal(t, 1000, m.Len())
+	for i := range 500 {
+		m.Delete(i)
+	}
+	m.Delete(-1)
+	require.Equal(t, 500, m.Len())
+	for i := range 1000 {
+		v, ok := m.Get(i)
+		require.Equal(t, i >= 500, ok)
+		if ok {
+			require.Equal(t, "b", v)
+		}
+	}
+	var n int
+	for k, v := range m.All() {
+		require.GreaterOrEqual(t, k, 500)
+		require.Equal(t, "b", v)
+		n++
+	}
+	require.Equal(t, 500, n)
+
+	// Churn on a small key set must purge tombstones instead of growing.
+	c := NewLockFree[int, int](16)
+	for i := range 100_000 {
+		c.Put(i, i)
+		c.Delete(i)
+	}
+	require.Zero(t, c.Len())
+	require.Len(t, c.cur.Load().grps, groupsnum(16))
+}
+
+func TestLockFreeConcurrent(t *testing.T) {
+	t.Parallel()
+	m := NewLockFree[int, int](0)
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				k := w*2000 + i
+				m.Put(k, k)
+				m.Put(k%100, w)
+				if i%2 == 0 {
+					m.Delete(k)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range 20_000 {
+				if v, ok := m.Get(i % 16_000); ok && i%16_000 >= 100 {
+					assert.Equal(t, i%16_000, v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	var n int
+	for k, v := range m.All() {
+		if k >= 100 {
+			require.Equal(t, k, v)
+			require.Equal(t, 1, k%2)
+		}
+		n++
+	}
+	require.Equal(t, n, m.Len())
+	for k := range 100 {
+		_, ok := m.Get(k)
+		require.True(t, ok)
+	}
+}
+
+func TestLockFreeDeletedValues(t *testing.T) {
+	t.Parallel()
+	// Readers keep using values of deleted entries while writers delete and
+	// reinsert the keys with new values. Without reclamation by the garbage
+	// collector, a reader could see the memory of a deleted value reused.
+	m := NewLockFree[int, *[64]int](0)
+	fill := func(k int) *[64]int {
+		v := new([64]int)
+		for i := range v {
+			v[i] = k
+		}
+		return v
+	}
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 5000 {
+				k := (w*5000 + i) % 64
+				m.Put(k, fill(k))
+				m.Delete(k)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var held []*[64]int
+			for i := range 5000 {
+				if v, ok := m.Get(i % 64); ok {
+					held = append(held, v)
+				}
+				if i%100 == 0 {
+					runtime.GC()
+				}
+			}
+			for _, v := range held {
+				for _, x := range v {
+					assert.Equal(t, v[0], x)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLockFreeStripes(t *testing.T) {
+	t.Parallel()
+	require.Len(t, NewLockFree[int, int](0).stripes, 1<<bits.Len(uint(4*runtime.GOMAXPROCS(0)-1)))
+	for _, tc := range []struct{ stripes, want int }{{0, 1}, {1, 1}, {3, 4}, {96, 128}} {
+		m := NewLockFreeStripes[int, int](0, tc.stripes)
+		require.Len(t, m.stripes, tc.want)
+		var wg sync.WaitGroup
+		for w := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					m.Put(w*1000+i, i)
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 4000, m.Len())
+	}
+}
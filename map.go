@@ -33,9 +33,15 @@
 package swiss
 
 import (
+	"context"
+	"fmt"
 	"iter"
+	"math"
 	"math/bits"
 	"math/rand"
+	"reflect"
+	"slices"
+	"time"
 	"unsafe"
 
 	"github.com/crn4/swiss/hash"
@@ -60,11 +66,50 @@ const (
 type Map[K comparable, V any] struct {
 	grps       []group[K, V]
 	hashfn     hash.HFunc
+	hkind      hashKind
 	seed       uintptr
 	len        int
 	cap        int
 	tombstones int
+	version    uint64
 	ngroups    uint32
+	h1shift    uint8
+	h2shift    uint8
+	h2mask     uintptr
+	filter     filter
+	// filterDeletes counts the deletes since the filter was last rebuilt.
+	filterDeletes int
+	// deterministic disables the random start of iteration.
+	deterministic bool
+	// plain is set if slots hold no pointers, so that deleted slots need
+	// not be zeroed for the garbage collector.
+	plain     bool
+	normalize func(K) K
+	gate      GrowthGate
+	hugepages uint64
+	onGrow    func(oldCap, newCap int, dur time.Duration)
+	// gens holds the generation of every group when lazy clearing is
+	// enabled, groups of older generations than gen are empty.
+	gens []uint32
+	gen  uint32
+	// iterators counts the active iterators if deferGrowth is set.
+	deferGrowth bool
+	iterators   int
+	growPending bool
+	monitor     *ProbeMonitor
+	maxProbe    int
+	reseeded    bool
+	cp          *Checkpoint[K, V]
+	// dirty tracks the groups modified since the last SaveIncremental.
+	dirty *dirtyGroups
+	// sums holds the checksums of the groups, see WithChecksums.
+	sums *checksums
+	meta []slotMeta
+	// obs holds the watchers and the change log, see Watch and LogChanges.
+	obs *observers[K, V]
+	// alloc and release manage the memory of the groups of off-heap maps.
+	alloc   func(ngroups int) []group[K, V]
+	release func(grps []group[K, V])
 }
 
 type group[K comparable, V any] struct {
@@ -79,25 +124,139 @@ type slot[K comparable, V any] struct {
 
 type control uint64
 
+// Pair holds a key together with its value.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
 // New creates a new Swiss map with the specified initial size. It preallocates
 // the necessary number of groups and sets up the hash function. The control
 // bytes of each group are initialized to an empty state (kEmpty). The hash
 // function and seed are also initialized. The capacity is calculated based
-// on the number of groups and the load factor.
-func New[K comparable, V any](size int) *Map[K, V] {
+// on the number of groups and the load factor, and is always larger than
+// size: the first size inserts of distinct keys never rehash the map, which
+// Cap and Available reflect from the start. New panics if the size or the
+// options are invalid, see NewE.
+func New[K comparable, V any](size int, opts ...Option) *Map[K, V] {
+	m, err := NewE[K, V](size, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewE is like New, but returns an error instead of panicking if the size is
+// negative or too large to address, if the options are invalid or conflict
+// with each other, or if they do not support the key type.
+func NewE[K comparable, V any](size int, opts ...Option) (*Map[K, V], error) {
+	if size < 0 {
+		return nil, fmt.Errorf("swiss: negative size %d", size)
+	}
+	if limit := maxSize[K, V](); size > limit {
+		return nil, fmt.Errorf("swiss: size %d exceeds the maximum of %d", size, limit)
+	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	hashfn := hash.GetHashFunc[K]()
+	if o.deterministic {
+		hashfn = hash.GetHashFuncDeterministic[K]()
+	}
+	switch o.hasher {
+	case FNV1aHasher:
+		hashfn = hash.GetHashFuncFNV1a[K]()
+	case DJB2Hasher:
+		hashfn = hash.GetHashFuncDJB2[K]()
+	case BinaryHasher:
+		hashfn = hash.GetHashFuncBinary[K]()
+	}
+	if hashfn == nil {
+		return nil, errHasherKey
+	}
+	if o.identity {
+		if hashfn = hash.GetHashFuncIdentity[K](); hashfn == nil {
+			return nil, errIdentityKey
+		}
+	}
+	var normalize func(K) K
+	if o.normalize != nil {
+		fn, ok := o.normalize.(func(K) K)
+		if !ok {
+			return nil, errNormalizerType
+		}
+		normalize = fn
+	}
+	hkind := hashIndirect
+	if !o.deterministic && !o.identity && o.hasher == DefaultHasher {
+		hashfn, hkind = devirtualize[K](hashfn)
+	}
+	m := newMap[K, V](size, hashfn, o)
+	m.hkind = hkind
+	m.normalize = normalize
+	for _, spec := range o.indexes {
+		newIndex, ok := spec.newFunc.(func() namedIndex[K, V])
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errIndexType, spec.name)
+		}
+		m.addNamedIndex(spec.name, newIndex())
+	}
+	return m, nil
+}
+
+// maxGroups returns the largest number of groups of a map: it must fit in
+// uint32 and the memory of the groups in an int.
+func maxGroups[K comparable, V any]() int {
+	return int(min(uint64(math.MaxUint32), uint64(math.MaxInt)/uint64(unsafe.Sizeof(group[K, V]{}))))
+}
+
+// maxSize returns the largest size a map can be created with.
+func maxSize[K comparable, V any]() int {
+	return maxGroups[K, V]()*grpload - grpload - 1
+}
+
+func newMap[K comparable, V any](size int, hashfn hash.HFunc, o options) *Map[K, V] {
 	ngroups := groupsnum(size)
 	m := &Map[K, V]{
 		grps:    make([]group[K, V], ngroups),
 		ngroups: uint32(ngroups),
-		// hashfn:  getHashFunc[K](),
-		hashfn: hash.GetHashFunc[K](),
-		seed:   uintptr(rand.Uint64()),
-		cap:    grpload * ngroups,
+		hashfn:  hashfn,
+		seed:    uintptr(rand.Uint64()),
+		cap:     grpload * ngroups,
+	}
+	if o.deterministic {
+		m.seed = uintptr(o.seed)
+		m.deterministic = true
+	}
+	m.plain = pointerFree(reflect.TypeFor[slot[K, V]]())
+	m.gate = o.gate
+	if o.lazyClear {
+		m.gens = make([]uint32, ngroups)
+	}
+	m.deferGrowth = o.deferGrowth
+	if o.metadata {
+		m.meta = make([]slotMeta, ngroups*grpssz)
+	}
+	m.monitor = o.monitor
+	m.hugepages = o.hugepages
+	m.onGrow = o.onGrow
+	m.adviseHugePages()
+	m.setSplit(o.split)
+	if o.filter {
+		m.filter = newFilter(ngroups)
 	}
 	m.groups(func(g *group[K, V]) bool {
 		g.cntrl = emptyContol
 		return true
 	})
+	if o.checksums {
+		m.sums = &checksums{verifyReads: o.verifyReads}
+		m.resetSums()
+	}
 	return m
 }
 
@@ -107,28 +266,78 @@ func New[K comparable, V any](size int) *Map[K, V] {
 // its value is updated. If an empty or deleted slot is found, the key-value
 // pair is inserted. Rehashing occurs if the map's load exceeds the capacity.
 func (m *Map[K, V]) Put(key K, value V) {
-	hash := m.hashfn(noescape(unsafe.Pointer(&key)), m.seed)
-	ngrp := uint32(h1(hash)) % m.ngroups
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	if err := m.put(key, value); err != nil {
+		panic(err)
+	}
+}
+
+// TryPut is like Put, but returns ErrFull instead of panicking if the key is
+// new and the growth gate set by WithGrowthGate denies growing the map.
+func (m *Map[K, V]) TryPut(key K, value V) error {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	return m.put(key, value)
+}
+
+func (m *Map[K, V]) put(key K, value V) error {
+	return m.putHash(key, value, m.hashKey(key))
+}
+
+// putHash is put for a key whose hash is already known.
+func (m *Map[K, V]) putHash(key K, value V, hash uintptr) error {
+	ngrp, i, found := m.probe(key, hash)
+	if !found {
+		return m.insertAt(ngrp, i, key, value, hash)
+	}
+	group := m.grp(ngrp)
+	m.modifying(group)
+	group.slts[i].value = value
+	if m.meta != nil {
+		m.written(ngrp*grpssz+i, false)
+	}
+	m.version++
+	if m.obs != nil {
+		m.notify(OpUpdate, key, value)
+	}
+	return nil
+}
+
+// probe looks up the key in a single pass over its probe sequence. If the
+// key is present it returns its group and slot. Otherwise it returns the
+// slot to insert the key at: the first tombstone of the sequence, or else
+// the first empty slot of the group ending it. The key may live beyond
+// groups holding tombstones, so a tombstone can only be chosen once the
+// sequence has ended.
+func (m *Map[K, V]) probe(key K, hash uintptr) (ngrp, i uint32, found bool) {
+	ngrp = uint32(m.h1(hash)) % m.ngroups
+	cgrp, ci, candidate := uint32(0), uint32(0), false
 	for {
-		group := &m.grps[ngrp]
-		equal := group.match(h2(hash))
+		if m.stale(ngrp) {
+			m.refresh(ngrp)
+		}
+		group := m.grp(ngrp)
+		equal := group.match(m.h2(hash))
 		for equal != 0 {
 			i := equal.first()
 			if key == group.slts[i].key {
-				group.slts[i].value = value
-				return
+				return ngrp, i, true
 			}
 			equal = equal.rmfirst()
 		}
-		if empty := group.maskEmptyOrDeleted(); empty != 0 {
-			i := empty.first()
-			group.slts[i] = slot[K, V]{key: key, value: value}
-			group.cntrl.set(i, uint8(h2(hash)))
-			m.len++
-			if m.len > m.cap {
-				m.rehash()
+		if empty := group.maskEmpty(); empty != 0 {
+			if candidate {
+				return cgrp, ci, false
+			}
+			return ngrp, empty.first(), false
+		}
+		if !candidate && m.tombstones > 0 {
+			if deleted := group.maskEmptyOrDeleted(); deleted != 0 {
+				cgrp, ci, candidate = ngrp, deleted.first(), true
 			}
-			return
 		}
 		ngrp++
 		if ngrp >= m.ngroups {
@@ -137,20 +346,107 @@ func (m *Map[K, V]) Put(key K, value V) {
 	}
 }
 
+// insertAt stores a new key in the i-th slot of group ngrp, as returned by
+// probe, and grows the map if needed. Reusing a tombstone does not change
+// the load of the map.
+func (m *Map[K, V]) insertAt(ngrp, i uint32, key K, value V, hash uintptr) error {
+	group := m.grp(ngrp)
+	reuse := group.cntrl.get(i) == kDeleted
+	if !reuse && m.len >= m.cap {
+		if _, ok := m.growTarget(); !ok {
+			return errTooLarge
+		}
+		if !m.allowGrowth() {
+			return ErrFull
+		}
+	}
+	m.modifying(group)
+	group.slts[i] = slot[K, V]{key: key, value: value}
+	group.cntrl.set(i, uint8(m.h2(hash)))
+	if m.meta != nil {
+		m.written(ngrp*grpssz+i, true)
+	}
+	if m.filter != nil {
+		m.filter.add(uint32(m.h1(hash))%m.ngroups, hash)
+	}
+	if reuse {
+		m.tombstones--
+	} else {
+		m.len++
+	}
+	m.version++
+	if m.obs != nil {
+		m.notify(OpPut, key, value)
+	}
+	if m.monitor != nil {
+		home := uint32(m.h1(hash)) % m.ngroups
+		m.observeProbe(int((ngrp+m.ngroups-home)%m.ngroups) + 1)
+	}
+	if m.len > m.cap {
+		// While iterating, growth is postponed as long as an empty slot is
+		// left to end probe sequences.
+		if m.iterators > 0 && m.len < len(m.grps)*grpssz {
+			m.growPending = true
+		} else {
+			m.rehash()
+		}
+	}
+	return nil
+}
+
+// GetOrPut returns the value of the key if it is present. Otherwise it
+// inserts the value under the key and returns it. The loaded result reports
+// whether the key was present. The key is located with a single probe, like
+// in Put.
+func (m *Map[K, V]) GetOrPut(key K, value V) (actual V, loaded bool) {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	hash := m.hashKey(key)
+	ngrp, i, found := m.probe(key, hash)
+	if found {
+		if m.meta != nil {
+			m.touch(ngrp*grpssz + i)
+		}
+		return m.grp(ngrp).slts[i].value, true
+	}
+	if err := m.insertAt(ngrp, i, key, value, hash); err != nil {
+		panic(err)
+	}
+	return value, false
+}
+
 // Get retrieves the value associated with a given key. It calculates the hash
 // of the key and uses h1 to find the corresponding group. The function checks
 // the control bytes of the group for a matching h2. If a match is found, it
 // compares the key and returns the value. If the key is not found or an empty
 // slot is encountered, the function returns false.
 func (m *Map[K, V]) Get(key K) (V, bool) {
-	hash := m.hashfn(noescape(unsafe.Pointer(&key)), m.seed)
-	ngrp := uint32(h1(hash)) % m.ngroups
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	hash := m.hashKey(key)
+	ngrp := uint32(m.h1(hash)) % m.ngroups
+	if m.filter != nil && !m.filter.mayContain(ngrp, hash) {
+		var res V
+		return res, false
+	}
 	for {
-		group := &m.grps[ngrp]
-		equal := group.match(h2(hash))
+		if m.stale(ngrp) {
+			var res V
+			return res, false
+		}
+		if m.sums != nil {
+			m.checkRead(ngrp)
+		}
+		group := m.grp(ngrp)
+		equal := group.match(m.h2(hash))
 		for equal != 0 {
 			i := equal.first()
 			if key == group.slts[i].key {
+				if m.meta != nil {
+					m.touch(ngrp*grpssz + i)
+				}
 				return group.slts[i].value, true
 			}
 			equal = equal.rmfirst()
@@ -166,34 +462,192 @@ func (m *Map[K, V]) Get(key K) (V, bool) {
 	}
 }
 
+// GetPtr returns a pointer to the value associated with the key, or nil if
+// the key is not present. The value can be read and modified in place. The
+// pointer is only valid until the next Put, Delete, Clear or any other call
+// that may modify the map: after that it may point to another entry's value.
+func (m *Map[K, V]) GetPtr(key K) *V {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	if s := m.lookup(key); s != nil {
+		m.modifying(m.groupOf(s))
+		if m.meta != nil {
+			m.touch(m.slotIndex(s))
+		}
+		return &s.value
+	}
+	return nil
+}
+
+// Modify calls fn with a pointer to the value associated with the key and
+// reports whether the key is present. fn must not modify the map.
+func (m *Map[K, V]) Modify(key K, fn func(value *V)) bool {
+	p := m.GetPtr(key)
+	if p == nil {
+		return false
+	}
+	fn(p)
+	m.version++
+	if m.obs != nil {
+		m.notify(OpUpdate, key, *p)
+	}
+	return true
+}
+
+// Do locates the key once and calls fn with a pointer to its value and
+// whether it is present; for an absent key the pointer refers to a zero
+// value. fn decides the outcome: if del is true the key is deleted, else if
+// write is true newV is stored under the key, inserting it if needed, and
+// otherwise the map is left as is. The hash of the key is computed once,
+// and the slot found by the lookup is updated or deleted in place. fn must
+// not modify the map.
+func (m *Map[K, V]) Do(key K, fn func(v *V, exists bool) (newV V, write, del bool)) {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	hash := m.hashKey(key)
+	s := m.lookupHash(key, hash)
+	if s == nil {
+		var zero V
+		newV, write, del := fn(&zero, false)
+		if write && !del {
+			if err := m.putHash(key, newV, hash); err != nil {
+				panic(err)
+			}
+		}
+		return
+	}
+	if m.meta != nil {
+		m.touch(m.slotIndex(s))
+	}
+	newV, write, del := fn(&s.value, true)
+	switch {
+	case del:
+		m.deleteAt(m.groupOf(s), m.slotIndex(s)%grpssz)
+	case write:
+		m.modifying(m.groupOf(s))
+		s.value = newV
+		if m.meta != nil {
+			m.written(m.slotIndex(s), false)
+		}
+		m.version++
+		if m.obs != nil {
+			m.notify(OpUpdate, key, newV)
+		}
+	}
+}
+
+// Rename moves the value of oldKey to newKey, replacing any value newKey
+// had, and reports whether oldKey was present. It takes one probe for each
+// key and copies the value directly between slots.
+func (m *Map[K, V]) Rename(oldKey, newKey K) bool {
+	if m.normalize != nil {
+		oldKey, newKey = m.normalize(oldKey), m.normalize(newKey)
+	}
+	src := m.lookup(oldKey)
+	if src == nil {
+		return false
+	}
+	if oldKey == newKey {
+		return true
+	}
+	value := src.value
+	// Deleting first leaves no pointer into the groups across a rehash
+	// triggered by the insertion.
+	m.deleteAt(m.groupOf(src), m.slotIndex(src)%grpssz)
+	if err := m.put(newKey, value); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// Replace stores newV under the key only if the key is present with the
+// value old, and reports whether it did. It is a function rather than a
+// method because it requires comparable values.
+func Replace[K, V comparable](m *Map[K, V], key K, old, newV V) bool {
+	replaced := false
+	m.Do(key, func(v *V, exists bool) (V, bool, bool) {
+		replaced = exists && *v == old
+		return newV, replaced, false
+	})
+	return replaced
+}
+
+// Version returns a counter incremented by every modification of the map:
+// Put, Delete of a present key, Clear, Modify and the methods built on them.
+// Writes through pointers returned by GetPtr are not counted. Rehashing does
+// not change the version.
+func (m *Map[K, V]) Version() uint64 {
+	return m.version
+}
+
+// lookup returns the slot holding the key, or nil if it is not present.
+func (m *Map[K, V]) lookup(key K) *slot[K, V] {
+	return m.lookupHash(key, m.hashKey(key))
+}
+
+// lookupHash is lookup for a key whose hash is already known.
+func (m *Map[K, V]) lookupHash(key K, hash uintptr) *slot[K, V] {
+	ngrp := uint32(m.h1(hash)) % m.ngroups
+	if m.filter != nil && !m.filter.mayContain(ngrp, hash) {
+		return nil
+	}
+	for {
+		if m.stale(ngrp) {
+			return nil
+		}
+		group := m.grp(ngrp)
+		equal := group.match(m.h2(hash))
+		for equal != 0 {
+			i := equal.first()
+			if key == group.slts[i].key {
+				return &group.slts[i]
+			}
+			equal = equal.rmfirst()
+		}
+		if group.maskEmpty() != 0 {
+			return nil
+		}
+		ngrp++
+		if ngrp >= m.ngroups {
+			ngrp = 0
+		}
+	}
+}
+
 // Delete removes a key-value pair from the map. If the key is found, the
 // slot is cleared, and the control byte is marked as either empty or deleted
 // (tombstone). This optimization helps avoid wasting slots if there are
 // empty slots available in the group. Tombstones are tracked and used to
 // trigger rehashing when necessary.
 func (m *Map[K, V]) Delete(key K) {
-	hash := m.hashfn(noescape(unsafe.Pointer(&key)), m.seed)
-	ngrp := uint32(h1(hash)) % m.ngroups
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	m.deleteHash(key, m.hashKey(key))
+}
+
+// deleteHash deletes a key whose hash is already known and reports whether
+// it was present.
+func (m *Map[K, V]) deleteHash(key K, hash uintptr) bool {
+	ngrp := uint32(m.h1(hash)) % m.ngroups
 	for {
-		group := &m.grps[ngrp]
-		equal := group.match(h2(hash))
+		if m.stale(ngrp) {
+			return false
+		}
+		group := m.grp(ngrp)
+		equal := group.match(m.h2(hash))
 		for equal != 0 {
 			i := equal.first()
 			if key == group.slts[i].key {
-				group.slts[i] = slot[K, V]{}
-				if group.maskEmpty() != 0 {
-					group.cntrl.set(i, kEmpty)
-					m.len--
-				} else {
-					group.cntrl.set(i, kDeleted)
-					m.tombstones++
-				}
-				return
+				m.deleteAt(group, i)
+				return true
 			}
 			equal = equal.rmfirst()
 		}
 		if group.maskEmpty() != 0 {
-			return
+			return false
 		}
 		ngrp++
 		if ngrp >= m.ngroups {
@@ -202,12 +656,58 @@ func (m *Map[K, V]) Delete(key K) {
 	}
 }
 
+// deleteAt clears the i-th slot of the group. The slot is marked empty if
+// the group still has empty slots, since no probe sequence can then pass
+// through it, and as a tombstone otherwise.
+func (m *Map[K, V]) deleteAt(group *group[K, V], i uint32) {
+	m.version++
+	m.modifying(group)
+	if m.obs != nil {
+		var zero V
+		m.notify(OpDelete, group.slts[i].key, zero)
+	}
+	if !m.plain {
+		group.slts[i] = slot[K, V]{}
+	}
+	if m.meta != nil {
+		m.meta[m.groupIndex(group)*grpssz+int(i)] = slotMeta{}
+	}
+	if group.maskEmpty() != 0 {
+		group.cntrl.set(i, kEmpty)
+		m.len--
+	} else {
+		group.cntrl.set(i, kDeleted)
+		m.tombstones++
+	}
+	if m.filter != nil {
+		if m.filterDeletes++; m.filterDeletes > m.cap {
+			m.rebuildFilter()
+		}
+	}
+}
+
 // Clear removes all key-value pairs from the map, resetting all groups to an
 // empty state. The capacity remains unchanged, but the length and tombstones
 // are reset to zero.
 func (m *Map[K, V]) Clear() {
 	m.len, m.tombstones = 0, 0
+	if m.dirty != nil {
+		m.dirty.full = true
+	}
+	m.version++
+	if m.obs != nil {
+		var key K
+		var value V
+		m.notify(OpClear, key, value)
+	}
+	m.filter.reset()
+	m.filterDeletes = 0
+	if m.gens != nil && m.bumpGen() {
+		return
+	}
+	clear(m.meta)
 	for i := range m.grps {
+		m.modifying(&m.grps[i])
 		m.grps[i].cntrl = emptyContol
 		for j := range m.grps[i].slts {
 			m.grps[i].slts[j] = slot[K, V]{}
@@ -222,25 +722,261 @@ func (m *Map[K, V]) Len() int {
 }
 
 // Cap returns the map’s capacity, which is based on the number of groups and
-// the load factor.
+// the load factor. The map rehashes when an insert makes Len plus the
+// tombstones exceed Cap, so a map created by New with size n has a Cap of at
+// least n+1.
 func (m *Map[K, V]) Cap() int {
 	return m.cap
 }
 
+// Available returns the number of new keys that are guaranteed to fit
+// before the map rehashes. Tombstones count as used until the next rehash,
+// although an insert may reuse one and leave Available unchanged. Updates of
+// present keys never rehash.
+func (m *Map[K, V]) Available() int {
+	return max(m.cap-m.len, 0)
+}
+
+// All returns an iterator over all key-value pairs of the map. Like the
+// built-in map, iteration starts at a random group so that code does not
+// come to depend on the order, unless the map was created with
+// WithDeterministic.
 func (m *Map[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		groups := m.grps
-		for i := range groups {
-			mask := groups[i].maskFull()
-			for mask != 0 {
-				j := mask.first()
-				if !yield(groups[i].slts[j].key, groups[i].slts[j].value) {
-					return
+		m.scan(func(s *slot[K, V]) bool {
+			return yield(s.key, s.value)
+		})
+	}
+}
+
+// AllFrom returns an iterator over all key-value pairs of the map starting
+// at the slot holding the key, which is yielded first, and wrapping around
+// the end of the table. If the key is absent, iteration starts at the first
+// slot of the group the key hashes to. As long as the map does not grow,
+// an export interrupted after some key can be resumed with AllFrom of that
+// key, stopping once it reaches the key the export started from.
+func (m *Map[K, V]) AllFrom(key K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.normalize != nil {
+			key = m.normalize(key)
+		}
+		ngrp, i := m.locate(key, m.hashKey(key))
+		if m.deferGrowth {
+			m.iterators++
+			defer m.endIteration()
+		}
+		m.scanFrom(int(ngrp), i, func(s *slot[K, V]) bool {
+			return yield(s.key, s.value)
+		})
+	}
+}
+
+// locate returns the group and slot holding the key, or the first slot of
+// its home group if the key is absent.
+func (m *Map[K, V]) locate(key K, hash uintptr) (ngrp, i uint32) {
+	home := uint32(m.h1(hash)) % m.ngroups
+	for ngrp = home; !m.stale(ngrp); {
+		group := m.grp(ngrp)
+		equal := group.match(m.h2(hash))
+		for equal != 0 {
+			i := equal.first()
+			if key == group.slts[i].key {
+				return ngrp, i
+			}
+			equal = equal.rmfirst()
+		}
+		if group.maskEmpty() != 0 {
+			break
+		}
+		ngrp++
+		if ngrp >= m.ngroups {
+			ngrp = 0
+		}
+	}
+	return home, 0
+}
+
+// AllWhere returns an iterator over the key-value pairs for which pred
+// returns true. The predicate is evaluated inside the group scan, in the
+// same order as All.
+func (m *Map[K, V]) AllWhere(pred func(K, V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.scan(func(s *slot[K, V]) bool {
+			return !pred(s.key, s.value) || yield(s.key, s.value)
+		})
+	}
+}
+
+// FilterKeys returns an iterator over the key-value pairs whose keys satisfy
+// pred. Values of rejected keys are never loaded.
+func (m *Map[K, V]) FilterKeys(pred func(K) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.scan(func(s *slot[K, V]) bool {
+			return !pred(s.key) || yield(s.key, s.value)
+		})
+	}
+}
+
+// AllChan returns a channel with a buffer of buf pairs receiving all entries
+// of the map, sent by a new goroutine in the order of All. The channel is
+// closed once all entries are sent or ctx is done, whichever comes first;
+// a receiver stopping early must cancel ctx to release the goroutine. The
+// map must not be modified until the channel is closed.
+func (m *Map[K, V]) AllChan(ctx context.Context, buf int) <-chan Pair[K, V] {
+	ch := make(chan Pair[K, V], buf)
+	go func() {
+		defer close(ch)
+		for k, v := range m.All() {
+			select {
+			case ch <- Pair[K, V]{Key: k, Value: v}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Partitions splits the groups of the map into n disjoint ranges of about
+// the same size and returns an iterator over the entries of each range, so
+// that every partition can be handed to its own goroutine. Together the
+// partitions yield every entry exactly once. Fewer than n partitions are
+// returned if the map has fewer groups, and none if n is not positive. The
+// iterators may run concurrently with each other, but the map must not be
+// modified until they are done.
+func (m *Map[K, V]) Partitions(n int) []iter.Seq2[K, V] {
+	n = min(n, len(m.grps))
+	if n <= 0 {
+		return nil
+	}
+	m.settle()
+	groups := m.grps
+	res := make([]iter.Seq2[K, V], n)
+	for p := range res {
+		lo, hi := p*len(groups)/n, (p+1)*len(groups)/n
+		res[p] = func(yield func(K, V) bool) {
+			for i := lo; i < hi; i++ {
+				mask := groups[i].maskFull()
+				for mask != 0 {
+					s := &groups[i].slts[mask.first()]
+					if !yield(s.key, s.value) {
+						return
+					}
+					mask = mask.rmfirst()
 				}
-				mask = mask.rmfirst()
 			}
 		}
 	}
+	return res
+}
+
+// scan calls fn for every full slot until it returns false, starting at a
+// random group unless the map is deterministic.
+func (m *Map[K, V]) scan(fn func(s *slot[K, V]) bool) {
+	if m.deferGrowth {
+		m.iterators++
+		defer m.endIteration()
+	}
+	start := 0
+	if !m.deterministic && len(m.grps) > 1 {
+		start = rand.Intn(len(m.grps))
+	}
+	m.scanFrom(start, 0, fn)
+}
+
+// scanFrom calls fn for the full slots starting at the slot-th slot of
+// group start, wrapping around the end of the table and ending with the
+// slots of group start in front of slot.
+func (m *Map[K, V]) scanFrom(start int, slot uint32, fn func(s *slot[K, V]) bool) {
+	groups := m.grps
+	for n := range len(groups) + 1 {
+		i := start + n
+		if i >= len(groups) {
+			i -= len(groups)
+		}
+		if m.stale(uint32(i)) {
+			continue
+		}
+		mask := groups[i].maskFull()
+		switch {
+		case n == 0:
+			mask &= ^bitmask(0) << (slot * 8)
+		case n == len(groups):
+			if slot == 0 {
+				return
+			}
+			mask &= ^(^bitmask(0) << (slot * 8))
+		}
+		for mask != 0 {
+			if !fn(&groups[i].slts[mask.first()]) {
+				return
+			}
+			mask = mask.rmfirst()
+		}
+	}
+}
+
+// endIteration performs the growth postponed while iterating once the last
+// iterator has finished.
+func (m *Map[K, V]) endIteration() {
+	m.iterators--
+	if m.iterators == 0 && m.growPending {
+		m.growPending = false
+		if m.len > m.cap {
+			m.rehash()
+		}
+	}
+}
+
+// MergeFrom puts all entries of the built-in map src into the map, growing
+// it once beforehand to fit them, so no rehash happens while inserting. If
+// the growth gate denies that, the entries are put one by one and Put
+// panics with ErrFull once the gate denies a growth it needs.
+func (m *Map[K, V]) MergeFrom(src map[K]V) {
+	m.reserve(m.len + len(src))
+	for k, v := range src {
+		m.Put(k, v)
+	}
+}
+
+// MergeFromSeq puts all pairs yielded by seq into the map, in order, so
+// later pairs win over earlier ones with the same key.
+func (m *Map[K, V]) MergeFromSeq(seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.Put(k, v)
+	}
+}
+
+// Clone returns a copy of the map. The copy shares no memory with the
+// original, but keys and values are copied shallowly.
+func (m *Map[K, V]) Clone() *Map[K, V] {
+	c := *m
+	c.grps = slices.Clone(m.grps)
+	c.alloc, c.release = nil, nil
+	c.adviseHugePages()
+	c.filter = slices.Clone(m.filter)
+	c.gens = slices.Clone(m.gens)
+	c.meta = slices.Clone(m.meta)
+	c.iterators, c.growPending = 0, false
+	c.cp = nil
+	c.dirty = nil
+	c.obs = nil
+	if m.sums != nil {
+		c.sums = &checksums{sums: slices.Clone(m.sums.sums), pending: m.sums.pending, verifyReads: m.sums.verifyReads}
+	}
+	c.copyIndexes(m)
+	return &c
+}
+
+// Hash returns the seeded hash of the key as computed by the map itself.
+// It is stable for the lifetime of the map, unless the map is reseeded by a
+// ProbeMonitor, and can be used to partition work consistently with the
+// map's internal placement.
+func (m *Map[K, V]) Hash(key K) uint64 {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	return uint64(m.hashKey(key))
 }
 
 // rehash reorganizes the map by creating new groups and reinserting all
@@ -248,31 +984,106 @@ func (m *Map[K, V]) All() iter.Seq2[K, V] {
 // The function is triggered when the map reaches a certain load factor or
 // when tombstones accumulate excessively.
 func (m *Map[K, V]) rehash() {
-	newsize := newsize(m.cap, m.tombstones)
-	groups := m.grps
-	ngroups := groupsnum(newsize)
-	m.grps = make([]group[K, V], ngroups)
+	ngroups, ok := m.growTarget()
+	if !ok {
+		panic(errTooLarge)
+	}
+	m.resize(ngroups)
+}
+
+// growTarget returns the number of groups for the next rehash. It reports
+// false if the map is as large as it can be and has no tombstones to drain.
+func (m *Map[K, V]) growTarget() (int, bool) {
+	ngroups := min(groupsnum(newsize(m.cap, m.tombstones)), maxGroups[K, V]())
+	return ngroups, ngroups > len(m.grps) || m.tombstones > 0
+}
+
+// reserve grows the map, if needed, so that n entries fit without a rehash.
+// If the growth gate denies it, the map is left as is and the gate is
+// consulted again by the inserts that need the map to grow.
+func (m *Map[K, V]) reserve(n int) {
+	if n > m.cap && m.allowGrowthTo(groupsnum(n)) {
+		m.resize(groupsnum(n))
+	}
+}
+
+// resize reinserts all entries into ngroups new groups. It panics if the
+// groups cannot be addressed, instead of truncating their number.
+func (m *Map[K, V]) resize(ngroups int) {
+	if ngroups > maxGroups[K, V]() {
+		panic(errTooLarge)
+	}
+	if m.onGrow != nil {
+		defer func(oldCap int, start time.Time) {
+			m.onGrow(oldCap, m.cap, time.Since(start))
+		}(m.cap, time.Now())
+	}
+	groups, version, monitor := m.grps, m.version, m.monitor
+	if ngroups > len(groups) {
+		m.reseeded = false
+	}
+	m.monitor, m.maxProbe = nil, 0
+	obs, sums := m.obs, m.sums
+	m.obs, m.sums = nil, nil
+	gens, gen, meta := m.gens, m.gen, m.meta
+	if m.alloc != nil {
+		m.grps = m.alloc(ngroups)
+	} else {
+		m.grps = make([]group[K, V], ngroups)
+	}
+	m.adviseHugePages()
 	m.ngroups = uint32(ngroups)
 	m.cap = ngroups * grpload
 	m.len, m.tombstones = 0, 0
+	if m.filter != nil {
+		m.filter, m.filterDeletes = newFilter(ngroups), 0
+	}
+	if m.gens != nil {
+		m.gens, m.gen = make([]uint32, ngroups), 0
+	}
+	if m.meta != nil {
+		m.meta = make([]slotMeta, ngroups*grpssz)
+	}
+	if m.dirty != nil {
+		m.dirty.reset(ngroups)
+	}
 	m.groups(func(g *group[K, V]) bool {
 		g.cntrl = emptyContol
 		return true
 	})
 	for i := range groups {
+		if gens != nil && gens[i] != gen {
+			continue
+		}
 		mask := groups[i].maskFull()
 		for mask != 0 {
 			j := mask.first()
-			m.Put(groups[i].slts[j].key, groups[i].slts[j].value)
+			m.put(groups[i].slts[j].key, groups[i].slts[j].value)
+			if meta != nil {
+				m.meta[m.slotIndex(m.lookup(groups[i].slts[j].key))] = meta[i*grpssz+int(j)]
+			}
 			mask = mask.rmfirst()
 		}
 	}
+	if m.release != nil {
+		m.release(groups)
+	}
+	m.version, m.monitor = version, monitor
+	m.obs = obs
+	if sums != nil {
+		// Checksums are computed once after reinserting all entries.
+		m.sums = sums
+		m.resetSums()
+	}
 }
 
 func newsize(oldsize, tombstones int) int {
 	if tombstones >= oldsize/2 {
 		return oldsize
 	}
+	if oldsize > math.MaxInt/2 {
+		return math.MaxInt
+	}
 	return oldsize * 2
 }
 
@@ -288,22 +1099,43 @@ func (c *control) set(i uint32, value uint8) {
 	*(*uint8)(unsafe.Add(unsafe.Pointer(c), i)) = value
 }
 
+func (c *control) get(i uint32) uint8 {
+	return *(*uint8)(unsafe.Add(unsafe.Pointer(c), i))
+}
+
 type bitmask uint64
 
 func (g *group[K, V]) match(h2 uintptr) bitmask {
-	// https://github.com/abseil/abseil-cpp/blob/master/absl/container/internal/raw_hash_set.h#L842
-	x := uint64(g.cntrl) ^ (kLsbsBytes * uint64(h2))
-	return bitmask(((x - kLsbsBytes) &^ x) & kMsbsBytes)
+	return g.cntrl.match(h2)
 }
 
 // maskEmpty returns a bitmask representing the positions of empty slots
 func (g *group[K, V]) maskEmpty() bitmask {
-	return bitmask((g.cntrl &^ (g.cntrl << 6)) & kMsbsBytes)
+	return g.cntrl.maskEmpty()
+}
+
+func (c control) match(h2 uintptr) bitmask {
+	// https://github.com/abseil/abseil-cpp/blob/master/absl/container/internal/raw_hash_set.h#L842
+	x := uint64(c) ^ (kLsbsBytes * uint64(h2))
+	return bitmask(((x - kLsbsBytes) &^ x) & kMsbsBytes)
+}
+
+// with returns the control word with the i-th byte set to value.
+func (c control) with(i uint32, value uint8) control {
+	return c&^(0xff<<(8*i)) | control(value)<<(8*i)
+}
+
+func (c control) maskEmpty() bitmask {
+	return bitmask((c &^ (c << 6)) & kMsbsBytes)
 }
 
 // maskFull returns a bitmask representing the positions of full slots
 func (g *group[K, V]) maskFull() bitmask {
-	return bitmask((g.cntrl ^ kMsbsBytes) & kMsbsBytes)
+	return g.cntrl.maskFull()
+}
+
+func (c control) maskFull() bitmask {
+	return bitmask((c ^ kMsbsBytes) & kMsbsBytes)
 }
 
 // maskNonFull returns a bitmask representing the positions of non full slots
@@ -312,11 +1144,18 @@ func (g *group[K, V]) maskNonFull() bitmask {
 }
 
 func (g *group[K, V]) maskEmptyOrDeleted() bitmask {
-	return bitmask((g.cntrl &^ (g.cntrl << 7)) & kMsbsBytes)
+	return g.cntrl.maskEmptyOrDeleted()
 }
 
+func (c control) maskEmptyOrDeleted() bitmask {
+	return bitmask((c &^ (c << 7)) & kMsbsBytes)
+}
+
+// first returns the index of the first slot set in the mask, which must not
+// be empty. The index is masked to the group size, which is free for valid
+// masks and lets the compiler drop the bounds checks on slts.
 func (b bitmask) first() uint32 {
-	return uint32(bits.TrailingZeros64(uint64(b))) >> 3
+	return uint32(bits.TrailingZeros64(uint64(b))) >> 3 & (grpssz - 1)
 }
 
 func (b bitmask) rmfirst() bitmask {
@@ -329,17 +1168,36 @@ func groupsnum(n int) int {
 	if n == 0 {
 		n = 10
 	}
-	return (n + grpload + 1) / grpload
+	// (n + grpload + 1) / grpload, without overflowing near MaxInt.
+	return n/grpload + (n%grpload+grpload+1)/grpload
 }
 
 // h1 and h2 split the hash value into two parts. h1 determines the group,
 // while h2 is used for matching the control bytes within that group.
-func h1(hash uintptr) uintptr {
-	return hash >> 7
+func (m *Map[K, V]) h1(hash uintptr) uintptr {
+	return hash >> m.h1shift
+}
+
+func (m *Map[K, V]) h2(hash uintptr) uintptr {
+	return (hash >> m.h2shift) & m.h2mask
 }
 
-func h2(hash uintptr) uintptr {
-	return hash & 0x7F
+// setSplit precomputes the shifts and the mask used by h1 and h2.
+func (m *Map[K, V]) setSplit(split HashSplit) {
+	m.h2mask = 1<<split.H2Bits - 1
+	if split.H2High {
+		m.h1shift, m.h2shift = 0, uint8(bits.UintSize)-split.H2Bits
+	} else {
+		m.h1shift, m.h2shift = split.H2Bits, 0
+	}
+}
+
+// HashSplit returns the H1/H2 split used by the map.
+func (m *Map[K, V]) HashSplit() HashSplit {
+	if m.h1shift == 0 {
+		return HashSplit{H2Bits: uint8(bits.UintSize) - m.h2shift, H2High: true}
+	}
+	return HashSplit{H2Bits: m.h1shift}
 }
 
 // noescape hides a pointer from escape analysis.  noescape is
@@ -347,21 +1205,75 @@ func h2(hash uintptr) uintptr {
 // output depends on the input. noescape is inlined and currently
 // compiles down to zero instructions.
 // USE CAREFULLY!
-// This was copied from the runtime; see issues 23382 and 7921.
+// This was adapted from the runtime; see issues 23382 and 7921. The runtime
+// converts x ^ 0 back with unsafe.Pointer(x ^ 0), which go vet reports as a
+// possible misuse of unsafe.Pointer outside the runtime, so the result is
+// loaded through a pointer to x instead, which compiles to the same code.
+//
+// It is only used to pass the address of a key to a hash function. Those
+// are indirect calls or body-less runtime functions, so escape analysis
+// would otherwise move every key to the heap. This is safe because the hash
+// functions only read the key during the call and never keep p. Since
+// noescape compiles to no instructions, nothing runs between the conversion
+// to uintptr and the load, so the stack cannot move while the address is
+// held as an integer.
 //
 //go:nosplit
 //go:nocheckptr
 func noescape(p unsafe.Pointer) unsafe.Pointer {
-	x := uintptr(p)
-	return unsafe.Pointer(x ^ 0)
+	x := uintptr(p) ^ 0
+	return *(*unsafe.Pointer)(unsafe.Pointer(&x))
+}
+
+// hashKind selects a direct call to the hash function of common key types,
+// saving the indirect call through hashfn on every operation.
+type hashKind uint8
+
+const (
+	hashIndirect hashKind = iota
+	hashWord              // 8-byte integers, hashed with hash.Word
+	hashString            // strings, hashed with hash.String
+)
+
+// devirtualize returns the hash function and kind for keys of type K hashed
+// with the default function hashfn. Word keys switch to hash.Word for hashfn
+// as well, so that both ways of hashing agree.
+func devirtualize[K comparable](hashfn hash.HFunc) (hash.HFunc, hashKind) {
+	var k K
+	switch any(k).(type) {
+	case int, uint, uintptr, int64, uint64:
+		if unsafe.Sizeof(k) == 8 {
+			return hash.Word, hashWord
+		}
+	case string:
+		return hashfn, hashString
+	}
+	return hashfn, hashIndirect
+}
+
+// hashKey hashes the key with the hash function of the map.
+func (m *Map[K, V]) hashKey(key K) uintptr {
+	switch m.hkind {
+	case hashWord:
+		return hash.Word(noescape(unsafe.Pointer(&key)), m.seed)
+	case hashString:
+		return hash.String(noescape(unsafe.Pointer(&key)), m.seed)
+	}
+	return m.hashfn(noescape(unsafe.Pointer(&key)), m.seed)
+}
+
+// grp returns the group at index ngrp, which must be below m.ngroups. It
+// avoids the bounds check of indexing m.grps on every hop of a probe.
+func (m *Map[K, V]) grp(ngrp uint32) *group[K, V] {
+	return (*group[K, V])(unsafe.Add(unsafe.Pointer(unsafe.SliceData(m.grps)), uintptr(ngrp)*unsafe.Sizeof(group[K, V]{})))
 }
 
 // find isn't used in the code, as it's inlined, but kept here for informational purposes only
 func (m *Map[K, V]) find(key K, hash uintptr) (uint32, uint32, bool) {
-	ngrp := uint32(h1(hash)) % m.ngroups
+	ngrp := uint32(m.h1(hash)) % m.ngroups
 	for {
 		group := &m.grps[ngrp]
-		equal := group.match(h2(hash))
+		equal := group.match(m.h2(hash))
 		for equal != 0 {
 			i := equal.first()
 			if key == group.slts[i].key {
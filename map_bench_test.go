@@ -2,7 +2,6 @@ package swiss
 
 import (
 	randn "math/rand"
-	"math/rand/v2"
 	"runtime"
 	"strconv"
 	"testing"
@@ -48,10 +47,12 @@ func BenchmarkHashFuncsIntInt(b *testing.B) {
 		runtime := make(map[int]int)
 		swiss := newRuntimeHash[int, int](size)
 		swissMemhash := newMemHash[int, int](size)
+		swissDirect := New[int, int](size)
 		for _, key := range keys {
 			runtime[key] = key
 			swiss.Put(key, key)
 			swissMemhash.Put(key, key)
+			swissDirect.Put(key, key)
 		}
 		b.Run("runtime map, size: "+strconv.Itoa(size), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
@@ -68,6 +69,11 @@ func BenchmarkHashFuncsIntInt(b *testing.B) {
 				_, _ = swissMemhash.Get(keys[i&mod])
 			}
 		})
+		b.Run("swiss direct hash, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = swissDirect.Get(keys[i&mod])
+			}
+		})
 	}
 }
 
@@ -79,10 +85,12 @@ func BenchmarkHashFuncsStringString(b *testing.B) {
 		runtime := make(map[string]string)
 		swiss := newRuntimeHash[string, string](size)
 		swissMemhash := newMemHash[string, string](size)
+		swissDirect := New[string, string](size)
 		for _, key := range keys {
 			runtime[key] = key
 			swiss.Put(key, key)
 			swissMemhash.Put(key, key)
+			swissDirect.Put(key, key)
 		}
 		b.Run("runtime map, size: "+strconv.Itoa(size), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
@@ -99,6 +107,61 @@ func BenchmarkHashFuncsStringString(b *testing.B) {
 				_, _ = swissMemhash.Get(keys[i&mod])
 			}
 		})
+		b.Run("swiss direct hash, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = swissDirect.Get(keys[i&mod])
+			}
+		})
+	}
+}
+
+func BenchmarkIdentityHashGetIntInt(b *testing.B) {
+	sizes := []int{128, 1024, 16384, 131072, 1048576}
+	for _, size := range sizes {
+		mod := size - 1
+		keys := genIntKeys(size)
+		swiss := New[int, int](size)
+		identity := New[int, int](size, WithIdentityHash())
+		for _, key := range keys {
+			swiss.Put(key, key)
+			identity.Put(key, key)
+		}
+		b.Run("swiss, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = swiss.Get(keys[i&mod])
+			}
+		})
+		b.Run("swiss identity, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = identity.Get(keys[i&mod])
+			}
+		})
+	}
+}
+
+func BenchmarkPerfectGetIntInt(b *testing.B) {
+	sizes := []int{128, 1024, 16384, 131072, 1048576}
+	for _, size := range sizes {
+		mod := size - 1
+		keys := genIntKeys(size)
+		swiss := New[int, int](size)
+		for _, key := range keys {
+			swiss.Put(key, key)
+		}
+		perfect, err := BuildPerfect(swiss.All())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run("swiss, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = swiss.Get(keys[i&mod])
+			}
+		})
+		b.Run("perfect, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = perfect.Get(keys[i&mod])
+			}
+		})
 	}
 }
 
@@ -217,10 +280,12 @@ func BenchmarkGetAbsentElements(b *testing.B) {
 	sizes := []int{100, 1000, 10000, 100000, 1000000}
 	for _, size := range sizes {
 		swiss := New[int, int](size)
+		filtered := New[int, int](size, WithFilter())
 		builtin := make(map[int]int, size)
 		for i := range size / 2 {
 			key := size + i
 			swiss.Put(key, key)
+			filtered.Put(key, key)
 			builtin[key] = key
 		}
 		b.Run("runtime map, size: "+strconv.Itoa(size), func(b *testing.B) {
@@ -233,6 +298,11 @@ func BenchmarkGetAbsentElements(b *testing.B) {
 				_, _ = swiss.Get(randn.Intn(size))
 			}
 		})
+		b.Run("swiss filter, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for range b.N {
+				_, _ = filtered.Get(randn.Intn(size))
+			}
+		})
 	}
 }
 
@@ -257,33 +327,168 @@ func BenchmarkControlSet(b *testing.B) {
 }
 
 func newRuntimeHash[K comparable, V any](size int) *Map[K, V] {
-	ngroups := groupsnum(size)
-	m := &Map[K, V]{
-		grps:    make([]group[K, V], ngroups),
-		ngroups: uint32(ngroups),
-		hashfn:  hash.GetHashFuncRnt[K](),
-		seed:    uintptr(rand.Uint64()),
-		cap:     ngroups * grpload,
+	return newMap[K, V](size, hash.GetHashFuncRnt[K](), defaultOptions())
+}
+
+func newMemHash[K comparable, V any](size int) *Map[K, V] {
+	return newMap[K, V](size, hash.GetHashFuncMemhash[K](), defaultOptions())
+}
+
+func BenchmarkPairKeys(b *testing.B) {
+	type pair struct{ a, b uint64 }
+	size := 1 << 16
+	mod := size - 1
+	structs := New[pair, int](size)
+	pairs := NewPairKeyed[uint64, uint64, int](size)
+	keys := make([]pair, size)
+	for i := range keys {
+		keys[i] = pair{randn.Uint64(), randn.Uint64()}
+		structs.Put(keys[i], i)
+		pairs.Put(hash.NewPair(keys[i].a, keys[i].b), i)
 	}
-	m.groups(func(g *group[K, V]) bool {
-		g.cntrl = emptyContol
-		return true
+	b.Run("struct memhash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = structs.Get(keys[i&mod])
+		}
+	})
+	b.Run("hash.Pair", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			k := keys[i&mod]
+			_, _ = pairs.Get(hash.NewPair(k.a, k.b))
+		}
 	})
-	return m
 }
 
-func newMemHash[K comparable, V any](size int) *Map[K, V] {
-	ngroups := groupsnum(size)
-	m := &Map[K, V]{
-		grps:    make([]group[K, V], ngroups),
-		ngroups: uint32(ngroups),
-		hashfn:  hash.GetHashFuncMemhash[K](),
-		seed:    uintptr(rand.Uint64()),
-		cap:     ngroups * grpload,
+func BenchmarkHashers(b *testing.B) {
+	ints := genIntKeys(1 << 16)
+	strs := genStringKeys(1 << 16)
+	mod := len(ints) - 1
+	for _, h := range []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"default", DefaultHasher},
+		{"fnv1a", FNV1aHasher},
+		{"djb2", DJB2Hasher},
+	} {
+		im := New[int, int](len(ints), WithHasher(h.hasher))
+		sm := New[string, int](len(strs), WithHasher(h.hasher))
+		for i := range ints {
+			im.Put(ints[i], i)
+			sm.Put(strs[i], i)
+		}
+		b.Run("int "+h.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = im.Get(ints[i&mod])
+			}
+		})
+		b.Run("string "+h.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = sm.Get(strs[i&mod])
+			}
+		})
+	}
+}
+
+// BenchmarkLongKeys hashes 100-byte keys, for which the default hasher uses
+// the runtime's aeshash where the CPU supports it. Compare with
+// GODEBUG=cpu.aes=off to measure the fallback.
+func BenchmarkLongKeys(b *testing.B) {
+	const n = 1 << 14
+	strs := make([]string, n)
+	arrs := make([][100]byte, n)
+	for i := range n {
+		strs[i] = genRandomString(100)
+		copy(arrs[i][:], strs[i])
+	}
+	for _, h := range []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"default", DefaultHasher},
+		{"fnv1a", FNV1aHasher},
+	} {
+		sm := New[string, int](n, WithHasher(h.hasher))
+		am := New[[100]byte, int](n, WithHasher(h.hasher))
+		for i := range n {
+			sm.Put(strs[i], i)
+			am.Put(arrs[i], i)
+		}
+		b.Run("string "+h.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = sm.Get(strs[i&(n-1)])
+			}
+		})
+		b.Run("array "+h.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = am.Get(arrs[i&(n-1)])
+			}
+		})
 	}
-	m.groups(func(g *group[K, V]) bool {
-		g.cntrl = emptyContol
-		return true
+}
+
+func BenchmarkU64Set(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 20} {
+		keys := make([]uint64, size)
+		for i := range keys {
+			keys[i] = randn.Uint64()
+		}
+		u := NewU64Set(size)
+		set := NewSet[uint64](size)
+		for _, key := range keys {
+			u.Add(key)
+			set.Add(key)
+		}
+		mod := size - 1
+		b.Run("u64set has, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = u.Has(keys[i&mod])
+			}
+		})
+		b.Run("set has, size: "+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = set.Has(keys[i&mod])
+			}
+		})
+	}
+}
+
+func BenchmarkConcurrentReadMostly(b *testing.B) {
+	const size = 1 << 16
+	keys := genIntKeys(size)
+	safe := NewSafeMap[int, int](size)
+	lf := NewLockFree[int, int](size)
+	for _, key := range keys {
+		safe.Put(key, key)
+		lf.Put(key, key)
+	}
+	// One operation in a hundred is a Put.
+	b.Run("safemap", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := randn.Int()
+			for pb.Next() {
+				key := keys[i&(size-1)]
+				if i%100 == 0 {
+					safe.Put(key, i)
+				} else {
+					_, _ = safe.Get(key)
+				}
+				i++
+			}
+		})
+	})
+	b.Run("lockfree", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := randn.Int()
+			for pb.Next() {
+				key := keys[i&(size-1)]
+				if i%100 == 0 {
+					lf.Put(key, i)
+				} else {
+					_, _ = lf.Get(key)
+				}
+				i++
+			}
+		})
 	})
-	return m
 }
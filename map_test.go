@@ -1,9 +1,18 @@
 package swiss
 
 import (
+	"context"
+	"fmt"
+	"math"
 	randn "math/rand"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -397,6 +406,592 @@ func TestIterator(t *testing.T) {
 		}
 		assert.NotEqual(t, cnt, swiss.Len())
 	})
+	t.Run("random start", func(t *testing.T) {
+		first := func(m *Map[int, int]) int {
+			for k := range m.All() {
+				return k
+			}
+			return -1
+		}
+		starts := make(map[int]bool)
+		for range 100 {
+			starts[first(swiss)] = true
+		}
+		assert.Greater(t, len(starts), 1)
+		det := New[int, int](size, WithDeterministic(1))
+		for i := range size {
+			det.Put(i, i)
+		}
+		k := first(det)
+		for range 100 {
+			assert.Equal(t, k, first(det))
+		}
+	})
+}
+
+func TestGetPtrModify(t *testing.T) {
+	t.Parallel()
+	type value struct {
+		n   int
+		pad [200]byte
+	}
+	m := New[int, value](0)
+	for i := range 1000 {
+		m.Put(i, value{n: i})
+	}
+	for i := range 1000 {
+		p := m.GetPtr(i)
+		require.NotNil(t, p)
+		p.n++
+		require.True(t, m.Modify(i, func(v *value) { v.n *= 2 }))
+	}
+	for i := range 1000 {
+		v, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, 2*(i+1), v.n)
+	}
+	require.Nil(t, m.GetPtr(-1))
+	require.False(t, m.Modify(-1, func(*value) { t.Fatal("called for absent key") }))
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithMetadata())
+	// Count occurrences, deleting keys whose count reaches 3.
+	for _, k := range []int{1, 2, 1, 3, 1, 2} {
+		m.Do(k, func(v *int, exists bool) (int, bool, bool) {
+			return *v + 1, true, *v+1 == 3
+		})
+	}
+	_, ok := m.Get(1)
+	require.False(t, ok)
+	v, _ := m.Get(2)
+	require.Equal(t, 2, v)
+	v, _ = m.Get(3)
+	require.Equal(t, 1, v)
+	require.Equal(t, 2, m.Len())
+
+	version := m.Version()
+	m.Do(4, func(v *int, exists bool) (int, bool, bool) {
+		require.False(t, exists)
+		require.Zero(t, *v)
+		return 0, false, false
+	})
+	m.Do(2, func(v *int, exists bool) (int, bool, bool) {
+		require.True(t, exists)
+		return 0, false, false
+	})
+	require.Equal(t, version, m.Version())
+	_, ok = m.Get(4)
+	require.False(t, ok)
+
+	for i := range 1000 {
+		m.Do(i, func(v *int, exists bool) (int, bool, bool) {
+			return i, true, false
+		})
+	}
+	for i := range 1000 {
+		m.Do(i, func(v *int, exists bool) (int, bool, bool) {
+			require.Equal(t, i, *v)
+			return -i, true, i%2 == 0
+		})
+	}
+	require.Equal(t, 500, m.Len())
+	for k, v := range m.All() {
+		require.Equal(t, -k, v)
+		if k > 4 {
+			_, meta, _ := m.GetWithMeta(k)
+			require.Equal(t, uint64(1), meta.Hits)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	require.False(t, Replace(m, "a", 0, 1))
+	_, ok := m.Get("a")
+	require.False(t, ok)
+	m.Put("a", 1)
+	require.False(t, Replace(m, "a", 2, 3))
+	require.True(t, Replace(m, "a", 1, 3))
+	v, _ := m.Get("a")
+	require.Equal(t, 3, v)
+	version := m.Version()
+	require.False(t, Replace(m, "a", 1, 4))
+	require.Equal(t, version, m.Version())
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	require.False(t, m.Rename("a", "b"))
+	m.Put("a", 1)
+	m.Put("c", 3)
+	require.True(t, m.Rename("a", "a"))
+	require.True(t, m.Rename("a", "b"))
+	_, ok := m.Get("a")
+	require.False(t, ok)
+	v, _ := m.Get("b")
+	require.Equal(t, 1, v)
+	require.True(t, m.Rename("b", "c"))
+	v, _ = m.Get("c")
+	require.Equal(t, 1, v)
+	require.Equal(t, 1, m.Len())
+
+	keys := New[int, int](0)
+	for i := range 1000 {
+		keys.Put(i, i)
+	}
+	for i := range 1000 {
+		require.True(t, keys.Rename(i, i+1000))
+	}
+	require.Equal(t, 1000, keys.Len())
+	for k, v := range keys.All() {
+		require.Equal(t, k-1000, v)
+	}
+}
+
+func TestMergeFrom(t *testing.T) {
+	t.Parallel()
+	var grows int
+	m := New[int, int](0, WithGrowCallback(func(int, int, time.Duration) { grows++ }))
+	m.Put(-1, -1)
+	src := make(map[int]int)
+	for i := range 10000 {
+		src[i] = i
+	}
+	m.MergeFrom(src)
+	require.Equal(t, 1, grows)
+	require.Equal(t, 10001, m.Len())
+	for k, v := range src {
+		got, ok := m.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+	m.MergeFromSeq(func(yield func(int, int) bool) {
+		_ = yield(1, 10) && yield(1, 20) && yield(-2, 0)
+	})
+	v, _ := m.Get(1)
+	require.Equal(t, 20, v)
+	require.Equal(t, 10002, m.Len())
+}
+
+func TestHasher(t *testing.T) {
+	t.Parallel()
+	type point struct{ x, y int32 }
+	for _, h := range []Hasher{DefaultHasher, FNV1aHasher, DJB2Hasher} {
+		s := New[string, int](0, WithHasher(h))
+		p := New[point, int](0, WithHasher(h))
+		for i := range 10000 {
+			s.Put(fmt.Sprint(i), i)
+			p.Put(point{int32(i), int32(-i)}, i)
+		}
+		for i := range 10000 {
+			v, ok := s.Get(fmt.Sprint(i))
+			require.True(t, ok)
+			require.Equal(t, i, v)
+			v, ok = p.Get(point{int32(i), int32(-i)})
+			require.True(t, ok)
+			require.Equal(t, i, v)
+		}
+	}
+	require.PanicsWithError(t, errHasherKey.Error(), func() {
+		New[float64, int](0, WithHasher(FNV1aHasher))
+	})
+	require.Panics(t, func() { New[int, int](0, WithHasher(BinaryHasher+1)) })
+}
+
+type binaryKey struct {
+	id   uint32
+	name string
+}
+
+func (k binaryKey) MarshalBinary() ([]byte, error) {
+	return fmt.Appendf(nil, "%d/%s", k.id, k.name), nil
+}
+
+func TestBinaryHasher(t *testing.T) {
+	t.Parallel()
+	m := New[binaryKey, int](0, WithHasher(BinaryHasher))
+	for i := range 1000 {
+		m.Put(binaryKey{uint32(i), fmt.Sprint(i)}, i)
+	}
+	for i := range 1000 {
+		v, ok := m.Get(binaryKey{uint32(i), fmt.Sprint(i)})
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	// time.Time implements AppendBinary; the location is part of the key.
+	times := New[time.Time, int](0, WithHasher(BinaryHasher))
+	now := time.Now().Round(0)
+	times.Put(now, 1)
+	times.Put(now.UTC(), 2)
+	require.Equal(t, 2, times.Len())
+	v, _ := times.Get(now)
+	require.Equal(t, 1, v)
+	require.PanicsWithError(t, errHasherKey.Error(), func() {
+		New[int, int](0, WithHasher(BinaryHasher))
+	})
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	require.Zero(t, m.Version())
+	last := m.Version()
+	changed := func() bool {
+		v := m.Version()
+		defer func() { last = v }()
+		return v > last
+	}
+	for i := range 1000 {
+		m.Put(i, i)
+		require.True(t, changed())
+	}
+	m.Put(1, 2)
+	require.True(t, changed())
+	m.Get(1)
+	m.Delete(-1)
+	require.False(t, m.Modify(-1, func(*int) {}))
+	require.False(t, changed())
+	m.Delete(1)
+	require.True(t, changed())
+	require.True(t, m.Modify(2, func(v *int) { *v++ }))
+	require.True(t, changed())
+	m.Clear()
+	require.True(t, changed())
+}
+
+func TestAllFrom(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithDeterministic(1))
+	for i := range 1000 {
+		m.Put(i, -i)
+	}
+	var order []int
+	for k := range m.All() {
+		order = append(order, k)
+	}
+	for _, pos := range []int{0, 1, 357, 999} {
+		var got []int
+		for k, v := range m.AllFrom(order[pos]) {
+			require.Equal(t, -k, v)
+			got = append(got, k)
+		}
+		require.Equal(t, append(order[pos:len(order):len(order)], order[:pos]...), got)
+	}
+
+	// Resuming an interrupted export at its last key and stopping at its
+	// first key visits every key exactly once.
+	seen := make(map[int]int)
+	var last int
+	for k := range m.AllFrom(500) {
+		seen[k]++
+		if len(seen) == 400 {
+			last = k
+			break
+		}
+	}
+	for k := range m.AllFrom(last) {
+		if k == 500 {
+			break
+		}
+		if k != last {
+			seen[k]++
+		}
+	}
+	require.Len(t, seen, 1000)
+	for _, n := range seen {
+		require.Equal(t, 1, n)
+	}
+
+	var cnt int
+	for range m.AllFrom(-1) {
+		cnt++
+	}
+	require.Equal(t, 1000, cnt)
+	for range m.AllFrom(5) {
+		cnt++
+		break
+	}
+	require.Equal(t, 1001, cnt)
+}
+
+func TestAllWhere(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	for i := range 1000 {
+		m.Put(i, -i)
+	}
+	var cnt int
+	for k, v := range m.AllWhere(func(k, v int) bool { return k%10 == 0 && v > -500 }) {
+		require.Zero(t, k%10)
+		require.Equal(t, -k, v)
+		cnt++
+	}
+	require.Equal(t, 50, cnt)
+	cnt = 0
+	for k, v := range m.FilterKeys(func(k int) bool { return k >= 900 }) {
+		require.GreaterOrEqual(t, k, 900)
+		require.Equal(t, -k, v)
+		cnt++
+	}
+	require.Equal(t, 100, cnt)
+	for range m.FilterKeys(func(int) bool { return true }) {
+		cnt++
+		break
+	}
+	require.Equal(t, 101, cnt)
+}
+
+func TestPartitions(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithLazyClear())
+	m.Put(-1, 1)
+	m.Clear()
+	for i := range 10000 {
+		m.Put(i, -i)
+	}
+	for _, n := range []int{1, 3, 8, 1 << 20} {
+		parts := m.Partitions(n)
+		require.Len(t, parts, min(n, len(m.grps)))
+		var wg sync.WaitGroup
+		counts := make([]map[int]int, len(parts))
+		for p, part := range parts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				counts[p] = make(map[int]int)
+				for k, v := range part {
+					assert.Equal(t, -k, v)
+					counts[p][k]++
+				}
+			}()
+		}
+		wg.Wait()
+		seen := make(map[int]int)
+		for _, c := range counts {
+			for k, cnt := range c {
+				seen[k] += cnt
+			}
+		}
+		require.Len(t, seen, 10000)
+		for _, cnt := range seen {
+			require.Equal(t, 1, cnt)
+		}
+	}
+	require.Nil(t, m.Partitions(0))
+}
+
+func TestAllChan(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	for i := range 1000 {
+		m.Put(i, -i)
+	}
+	seen := make(map[int]bool)
+	for p := range m.AllChan(context.Background(), 16) {
+		require.Equal(t, -p.Key, p.Value)
+		seen[p.Key] = true
+	}
+	require.Len(t, seen, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := m.AllChan(ctx, 0)
+	<-ch
+	cancel()
+	var n int
+	for range ch {
+		n++
+	}
+	require.Less(t, n, 999)
+}
+
+func TestHashSplit(t *testing.T) {
+	t.Parallel()
+	tests := []HashSplit{
+		AbseilSplit,
+		{H2Bits: 7, H2High: true},
+		{H2Bits: 3},
+		{H2Bits: 1, H2High: true},
+	}
+	size := 10000
+	for _, split := range tests {
+		m := New[int, int](size/10, WithHashSplit(split))
+		require.Equal(t, split, m.HashSplit())
+		keys := genIntKeys(size)
+		for _, key := range keys {
+			m.Put(key, key)
+		}
+		for _, key := range keys[:size/2] {
+			m.Delete(key)
+		}
+		for _, key := range keys[:size/2] {
+			_, ok := m.Get(key)
+			require.False(t, ok, "split %+v", split)
+		}
+		for _, key := range keys[size/2:] {
+			value, ok := m.Get(key)
+			require.True(t, ok, "split %+v", split)
+			require.Equal(t, key, value)
+		}
+		for g := range m.grps {
+			mask := m.grps[g].maskFull()
+			for mask != 0 {
+				i := mask.first()
+				require.Less(t, uint8(m.grps[g].cntrl>>(8*i)), uint8(1)<<split.H2Bits)
+				mask = mask.rmfirst()
+			}
+		}
+	}
+	assert.Panics(t, func() { New[int, int](0, WithHashSplit(HashSplit{H2Bits: 8})) })
+	assert.Panics(t, func() { New[int, int](0, WithHashSplit(HashSplit{})) })
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	other := New[string, int](0)
+	key := genRandomString(16)
+	require.Equal(t, m.Hash(key), m.Hash(strings.Clone(key)))
+	require.NotEqual(t, m.Hash(key), other.Hash(key))
+	hash := uintptr(m.Hash(key))
+	m.Put(key, 1)
+	g := &m.grps[uint32(m.h1(hash))%m.ngroups]
+	require.NotZero(t, g.match(m.h2(hash)))
+}
+
+func TestIdentityHash(t *testing.T) {
+	t.Parallel()
+	size := 100_000
+	m := New[uint64, int](size/10, WithIdentityHash())
+	expected := make(map[uint64]int, size)
+	for range size {
+		k := randn.Uint64()
+		m.Put(k, int(k))
+		expected[k] = int(k)
+	}
+	require.Equal(t, len(expected), m.Len())
+	for k, v := range expected {
+		value, ok := m.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, value)
+	}
+	require.Equal(t, uint64(42)^uint64(m.seed), m.Hash(42))
+	small := New[int8, int](0, WithIdentityHash())
+	for i := range 128 {
+		small.Put(int8(i), i)
+	}
+	require.Equal(t, 128, small.Len())
+	assert.Panics(t, func() { New[string, int](0, WithIdentityHash()) })
+	assert.Panics(t, func() { New[float64, int](0, WithIdentityHash()) })
+}
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+	keys := func(m *Map[string, int]) []string {
+		var res []string
+		for k := range m.All() {
+			res = append(res, k)
+		}
+		return res
+	}
+	build := func() *Map[string, int] {
+		m := New[string, int](0, WithDeterministic(42))
+		for i := range 1000 {
+			m.Put(strings.Repeat("k", i%7)+string(rune('a'+i%26))+strings.Repeat("x", i/26), i)
+			if i%3 == 0 {
+				m.Delete(strings.Repeat("k", (i/2)%7) + "a")
+			}
+		}
+		return m
+	}
+	require.Equal(t, keys(build()), keys(build()))
+	m := build()
+	require.Equal(t, m.Hash("key"), m.Hash(strings.Clone("key")))
+
+	type key struct {
+		s string
+		f float64
+		i any
+	}
+	k := New[key, int](0, WithDeterministic(1))
+	k.Put(key{"a", 0, 1}, 1)
+	k.Put(key{"a", 1, 1}, 2)
+	k.Put(key{"a", 0, "1"}, 3)
+	require.Equal(t, 3, k.Len())
+	v, ok := k.Get(key{strings.Clone("a"), math.Copysign(0, -1), 1})
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	v, ok = k.Get(key{"a", 0, "1"})
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+	_, ok = k.Get(key{"a", 0, int64(1)})
+	require.False(t, ok)
+	assert.Panics(t, func() { k.Put(key{i: []int{}}, 4) })
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0, WithKeyNormalizer(strings.ToLower))
+	for i := range 1000 {
+		m.Put(fmt.Sprintf("Key%d", i), i)
+	}
+	for i := range 1000 {
+		m.Put(fmt.Sprintf("KEY%d", i), i+1)
+	}
+	require.Equal(t, 1000, m.Len())
+	for i := range 1000 {
+		v, ok := m.Get(fmt.Sprintf("kEy%d", i))
+		require.True(t, ok)
+		require.Equal(t, i+1, v)
+	}
+	for k := range m.All() {
+		require.Equal(t, strings.ToLower(k), k)
+	}
+	require.Equal(t, m.Hash("a"), m.Hash("A"))
+	require.True(t, m.KeySet().Has("KEY1"))
+	m.Delete("KEY1")
+	_, ok := m.Get("key1")
+	require.False(t, ok)
+	assert.Panics(t, func() { New[int, int](0, WithKeyNormalizer(strings.ToLower)) })
+}
+
+func TestInterfaceKeys(t *testing.T) {
+	t.Parallel()
+	type key struct {
+		s string
+		f float64
+	}
+	m := New[any, int](0)
+	keys := []any{1, int64(1), "1", 1.5, key{"a", 1}, [2]string{"a", "b"}, nil, true}
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	require.Equal(t, len(keys), m.Len())
+	v, ok := m.Get(strings.Clone("1"))
+	require.True(t, ok)
+	require.Equal(t, 2, v)
+	v, ok = m.Get(key{strings.Clone("a"), 1})
+	require.True(t, ok)
+	require.Equal(t, 4, v)
+	v, ok = m.Get([2]string{strings.Clone("a"), "b"})
+	require.True(t, ok)
+	require.Equal(t, 5, v)
+	v, ok = m.Get(nil)
+	require.True(t, ok)
+	require.Equal(t, 6, v)
+	_, ok = m.Get(uint(1))
+	require.False(t, ok)
+	assert.Panics(t, func() { m.Put([]int{1}, 0) })
+
+	s := New[key, int](0)
+	s.Put(key{"a", 0}, 1)
+	v, ok = s.Get(key{strings.Clone("a"), math.Copysign(0, -1)})
+	require.True(t, ok)
+	require.Equal(t, 1, v)
 }
 
 func TestControlSetByte(t *testing.T) {
@@ -596,3 +1191,282 @@ func genRandomString(length int) string {
 	}
 	return sb.String()
 }
+
+func TestHugePages(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithHugePages(hugePageSize))
+	for i := range 1_000_000 {
+		m.Put(i, i)
+	}
+	c := m.Clone()
+	for i := range 1_000_000 {
+		v, ok := c.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+}
+
+func TestDeferredGrowth(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithDeferredGrowth())
+	for i := range 50 {
+		m.Put(i, i)
+	}
+	grps := len(m.grps)
+	seen := make(map[int]int)
+	next := 1000
+	for k := range m.All() {
+		seen[k]++
+		for range 3 {
+			if m.Len() < len(m.grps)*grpssz-1 {
+				m.Put(next, next)
+				next++
+			}
+		}
+		require.Equal(t, grps, len(m.grps))
+	}
+	for k, n := range seen {
+		require.Equal(t, 1, n, k)
+	}
+	for i := range 50 {
+		require.Equal(t, 1, seen[i])
+	}
+	require.Greater(t, len(m.grps), grps)
+	require.LessOrEqual(t, m.Len(), m.Cap())
+	for i := 1000; i < next; i++ {
+		v, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	// Running out of empty slots forces growth during iteration.
+	n := 10 * len(m.grps) * grpssz
+	for range m.All() {
+		for i := range n {
+			m.Put(-i-1, i)
+		}
+		break
+	}
+	for i := range n {
+		v, ok := m.Get(-i - 1)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	require.LessOrEqual(t, m.Len(), m.Cap())
+}
+
+func TestDevirtualizedHash(t *testing.T) {
+	t.Parallel()
+	ints := New[int, int](0)
+	require.Equal(t, hashWord, ints.hkind)
+	strs := New[string, int](0)
+	require.Equal(t, hashString, strs.hkind)
+	require.Equal(t, hashIndirect, New[int32, int](0).hkind)
+	require.Equal(t, hashIndirect, New[int, int](0, WithIdentityHash()).hkind)
+	require.Equal(t, hashIndirect, New[string, int](0, WithHasher(FNV1aHasher)).hkind)
+
+	// The direct call must agree with hashfn, which the other code paths use,
+	// and keep hashing integers with the runtime's memhash.
+	memhash := hash.GetHashFuncMemhash[int]()
+	for i := range 1000 {
+		k := i * 7919
+		require.Equal(t, ints.hashfn(unsafe.Pointer(&k), ints.seed), ints.hashKey(k))
+		require.Equal(t, memhash(unsafe.Pointer(&k), ints.seed), ints.hashKey(k))
+		s := strconv.Itoa(k)
+		require.Equal(t, strs.hashfn(unsafe.Pointer(&s), strs.seed), strs.hashKey(s))
+		ints.Put(k, i)
+		strs.Put(s, i)
+	}
+	for i := range 1000 {
+		v, ok := ints.Get(i * 7919)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+		v, ok = strs.Get(strconv.Itoa(i * 7919))
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+}
+
+func TestDeleteZeroing(t *testing.T) {
+	t.Parallel()
+	plain := New[int, [4]int](0)
+	require.True(t, plain.plain)
+	plain.Put(1, [4]int{1, 2, 3, 4})
+	s := plain.lookup(1)
+	plain.Delete(1)
+	// Deleted slots of pointer-free maps keep their stale contents.
+	require.Equal(t, [4]int{1, 2, 3, 4}, s.value)
+	_, ok := plain.Get(1)
+	require.False(t, ok)
+
+	ptrs := New[int, *int](0)
+	require.False(t, ptrs.plain)
+	ptrs.Put(1, new(int))
+	p := ptrs.lookup(1)
+	ptrs.Delete(1)
+	require.Nil(t, p.value)
+	require.False(t, New[string, int](0).plain)
+}
+
+func TestDeleteAvoidsTombstones(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](64)
+	for i := 0; m.Len() < m.cap; i++ {
+		m.Put(i, i)
+	}
+	var full, sparse *group[int, int]
+	for i := range m.grps {
+		if m.grps[i].maskEmpty() == 0 {
+			full = &m.grps[i]
+		} else if m.grps[i].maskFull() != 0 {
+			sparse = &m.grps[i]
+		}
+	}
+	require.NotNil(t, full)
+	require.NotNil(t, sparse)
+
+	// No probe sequence passes through a group with empty slots, so its
+	// deleted slots can be marked empty.
+	m.Delete(sparse.slts[sparse.maskFull().first()].key)
+	require.Zero(t, m.tombstones)
+	m.Delete(full.slts[full.maskFull().first()].key)
+	require.Equal(t, 1, m.tombstones)
+}
+
+func TestGetOrPut(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	v, loaded := m.GetOrPut("a", 1)
+	require.False(t, loaded)
+	require.Equal(t, 1, v)
+	v, loaded = m.GetOrPut("a", 2)
+	require.True(t, loaded)
+	require.Equal(t, 1, v)
+	require.Equal(t, 1, m.Len())
+	for i := range 1000 {
+		m.GetOrPut(strconv.Itoa(i), i)
+	}
+	require.Equal(t, 1001, m.Len())
+	for i := range 1000 {
+		v, _ := m.Get(strconv.Itoa(i))
+		require.Equal(t, i, v)
+	}
+}
+
+func TestPutReusesTombstones(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](1024)
+	for i := 0; m.Len() < m.cap; i++ {
+		m.Put(i, i)
+	}
+	n := m.Len()
+	for i := 0; i < n; i += 2 {
+		m.Delete(i)
+	}
+	tombstones, capacity := m.tombstones, m.cap
+	require.NotZero(t, tombstones)
+	for i := n; i < n+n/4; i++ {
+		m.Put(i, i)
+	}
+	require.Equal(t, capacity, m.cap)
+	require.Less(t, m.tombstones, tombstones)
+	require.Equal(t, n/2+n/4, m.Len())
+	for i := range n + n/4 {
+		v, ok := m.Get(i)
+		require.Equal(t, i >= n || i%2 == 1, ok, i)
+		if ok {
+			require.Equal(t, i, v)
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](100)
+	capacity := m.Cap()
+	require.Equal(t, capacity, m.Available())
+	for i := range capacity {
+		m.Put(i, i)
+		m.Put(i, i+1)
+		require.Equal(t, capacity-i-1, m.Available())
+	}
+	require.Equal(t, capacity, m.Cap())
+	m.Put(-1, 0)
+	require.Greater(t, m.Cap(), capacity)
+	require.Equal(t, m.Cap()-m.Len(), m.Available())
+}
+
+func TestSizeGuarantee(t *testing.T) {
+	t.Parallel()
+	sizes := []int{1000, 4095, 4096, 100_000}
+	for n := range 300 {
+		sizes = append(sizes, n)
+	}
+	for _, n := range sizes {
+		var grows int
+		m := New[int, int](n, WithGrowCallback(func(int, int, time.Duration) { grows++ }))
+		require.Greater(t, m.Cap(), n)
+		require.GreaterOrEqual(t, m.Available(), n)
+		for i := range n {
+			m.Put(i, i)
+		}
+		require.Zero(t, grows, "size %d", n)
+		require.Equal(t, n, m.Len())
+	}
+}
+
+func TestNewE(t *testing.T) {
+	t.Parallel()
+	m, err := NewE[int, int](100, WithFilter())
+	require.NoError(t, err)
+	m.Put(1, 1)
+	require.Equal(t, 1, m.Len())
+
+	for _, test := range []struct {
+		name string
+		fn   func() error
+	}{
+		{"negative size", func() error { _, err := NewE[int, int](-1); return err }},
+		{"huge size", func() error { _, err := NewE[int, int](math.MaxInt); return err }},
+		{"split", func() error { _, err := NewE[int, int](0, WithHashSplit(HashSplit{H2Bits: 8})); return err }},
+		{"identity key", func() error { _, err := NewE[string, int](0, WithIdentityHash()); return err }},
+		{"normalizer", func() error { _, err := NewE[int, int](0, WithKeyNormalizer(strings.ToLower)); return err }},
+		{"conflict", func() error {
+			_, err := NewE[int, int](0, WithIdentityHash(), WithHasher(FNV1aHasher))
+			return err
+		}},
+	} {
+		require.Error(t, test.fn(), test.name)
+	}
+	require.Panics(t, func() { New[int, int](-10) })
+
+	limit := maxSize[int, int]()
+	require.Greater(t, limit, 0)
+	require.LessOrEqual(t, uint64(groupsnum(limit)), uint64(math.MaxUint32))
+	_, err = NewE[int, int](limit + 1)
+	require.Error(t, err)
+}
+
+func TestCapacityOverflow(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int{0, 1, 6, 7, 8, 13, 14, 1 << 20} {
+		require.Equal(t, (max(n, 10*boolInt(n == 0))+grpload+1)/grpload, groupsnum(n), n)
+	}
+	require.Equal(t, math.MaxInt/grpload+1, groupsnum(math.MaxInt))
+	require.Equal(t, math.MaxInt, newsize(math.MaxInt/2+1, 0))
+	require.Equal(t, math.MaxInt/2*2, newsize(math.MaxInt/2, 0))
+
+	limit := maxGroups[int, int]()
+	require.Equal(t, min(uint64(math.MaxUint32), uint64(math.MaxInt)/uint64(unsafe.Sizeof(group[int, int]{}))), uint64(limit))
+	m := New[int, int](0)
+	require.PanicsWithError(t, errTooLarge.Error(), func() { m.resize(limit + 1) })
+	m.Put(1, 1)
+	require.Equal(t, 1, m.Len())
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package swiss
+
+import "sync"
+
+// Memo caches the results of a function per key and is safe for concurrent
+// use. The function runs at most once per key at a time: concurrent calls
+// for a key that is being computed wait for the first one and share its
+// result, while calls for other keys proceed in parallel. It replaces the
+// pattern of storing a sync.Once per key in a sync.Map.
+type Memo[K comparable, V any] struct {
+	m  *SafeMap[K, *memoEntry[V]]
+	fn func(K) V
+}
+
+type memoEntry[V any] struct {
+	once  sync.Once
+	value V
+	done  bool
+}
+
+// NewMemo creates a Memo computing missing values with fn, with room for
+// size keys. It accepts the same options as New.
+func NewMemo[K comparable, V any](size int, fn func(key K) V, opts ...Option) *Memo[K, V] {
+	return &Memo[K, V]{m: NewSafeMap[K, *memoEntry[V]](size, opts...), fn: fn}
+}
+
+// Get returns the value of the key, computing and caching it first if the
+// key is new. If the function panics, the panic propagates to the caller
+// that ran it, nothing is cached, and the next Get of the key calls the
+// function again.
+func (c *Memo[K, V]) Get(key K) V {
+	for {
+		e, ok := c.m.Get(key)
+		if !ok {
+			c.m.Do(key, func(v **memoEntry[V], exists bool) (*memoEntry[V], bool, bool) {
+				if !exists {
+					*v = &memoEntry[V]{}
+				}
+				e = *v
+				return e, !exists, false
+			})
+		}
+		e.once.Do(func() {
+			defer func() {
+				if !e.done {
+					c.drop(key, e)
+				}
+			}()
+			e.value = c.fn(key)
+			e.done = true
+		})
+		if e.done {
+			return e.value
+		}
+		// The function panicked in another goroutine, compute again.
+	}
+}
+
+// drop removes the entry of the key unless it was replaced already.
+func (c *Memo[K, V]) drop(key K, e *memoEntry[V]) {
+	c.m.Do(key, func(v **memoEntry[V], exists bool) (*memoEntry[V], bool, bool) {
+		return nil, false, exists && *v == e
+	})
+}
+
+// Forget removes the value of the key, so that the next Get computes it
+// again. Calls of Get already waiting for the key still receive the old
+// computation.
+func (c *Memo[K, V]) Forget(key K) {
+	c.m.Delete(key)
+}
+
+// Len returns the number of cached keys, including those being computed.
+func (c *Memo[K, V]) Len() int {
+	return c.m.Len()
+}
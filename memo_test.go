@@ -0,0 +1,55 @@
+package swiss
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemo(t *testing.T) {
+	t.Parallel()
+	var calls [100]atomic.Int32
+	m := NewMemo[int, int](0, func(k int) int {
+		calls[k].Add(1)
+		return k * k
+	})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range 100 {
+				assert.Equal(t, k*k, m.Get(k))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 100, m.Len())
+	for k := range calls {
+		require.Equal(t, int32(1), calls[k].Load())
+	}
+
+	m.Forget(7)
+	require.Equal(t, 99, m.Len())
+	require.Equal(t, 49, m.Get(7))
+	require.Equal(t, int32(2), calls[7].Load())
+}
+
+func TestMemoPanic(t *testing.T) {
+	t.Parallel()
+	var fail atomic.Bool
+	fail.Store(true)
+	m := NewMemo[string, int](0, func(k string) int {
+		if fail.Load() {
+			panic("boom")
+		}
+		return len(k)
+	})
+	require.Panics(t, func() { m.Get("abc") })
+	require.Zero(t, m.Len())
+	fail.Store(false)
+	require.Equal(t, 3, m.Get("abc"))
+}
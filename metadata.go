@@ -0,0 +1,107 @@
+package swiss
+
+import (
+	"iter"
+	"time"
+	"unsafe"
+)
+
+// Meta is the metadata recorded for an entry of a map created with
+// WithMetadata.
+type Meta struct {
+	// Written is the time of the last Put of the entry.
+	Written time.Time
+	// Accessed is the time of the last Get, GetPtr or Modify of the entry,
+	// or Written if it has not been read since.
+	Accessed time.Time
+	// Hits counts the reads of the entry since it was inserted.
+	Hits uint64
+}
+
+// slotMeta is the compact form of Meta kept in a slice parallel to the
+// slots, at index group*grpssz+slot.
+type slotMeta struct {
+	written  int64 // unix nanoseconds
+	accessed int64
+	hits     uint64
+}
+
+// WithMetadata makes the map record, for every entry, the time it was last
+// written and read and the number of times it was read, as reported by
+// GetWithMeta and AllMeta. It is meant for eviction policies such as TTL, LRU
+// or LFU built on top of the map. It costs 24 bytes per slot and a clock
+// read on every write and hit. Since reads update the metadata, SafeMap
+// takes its exclusive lock for them, and NewRCU rejects such maps.
+func WithMetadata() Option {
+	return func(o *options) {
+		o.metadata = true
+	}
+}
+
+func (s slotMeta) export() Meta {
+	return Meta{
+		Written:  time.Unix(0, s.written),
+		Accessed: time.Unix(0, s.accessed),
+		Hits:     s.hits,
+	}
+}
+
+// written records a Put of the entry at index i.
+func (m *Map[K, V]) written(i uint32, inserted bool) {
+	now := time.Now().UnixNano()
+	if inserted {
+		m.meta[i] = slotMeta{}
+	}
+	m.meta[i].written, m.meta[i].accessed = now, now
+}
+
+// touch records a read of the entry at index i.
+func (m *Map[K, V]) touch(i uint32) {
+	m.meta[i].accessed = time.Now().UnixNano()
+	m.meta[i].hits++
+}
+
+// slotIndex returns the index of the slot in the metadata slice.
+func (m *Map[K, V]) slotIndex(s *slot[K, V]) uint32 {
+	g := m.groupOf(s)
+	i := (uintptr(unsafe.Pointer(s)) - uintptr(unsafe.Pointer(&g.slts[0]))) / unsafe.Sizeof(*s)
+	return uint32(m.groupIndex(g)*grpssz) + uint32(i)
+}
+
+// groupIndex returns the index of the group in the groups slice.
+func (m *Map[K, V]) groupIndex(g *group[K, V]) int {
+	off := uintptr(unsafe.Pointer(g)) - uintptr(unsafe.Pointer(unsafe.SliceData(m.grps)))
+	return int(off / unsafe.Sizeof(*g))
+}
+
+// GetWithMeta retrieves the value associated with the key together with its
+// metadata. Unlike Get it does not count as a read of the entry. The
+// metadata is zero if the map was not created with WithMetadata.
+func (m *Map[K, V]) GetWithMeta(key K) (V, Meta, bool) {
+	if m.normalize != nil {
+		key = m.normalize(key)
+	}
+	s := m.lookup(key)
+	if s == nil {
+		var res V
+		return res, Meta{}, false
+	}
+	if m.meta == nil {
+		return s.value, Meta{}, true
+	}
+	return s.value, m.meta[m.slotIndex(s)].export(), true
+}
+
+// AllMeta returns an iterator over the keys of the map and their metadata,
+// in the same order as All. It yields nothing if the map was not created with
+// WithMetadata.
+func (m *Map[K, V]) AllMeta() iter.Seq2[K, Meta] {
+	return func(yield func(K, Meta) bool) {
+		if m.meta == nil {
+			return
+		}
+		m.scan(func(s *slot[K, V]) bool {
+			return yield(s.key, m.meta[m.slotIndex(s)].export())
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package swiss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	for _, opts := range [][]Option{{WithMetadata()}, {WithMetadata(), WithLazyClear()}} {
+		m := New[int, int](0, opts...)
+		start := time.Now()
+		for i := range 1000 {
+			m.Put(i, i)
+		}
+		for i := range 1000 {
+			for range i % 3 {
+				_, ok := m.Get(i)
+				require.True(t, ok)
+			}
+		}
+		m.Modify(999, func(v *int) { *v++ })
+		for i := range 1000 {
+			v, meta, ok := m.GetWithMeta(i)
+			require.True(t, ok)
+			require.Equal(t, i+i/999, v)
+			require.Equal(t, uint64(i%3+i/999), meta.Hits)
+			require.False(t, meta.Written.Before(start))
+			require.False(t, meta.Accessed.Before(meta.Written))
+		}
+		_, meta, _ := m.GetWithMeta(1)
+		require.Equal(t, uint64(1), meta.Hits)
+
+		written := meta.Written
+		m.Put(1, 10)
+		_, meta, _ = m.GetWithMeta(1)
+		require.Equal(t, uint64(1), meta.Hits)
+		require.False(t, meta.Written.Before(written))
+
+		m.Delete(2)
+		m.Put(2, 2)
+		_, meta, _ = m.GetWithMeta(2)
+		require.Zero(t, meta.Hits)
+
+		var n int
+		for k, meta := range m.AllMeta() {
+			if k != 2 {
+				require.Equal(t, uint64(k%3+k/999), meta.Hits)
+			}
+			n++
+		}
+		require.Equal(t, m.Len(), n)
+
+		c := m.Clone()
+		m.Clear()
+		for i := range 10 {
+			m.Put(i, i)
+			_, meta, _ := m.GetWithMeta(i)
+			require.Zero(t, meta.Hits)
+		}
+		_, meta, _ = c.GetWithMeta(998)
+		require.Equal(t, uint64(2), meta.Hits)
+	}
+
+	m := New[int, int](0)
+	m.Put(1, 1)
+	_, meta, ok := m.GetWithMeta(1)
+	require.True(t, ok)
+	require.Zero(t, meta)
+	for range m.AllMeta() {
+		t.Fatal("unexpected metadata")
+	}
+}
@@ -0,0 +1,56 @@
+package swiss
+
+import "math/rand"
+
+// ProbeMonitor detects abnormally long probe sequences, the symptom of hash
+// flooding or of a poor custom hash function. With a good hash the longest
+// probe sequences grow slowly with the table, to about a hundred groups for
+// millions of entries near the maximum load, so the threshold should leave
+// ample margin.
+type ProbeMonitor struct {
+	// Threshold is the number of groups an insertion may probe before it is
+	// reported.
+	Threshold int
+	// OnExceed, if not nil, is called with the probe length of every
+	// insertion exceeding Threshold.
+	OnExceed func(probeLen int)
+	// Reseed makes the map pick a new seed and rehash in place when the
+	// threshold is exceeded. To bound the cost under a sustained attack, the
+	// map is reseeded at most once between two growths, and never while
+	// iterating with WithDeferredGrowth. Reseeding only helps
+	// against collisions of seeded hashes; it cannot break up equal hashes
+	// of identity-hashed keys.
+	Reseed bool
+}
+
+// MaxProbeLength returns the longest probe sequence, in groups, of the keys
+// inserted since the last rehash. It is only tracked with WithProbeMonitor
+// and is zero otherwise.
+func (m *Map[K, V]) MaxProbeLength() int {
+	return m.maxProbe
+}
+
+func (m *Map[K, V]) observeProbe(n int) {
+	m.maxProbe = max(m.maxProbe, n)
+	if n <= m.monitor.Threshold {
+		return
+	}
+	if m.monitor.OnExceed != nil {
+		m.monitor.OnExceed(n)
+	}
+	if m.monitor.Reseed && !m.reseeded && m.iterators == 0 {
+		m.reseed()
+	}
+}
+
+// reseed rehashes the map in place with a new seed. Deterministic maps
+// derive the new seed from the old one.
+func (m *Map[K, V]) reseed() {
+	if m.deterministic {
+		m.seed = uintptr(mix64(uint64(m.seed)))
+	} else {
+		m.seed = uintptr(rand.Uint64())
+	}
+	m.reseeded = true
+	m.resize(len(m.grps))
+}
@@ -0,0 +1,54 @@
+package swiss
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/crn4/swiss/hash"
+)
+
+func TestProbeMonitor(t *testing.T) {
+	t.Parallel()
+	const bad = 42
+	good := hash.GetHashFunc[int]()
+	// A seeded hash function broken for one seed, as if an attacker had
+	// found colliding keys for it.
+	hashfn := func(p unsafe.Pointer, seed uintptr) uintptr {
+		if seed == bad {
+			return 0
+		}
+		return good(p, seed)
+	}
+	var exceeded []int
+	o := defaultOptions()
+	o.monitor = &ProbeMonitor{
+		Threshold: 64,
+		OnExceed:  func(n int) { exceeded = append(exceeded, n) },
+		Reseed:    true,
+	}
+	m := newMap[int, int](1000, hashfn, o)
+	m.seed = bad
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	require.NotEmpty(t, exceeded)
+	require.Greater(t, exceeded[0], 64)
+	require.NotEqual(t, uintptr(bad), m.seed)
+	require.Less(t, m.MaxProbeLength(), 64)
+	for i := range 1000 {
+		v, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	plain := New[int, int](0)
+	plain.Put(1, 1)
+	require.Zero(t, plain.MaxProbeLength())
+	monitored := New[int, int](0, WithProbeMonitor(ProbeMonitor{Threshold: 100}))
+	for i := range 1000 {
+		monitored.Put(i, i)
+	}
+	require.Positive(t, monitored.MaxProbeLength())
+}
@@ -0,0 +1,96 @@
+package swiss
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"runtime"
+)
+
+// OffHeap is a map whose groups live in memory allocated directly from the
+// operating system, outside of the Go heap. The garbage collector neither
+// scans nor accounts for that memory, so very large tables do not slow down
+// or trigger GC cycles. It is limited to key and value types without
+// pointers, since the GC would not see them.
+//
+// The memory must be released with Free; a finalizer releases it if the map
+// becomes unreachable first, but relying on it delays reclamation. The map
+// must not be used after Free. On platforms without mmap the groups are
+// allocated on the heap.
+type OffHeap[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+// NewOffHeap creates an off-heap map with the specified initial size. It
+// accepts the same options as New and returns ErrUnsupportedType if the key
+// or value type contains pointers.
+func NewOffHeap[K comparable, V any](size int, opts ...Option) (*OffHeap[K, V], error) {
+	for _, t := range []reflect.Type{reflect.TypeFor[K](), reflect.TypeFor[V]()} {
+		if !pointerFree(t) {
+			return nil, fmt.Errorf("%w: off-heap map of %v contains pointers", ErrUnsupportedType, t)
+		}
+	}
+	m := New[K, V](size, opts...)
+	m.alloc, m.release = allocOffHeap[K, V], releaseOffHeap[K, V]
+	m.grps = m.alloc(len(m.grps))
+	m.groups(func(g *group[K, V]) bool {
+		g.cntrl = emptyContol
+		return true
+	})
+	if m.sums != nil {
+		m.resetSums()
+	}
+	o := &OffHeap[K, V]{m: m}
+	runtime.SetFinalizer(o, (*OffHeap[K, V]).Free)
+	return o, nil
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (o *OffHeap[K, V]) Put(key K, value V) {
+	o.m.Put(key, value)
+	// The finalizer of o must not release the groups while they are used.
+	runtime.KeepAlive(o)
+}
+
+// Get retrieves the value associated with the key.
+func (o *OffHeap[K, V]) Get(key K) (V, bool) {
+	v, ok := o.m.Get(key)
+	runtime.KeepAlive(o)
+	return v, ok
+}
+
+// Delete removes the key from the map.
+func (o *OffHeap[K, V]) Delete(key K) {
+	o.m.Delete(key)
+	runtime.KeepAlive(o)
+}
+
+// Len returns the number of entries in the map.
+func (o *OffHeap[K, V]) Len() int {
+	n := o.m.Len()
+	runtime.KeepAlive(o)
+	return n
+}
+
+// All returns an iterator over all entries of the map.
+func (o *OffHeap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range o.m.All() {
+			if !yield(k, v) {
+				break
+			}
+		}
+		runtime.KeepAlive(o)
+	}
+}
+
+// Free releases the memory of the map. It is safe to call Free more than
+// once.
+func (o *OffHeap[K, V]) Free() {
+	if o.m == nil {
+		return
+	}
+	o.m.release(o.m.grps)
+	o.m = nil
+	runtime.SetFinalizer(o, nil)
+}
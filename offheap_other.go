@@ -0,0 +1,9 @@
+//go:build !unix
+
+package swiss
+
+func allocOffHeap[K comparable, V any](ngroups int) []group[K, V] {
+	return make([]group[K, V], ngroups)
+}
+
+func releaseOffHeap[K comparable, V any]([]group[K, V]) {}
@@ -0,0 +1,51 @@
+package swiss
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffHeap(t *testing.T) {
+	t.Parallel()
+	m, err := NewOffHeap[int, [4]int](0)
+	require.NoError(t, err)
+	for i := range 100_000 {
+		m.Put(i, [4]int{i})
+	}
+	for i := 0; i < 100_000; i += 2 {
+		m.Delete(i)
+	}
+	require.Equal(t, 50_000, m.Len())
+	for i := range 100_000 {
+		v, ok := m.Get(i)
+		require.Equal(t, i%2 == 1, ok)
+		if ok {
+			require.Equal(t, i, v[0])
+		}
+	}
+	var cnt int
+	for range m.All() {
+		cnt++
+	}
+	require.Equal(t, 50_000, cnt)
+	m.Free()
+	m.Free()
+	require.Panics(t, func() { m.Get(1) })
+
+	_, err = NewOffHeap[string, int](0)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = NewOffHeap[int, *int](0)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestOffHeapFinalizer(t *testing.T) {
+	func() {
+		m, err := NewOffHeap[int, int](1000)
+		require.NoError(t, err)
+		m.Put(1, 1)
+	}()
+	runtime.GC()
+	runtime.GC()
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package swiss
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func allocOffHeap[K comparable, V any](ngroups int) []group[K, V] {
+	size := ngroups * int(unsafe.Sizeof(group[K, V]{}))
+	b, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic("swiss: mmap: " + err.Error())
+	}
+	return unsafe.Slice((*group[K, V])(unsafe.Pointer(unsafe.SliceData(b))), ngroups)
+}
+
+func releaseOffHeap[K comparable, V any](grps []group[K, V]) {
+	size := len(grps) * int(unsafe.Sizeof(group[K, V]{}))
+	if err := syscall.Munmap(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(grps))), size)); err != nil {
+		panic("swiss: munmap: " + err.Error())
+	}
+}
@@ -0,0 +1,225 @@
+package swiss
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Option configures a Map at construction time.
+type Option func(*options)
+
+type options struct {
+	split    HashSplit
+	filter   bool
+	identity bool
+	hasher   Hasher
+
+	deterministic bool
+	seed          uint64
+
+	normalize   any // func(K) K
+	gate        GrowthGate
+	hugepages   uint64
+	onGrow      func(oldCap, newCap int, dur time.Duration)
+	lazyClear   bool
+	deferGrowth bool
+	monitor     *ProbeMonitor
+	metadata    bool
+	checksums   bool
+	verifyReads bool
+	indexes     []indexSpec
+}
+
+var (
+	errIdentityKey    = errors.New("swiss: identity hash requires an integer key type")
+	errNormalizerType = errors.New("swiss: key normalizer does not match the key type")
+	errHasherKey      = errors.New("swiss: hasher does not support the key type")
+	errTooLarge       = errors.New("swiss: map exceeds the maximum number of groups")
+)
+
+func defaultOptions() options {
+	return options{
+		split: AbseilSplit,
+	}
+}
+
+func (o *options) validate() error {
+	if o.split.H2Bits < 1 || o.split.H2Bits > 7 {
+		return errors.New("swiss: H2Bits must be in range [1, 7]")
+	}
+	if o.hasher > BinaryHasher {
+		return errors.New("swiss: unknown hasher")
+	}
+	if o.identity && o.hasher != DefaultHasher {
+		return errors.New("swiss: WithIdentityHash conflicts with WithHasher")
+	}
+	for i, spec := range o.indexes {
+		for _, other := range o.indexes[:i] {
+			if spec.name == other.name {
+				return fmt.Errorf("swiss: duplicate index name %q", spec.name)
+			}
+		}
+	}
+	return nil
+}
+
+// HashSplit describes how a hash value is divided between group selection
+// (H1) and the control byte (H2). H2Bits bits are stored in the control byte,
+// at most 7 since the top bit marks empty and deleted slots. By default H2
+// is taken from the lowest bits of the hash and H1 from the rest; H2High
+// takes H2 from the highest bits instead and leaves the whole hash for H1,
+// which helps hash functions with weak low bits.
+type HashSplit struct {
+	H2Bits uint8
+	H2High bool
+}
+
+// AbseilSplit is the default split, identical to the one used by Abseil's
+// SwissTable: the low 7 bits go to H2 and the remaining bits to H1.
+var AbseilSplit = HashSplit{H2Bits: 7}
+
+// WithHashSplit sets the H1/H2 split used by the map.
+func WithHashSplit(split HashSplit) Option {
+	return func(o *options) {
+		o.split = split
+	}
+}
+
+// WithFilter enables a compact Bloom filter consulted by Get before probing
+// the groups. It costs 8 bytes per group and speeds up lookups dominated by
+// absent keys, at the price of slightly slower Puts. Deleted keys keep their
+// filter bits until the filter is rebuilt, which happens on rehash, on Clear
+// and whenever the deletes since the last rebuild exceed the capacity.
+func WithFilter() Option {
+	return func(o *options) {
+		o.filter = true
+	}
+}
+
+// WithIdentityHash makes the map use integer keys directly as their hash,
+// folded with the map's seed, instead of hashing them. It saves the cost of
+// hashing for keys that are already uniformly distributed, such as random
+// 64-bit identifiers, and degrades badly for anything else. New panics if
+// the key type is not an integer type.
+func WithIdentityHash() Option {
+	return func(o *options) {
+		o.identity = true
+	}
+}
+
+// Hasher selects one of the simple hash functions of the hash package.
+type Hasher uint8
+
+const (
+	// DefaultHasher picks the hash function by key type, see
+	// hash.GetHashFunc.
+	DefaultHasher Hasher = iota
+	// FNV1aHasher is hash.GetHashFuncFNV1a.
+	FNV1aHasher
+	// DJB2Hasher is hash.GetHashFuncDJB2.
+	DJB2Hasher
+	// BinaryHasher is hash.GetHashFuncBinary, for keys implementing
+	// AppendBinary or encoding.BinaryMarshaler.
+	BinaryHasher
+)
+
+// WithHasher makes the map hash keys with the selected function instead of
+// the default one. FNV1aHasher and DJB2Hasher suit tiny keys or cases where
+// the hash must be easy to audit, and only support strings and plain-memory
+// keys. BinaryHasher hashes the binary encoding of keys. New panics if the
+// key type is not supported.
+func WithHasher(h Hasher) Option {
+	return func(o *options) {
+		o.hasher = h
+	}
+}
+
+// WithDeterministic makes the layout of the map, and therefore the order of
+// iteration, depend only on the sequence of operations performed on it. The
+// map uses the given seed and a hash function that is the same in every
+// process, so golden-file tests and differential fuzzing get reproducible
+// output. The hash function is slower for keys that are not plain memory,
+// such as strings, and keys containing pointers are still hashed by address.
+func WithDeterministic(seed uint64) Option {
+	return func(o *options) {
+		o.deterministic = true
+		o.seed = seed
+	}
+}
+
+// WithKeyNormalizer makes the map pass every key through fn before hashing
+// or storing it, in Put, Get, Delete and the other methods taking a key, so
+// keys normalizing to the same value are treated as equal. For example,
+// strings.ToLower makes a map of strings case-insensitive. Iteration yields
+// the normalized keys. fn must be idempotent. New panics if fn does not
+// match the key type of the map.
+func WithKeyNormalizer[K comparable](fn func(K) K) Option {
+	return func(o *options) {
+		o.normalize = fn
+	}
+}
+
+// WithGrowthGate makes the map consult gate before every rehash that grows
+// it. If the gate denies growth, the map keeps working at its current size
+// but refuses new keys: TryPut returns ErrFull and Put panics with it.
+func WithGrowthGate(gate GrowthGate) Option {
+	return func(o *options) {
+		o.gate = gate
+	}
+}
+
+// WithHugePages asks the kernel to back the groups with transparent huge
+// pages whenever the table takes at least minBytes bytes, which cuts TLB
+// misses on random access into very large tables. It only has an effect on
+// Linux with transparent huge pages in madvise or always mode.
+func WithHugePages(minBytes uint64) Option {
+	return func(o *options) {
+		o.hugepages = max(minBytes, 1)
+	}
+}
+
+// WithGrowCallback sets a function called after every rehash of the map,
+// whether it grew the table or only drained tombstones, with the capacity
+// before and after and the time the rehash took.
+func WithGrowCallback(fn func(oldCap, newCap int, dur time.Duration)) Option {
+	return func(o *options) {
+		o.onGrow = fn
+	}
+}
+
+// WithLazyClear makes Clear run in constant time, apart from resetting the
+// filter if one is enabled: instead of zeroing every group it increments a
+// generation counter, and groups of older generations are treated as empty
+// and reset when they are first written to. It costs 4 bytes per group and
+// a check per probed group. Keys and values of cleared entries stay
+// reachable until their group is reused or the map is rehashed. KeySet,
+// Partitions, Diff and x/group reset stale groups, so they must not run
+// concurrently with other reads, and NewRCU rejects such maps.
+func WithLazyClear() Option {
+	return func(o *options) {
+		o.lazyClear = true
+	}
+}
+
+// WithDeferredGrowth makes it safe to Put new keys while iterating over the
+// map with All or the other iterators: growth is postponed until the last
+// active iterator finishes, so the table being iterated is never replaced.
+// Keys added during iteration may or may not be visited. Only if the table
+// runs completely out of empty slots does it grow anyway, in which case the
+// iteration continues over the old table and misses the newer entries. The
+// iterators update the map, so concurrent iteration is not allowed: SafeMap
+// takes its exclusive lock for them, and NewRCU rejects such maps.
+func WithDeferredGrowth() Option {
+	return func(o *options) {
+		o.deferGrowth = true
+	}
+}
+
+// WithProbeMonitor makes the map watch the length of the probe sequences of
+// new keys, see ProbeMonitor.
+func WithProbeMonitor(pm ProbeMonitor) Option {
+	return func(o *options) {
+		o.monitor = &pm
+	}
+}
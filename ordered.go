@@ -0,0 +1,45 @@
+package swiss
+
+import "cmp"
+
+// MinKey returns the smallest key of the map, as ordered by cmp.Compare, and
+// false if the map is empty.
+func MinKey[K cmp.Ordered, V any](m *Map[K, V]) (K, bool) {
+	k, _, ok := m.MinBy(cmp.Compare[K])
+	return k, ok
+}
+
+// MaxKey returns the largest key of the map, as ordered by cmp.Compare, and
+// false if the map is empty.
+func MaxKey[K cmp.Ordered, V any](m *Map[K, V]) (K, bool) {
+	k, _, ok := m.MaxBy(cmp.Compare[K])
+	return k, ok
+}
+
+// MinBy returns the entry with the smallest key according to compare, which
+// returns a negative number if a < b, zero if a == b and a positive number
+// otherwise. Among equal keys it returns any of them. The last return value
+// is false if the map is empty.
+func (m *Map[K, V]) MinBy(compare func(a, b K) int) (K, V, bool) {
+	var best *slot[K, V]
+	m.scan(func(s *slot[K, V]) bool {
+		if best == nil || compare(s.key, best.key) < 0 {
+			best = s
+		}
+		return true
+	})
+	if best == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	return best.key, best.value, true
+}
+
+// MaxBy returns the entry with the largest key according to compare, see
+// MinBy.
+func (m *Map[K, V]) MaxBy(compare func(a, b K) int) (K, V, bool) {
+	return m.MinBy(func(a, b K) int {
+		return compare(b, a)
+	})
+}
@@ -0,0 +1,202 @@
+// Package ordered implements a sorted map for the occasional range scan that
+// a hash table cannot answer. Entries are kept in a skip list ordered by
+// key, and indexed by a swiss map, so point lookups and updates of present
+// keys cost a hash lookup while inserts, deletions and seeks take expected
+// logarithmic time.
+package ordered
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+	"math/rand"
+
+	"github.com/crn4/swiss"
+)
+
+// maxLevel bounds the height of the skip list. With a branching factor of
+// four it suits up to 4^maxLevel entries.
+const maxLevel = 24
+
+// Map is a map with keys of an ordered type that iterates in key order. It
+// is not safe for concurrent use.
+type Map[K cmp.Ordered, V any] struct {
+	index *swiss.Map[K, *node[K, V]]
+	head  [maxLevel]*node[K, V]
+	tail  *node[K, V]
+	level int
+}
+
+type node[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	prev  *node[K, V]
+	next  []*node[K, V]
+}
+
+// New creates an empty map with room for size entries in its index. It
+// accepts the same options as swiss.New.
+func New[K cmp.Ordered, V any](size int, opts ...swiss.Option) *Map[K, V] {
+	return &Map[K, V]{index: swiss.New[K, *node[K, V]](size, opts...), level: 1}
+}
+
+// Get retrieves the value associated with the key.
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	if n, ok := m.index.Get(key); ok {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (m *Map[K, V]) Put(key K, value V) {
+	if n, ok := m.index.Get(key); ok {
+		n.value = value
+		return
+	}
+	var update [maxLevel]*node[K, V]
+	m.search(key, &update)
+	level := randomLevel()
+	if level > m.level {
+		m.level = level
+	}
+	n := &node[K, V]{key: key, value: value, prev: update[0], next: make([]*node[K, V], level)}
+	for i := range level {
+		next := &m.head[i]
+		if update[i] != nil {
+			next = &update[i].next[i]
+		}
+		n.next[i], *next = *next, n
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		m.tail = n
+	}
+	m.index.Put(key, n)
+}
+
+// Delete removes the key from the map.
+func (m *Map[K, V]) Delete(key K) {
+	n, ok := m.index.Get(key)
+	if !ok {
+		return
+	}
+	m.index.Delete(key)
+	var update [maxLevel]*node[K, V]
+	m.search(key, &update)
+	for i := range n.next {
+		next := &m.head[i]
+		if update[i] != nil {
+			next = &update[i].next[i]
+		}
+		*next = n.next[i]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	for m.level > 1 && m.head[m.level-1] == nil {
+		m.level--
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *Map[K, V]) Len() int {
+	return m.index.Len()
+}
+
+// Min returns the entry with the smallest key, and false if the map is
+// empty.
+func (m *Map[K, V]) Min() (K, V, bool) {
+	return entry(m.head[0])
+}
+
+// Max returns the entry with the largest key, and false if the map is
+// empty.
+func (m *Map[K, V]) Max() (K, V, bool) {
+	return entry(m.tail)
+}
+
+// All returns an iterator over all entries of the map in ascending key
+// order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(m.head[0], func(K) bool { return true })(yield)
+	}
+}
+
+// AscendRange returns an iterator over the entries with keys in the range
+// [from, to), in ascending order.
+func (m *Map[K, V]) AscendRange(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var update [maxLevel]*node[K, V]
+		m.search(from, &update)
+		first := m.head[0]
+		if update[0] != nil {
+			first = update[0].next[0]
+		}
+		ascend(first, func(k K) bool { return k < to })(yield)
+	}
+}
+
+// DescendRange returns an iterator over the entries with keys in the range
+// (to, from], in descending order.
+func (m *Map[K, V]) DescendRange(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var update [maxLevel]*node[K, V]
+		m.search(from, &update)
+		last := update[0]
+		if n, ok := m.index.Get(from); ok {
+			last = n
+		}
+		for n := last; n != nil && n.key > to; n = n.prev {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// search stores in update the last node of every level with a key less than
+// key, or nil where the level has no such node.
+func (m *Map[K, V]) search(key K, update *[maxLevel]*node[K, V]) {
+	var x *node[K, V]
+	for i := m.level - 1; i >= 0; i-- {
+		next := m.head[i]
+		if x != nil {
+			next = x.next[i]
+		}
+		for next != nil && next.key < key {
+			x, next = next, next.next[i]
+		}
+		update[i] = x
+	}
+}
+
+func ascend[K cmp.Ordered, V any](first *node[K, V], in func(K) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := first; n != nil && in(n.key); n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+func entry[K cmp.Ordered, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		var key K
+		var value V
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
+
+// randomLevel returns the height of a new node: 1 with probability 3/4, and
+// one more for every further factor of 1/4.
+func randomLevel() int {
+	return min(bits.TrailingZeros64(rand.Uint64()|1<<63)/2+1, maxLevel)
+}
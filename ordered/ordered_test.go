@@ -0,0 +1,126 @@
+package ordered
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collect[K comparable, V any](seq func(func(K, V) bool)) []K {
+	var keys []K
+	for k := range seq {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestMapEmpty(t *testing.T) {
+	t.Parallel()
+	m := New[int, string](0)
+	_, ok := m.Get(1)
+	require.False(t, ok)
+	_, _, ok = m.Min()
+	require.False(t, ok)
+	_, _, ok = m.Max()
+	require.False(t, ok)
+	require.Empty(t, collect(m.All()))
+	require.Empty(t, collect(m.AscendRange(0, 10)))
+	require.Empty(t, collect(m.DescendRange(10, 0)))
+	m.Delete(1)
+	require.Zero(t, m.Len())
+}
+
+func TestMapRandom(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	expected := make(map[int]int)
+	for range 20000 {
+		k := rand.Intn(5000)
+		if rand.Intn(3) == 0 {
+			m.Delete(k)
+			delete(expected, k)
+		} else {
+			m.Put(k, -k)
+			expected[k] = -k
+		}
+	}
+	require.Equal(t, len(expected), m.Len())
+	var keys []int
+	for k, v := range expected {
+		got, ok := m.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	require.Equal(t, keys, collect(m.All()))
+
+	minKey, _, _ := m.Min()
+	maxKey, _, _ := m.Max()
+	require.Equal(t, keys[0], minKey)
+	require.Equal(t, keys[len(keys)-1], maxKey)
+
+	for range 100 {
+		from, to := rand.Intn(5200)-100, rand.Intn(5200)-100
+		var asc, desc []int
+		for _, k := range keys {
+			if k >= from && k < to {
+				asc = append(asc, k)
+			}
+			if k <= from && k > to {
+				desc = append(desc, k)
+			}
+		}
+		slices.Reverse(desc)
+		require.Equal(t, asc, collect(m.AscendRange(from, to)))
+		require.Equal(t, desc, collect(m.DescendRange(from, to)))
+	}
+
+	// Descending from the largest key visits all entries backwards.
+	all := collect(m.DescendRange(maxKey, minKey-1))
+	slices.Reverse(all)
+	require.Equal(t, keys, all)
+}
+
+func TestMapBreak(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	for i, k := range []string{"d", "a", "c", "b", "e"} {
+		m.Put(k, i)
+	}
+	m.Put("c", 10)
+	v, _ := m.Get("c")
+	require.Equal(t, 10, v)
+	var keys []string
+	for k := range m.AscendRange("b", "z") {
+		keys = append(keys, k)
+		if k == "c" {
+			break
+		}
+	}
+	require.Equal(t, []string{"b", "c"}, keys)
+	keys = keys[:0]
+	for k := range m.DescendRange("d", "") {
+		keys = append(keys, k)
+		if k == "c" {
+			break
+		}
+	}
+	require.Equal(t, []string{"d", "c"}, keys)
+}
+
+func TestMapIterateTwice(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	all := m.All()
+	for i := range 3 {
+		m.Put(i, i)
+	}
+	// An iterator sees the entries of the map when it runs, every time.
+	require.Equal(t, []int{0, 1, 2}, collect(all))
+	require.Equal(t, []int{0, 1, 2}, collect(all))
+	m.Put(3, 3)
+	require.Equal(t, []int{0, 1, 2, 3}, collect(all))
+}
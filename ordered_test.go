@@ -0,0 +1,50 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinMaxKey(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	_, ok := MinKey(m)
+	require.False(t, ok)
+	_, _, ok = m.MaxBy(func(a, b int) int { return a - b })
+	require.False(t, ok)
+	lo, hi := 0, 0
+	for i := range 10_000 {
+		k := randn.Intn(1_000_000) - 500_000
+		if i == 0 || k < lo {
+			lo = k
+		}
+		if i == 0 || k > hi {
+			hi = k
+		}
+		m.Put(k, -k)
+	}
+	k, ok := MinKey(m)
+	require.True(t, ok)
+	require.Equal(t, lo, k)
+	k, ok = MaxKey(m)
+	require.True(t, ok)
+	require.Equal(t, hi, k)
+
+	s := New[string, int](0)
+	s.Put("b", 1)
+	s.Put("A", 2)
+	s.Put("c", 3)
+	key, v, ok := s.MinBy(func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	require.True(t, ok)
+	require.Equal(t, "A", key)
+	require.Equal(t, 2, v)
+	key, v, ok = s.MaxBy(strings.Compare)
+	require.True(t, ok)
+	require.Equal(t, "c", key)
+	require.Equal(t, 3, v)
+}
@@ -0,0 +1,180 @@
+package swiss
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
+)
+
+// Perfect is an immutable map built around a minimal perfect hash function:
+// every key maps to its own slot, so lookups never probe and the table is
+// exactly as large as the number of entries. It is built once by
+// BuildPerfect and is safe for concurrent reads.
+//
+// The construction follows the hash-and-displace scheme (CHD): keys are
+// split into small buckets by their hash, and for every bucket, largest
+// first, a displacement is searched that sends all of its keys to free
+// slots. Only the displacements, 4 bytes per bucket of about 4 keys, are
+// stored on top of the keys and values.
+type Perfect[K comparable, V any] struct {
+	keys   []K
+	values []V
+	disps  []uint32
+	hashfn hash.HFunc
+	seed   uintptr
+}
+
+const (
+	perfectBucketSize = 4
+	perfectAttempts   = 8
+)
+
+var (
+	// ErrDuplicateKey is returned by BuildPerfect if a key occurs twice.
+	ErrDuplicateKey = errors.New("swiss: duplicate key")
+	// ErrNoPerfectHash is returned by BuildPerfect if no perfect hash
+	// function could be found, which is extremely unlikely for a reasonable
+	// hash function.
+	ErrNoPerfectHash = errors.New("swiss: failed to build perfect hash")
+)
+
+// BuildPerfect builds a Perfect map from the key-value pairs. It returns
+// ErrDuplicateKey if a key occurs more than once.
+func BuildPerfect[K comparable, V any](pairs iter.Seq2[K, V]) (*Perfect[K, V], error) {
+	seen := New[K, V](0)
+	var keys []K
+	var values []V
+	for k, v := range pairs {
+		if _, ok := seen.Get(k); ok {
+			return nil, ErrDuplicateKey
+		}
+		seen.Put(k, v)
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	p := &Perfect[K, V]{hashfn: hash.GetHashFunc[K]()}
+	if len(keys) == 0 {
+		return p, nil
+	}
+	for range perfectAttempts {
+		p.seed = uintptr(rand.Uint64())
+		if order, ok := p.place(keys); ok {
+			p.keys = make([]K, len(keys))
+			p.values = make([]V, len(keys))
+			for i, slot := range order {
+				p.keys[slot] = keys[i]
+				p.values[slot] = values[i]
+			}
+			return p, nil
+		}
+	}
+	return nil, ErrNoPerfectHash
+}
+
+// place searches the displacements for the current seed. It returns the
+// slot assigned to every key.
+func (p *Perfect[K, V]) place(keys []K) ([]uint32, bool) {
+	n := uint64(len(keys))
+	nbuckets := (n + perfectBucketSize - 1) / perfectBucketSize
+	hashes := make([]uint64, n)
+	buckets := make([][]uint32, nbuckets)
+	for i := range keys {
+		hashes[i] = uint64(p.hashfn(noescape(unsafe.Pointer(&keys[i])), p.seed))
+		b := fastrange(mix64(hashes[i]), nbuckets)
+		buckets[b] = append(buckets[b], uint32(i))
+	}
+	order := make([]uint64, nbuckets)
+	for i := range order {
+		order[i] = uint64(i)
+	}
+	slices.SortStableFunc(order, func(a, b uint64) int {
+		return len(buckets[b]) - len(buckets[a])
+	})
+	p.disps = make([]uint32, nbuckets)
+	taken := make([]bool, n)
+	assigned := make([]uint32, n)
+	slots := make([]uint32, 0, perfectBucketSize)
+	maxdisp := 16*n + 1024
+	for _, b := range order {
+		bucket := buckets[b]
+		if len(bucket) == 0 {
+			break
+		}
+	search:
+		for d := uint64(0); ; d++ {
+			if d == maxdisp {
+				return nil, false
+			}
+			slots = slots[:0]
+			for _, k := range bucket {
+				slot := uint32(perfectSlot(hashes[k], uint32(d), n))
+				if taken[slot] || slices.Contains(slots, slot) {
+					continue search
+				}
+				slots = append(slots, slot)
+			}
+			for j, k := range bucket {
+				taken[slots[j]] = true
+				assigned[k] = slots[j]
+			}
+			p.disps[b] = uint32(d)
+			break
+		}
+	}
+	return assigned, true
+}
+
+// Get returns the value associated with the key.
+func (p *Perfect[K, V]) Get(key K) (V, bool) {
+	n := uint64(len(p.keys))
+	if n == 0 {
+		var res V
+		return res, false
+	}
+	hash := uint64(p.hashfn(noescape(unsafe.Pointer(&key)), p.seed))
+	d := p.disps[fastrange(mix64(hash), uint64(len(p.disps)))]
+	slot := perfectSlot(hash, d, n)
+	if p.keys[slot] != key {
+		var res V
+		return res, false
+	}
+	return p.values[slot], true
+}
+
+// Len returns the number of entries.
+func (p *Perfect[K, V]) Len() int {
+	return len(p.keys)
+}
+
+// All returns an iterator over all entries.
+func (p *Perfect[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range p.keys {
+			if !yield(p.keys[i], p.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+func perfectSlot(hash uint64, disp uint32, n uint64) uint64 {
+	return fastrange(mix64(hash+uint64(disp)*0x9e3779b97f4a7c15), n)
+}
+
+// fastrange maps x to [0, n) without a division.
+func fastrange(x, n uint64) uint64 {
+	hi, _ := bits.Mul64(x, n)
+	return hi
+}
+
+// mix64 is the splitmix64 finalizer.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,64 @@
+package swiss
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPerfect(t *testing.T) {
+	t.Parallel()
+	for _, size := range []int{0, 1, 2, 7, 100, 100_000} {
+		expected := genMapStringInt(size)
+		p, err := BuildPerfect(maps.All(expected))
+		require.NoError(t, err)
+		require.Equal(t, len(expected), p.Len())
+		require.Len(t, p.keys, len(expected))
+		for k, v := range expected {
+			value, ok := p.Get(k)
+			require.True(t, ok, "absent key %s", k)
+			require.Equal(t, v, value)
+		}
+		for _, k := range genStringKeys(1000) {
+			_, exp := expected[k]
+			_, ok := p.Get(k)
+			require.Equal(t, exp, ok)
+		}
+		require.Equal(t, expected, maps.Collect(p.All()))
+	}
+}
+
+func TestBuildPerfectFromMap(t *testing.T) {
+	t.Parallel()
+	size := 10000
+	m := New[int, int](size)
+	for _, key := range genIntKeys(size) {
+		m.Put(key, -key)
+	}
+	p, err := BuildPerfect(m.All())
+	require.NoError(t, err)
+	require.Equal(t, m.Len(), p.Len())
+	for k, v := range m.All() {
+		value, ok := p.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, value)
+	}
+}
+
+func TestBuildPerfectDuplicateKey(t *testing.T) {
+	t.Parallel()
+	keys := []int{1, 2, 3, 2}
+	_, err := BuildPerfect(slices.All(keys))
+	assert.NoError(t, err)
+	_, err = BuildPerfect(func(yield func(int, int) bool) {
+		for i, k := range keys {
+			if !yield(k, i) {
+				return
+			}
+		}
+	})
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+}
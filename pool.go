@@ -0,0 +1,53 @@
+package swiss
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// MapPool is a pool of empty maps for short-lived scratch use, such as maps
+// scoped to a single request. Returned maps are cleared but keep their
+// groups, so a map taken from the pool usually allocates nothing until it
+// outgrows its size class. Size classes are powers of two numbers of groups.
+// Like sync.Pool, a MapPool is safe for concurrent use, and pooled maps may
+// be dropped by the garbage collector at any time.
+type MapPool[K comparable, V any] struct {
+	opts    []Option
+	classes [32]sync.Pool
+}
+
+// NewMapPool creates a pool of maps created with the options opts, which
+// are checked like by NewE.
+func NewMapPool[K comparable, V any](opts ...Option) (*MapPool[K, V], error) {
+	if _, err := NewE[K, V](0, opts...); err != nil {
+		return nil, err
+	}
+	return &MapPool[K, V]{opts: opts}, nil
+}
+
+// Get returns an empty map taking at least size inserts without rehashing.
+func (p *MapPool[K, V]) Get(size int) *Map[K, V] {
+	class := max(bits.Len(uint(groupsnum(size)-1)), 1)
+	if class >= len(p.classes) || 1<<class > maxGroups[K, V]() {
+		return New[K, V](size, p.opts...)
+	}
+	if m, ok := p.classes[class].Get().(*Map[K, V]); ok {
+		return m
+	}
+	return New[K, V](1<<class*grpload-grpload-1, p.opts...)
+}
+
+// Put clears the map and returns it to the pool. The map must not be used
+// afterwards. Maps with watchers, an active checkpoint or a running
+// iterator, and off-heap maps, are not pooled.
+func (p *MapPool[K, V]) Put(m *Map[K, V]) {
+	if m.obs != nil || m.cp != nil || m.iterators > 0 || m.alloc != nil {
+		return
+	}
+	m.Clear()
+	m.growPending = false
+	class := bits.Len(uint(len(m.grps))) - 1
+	if class > 0 && class < len(p.classes) {
+		p.classes[class].Put(m)
+	}
+}
@@ -0,0 +1,53 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapPool(t *testing.T) {
+	t.Parallel()
+	p, err := NewMapPool[int, int](WithLazyClear())
+	require.NoError(t, err)
+	for _, size := range []int{0, 1, 6, 7, 100, 1000} {
+		m := p.Get(size)
+		require.Zero(t, m.Len())
+		require.Greater(t, m.Cap(), size)
+		for i := range size {
+			m.Put(i, i)
+		}
+		p.Put(m)
+	}
+
+	// A map returned to the pool comes back empty, with its groups. The pool
+	// may drop it, so retry a few times.
+	var reused bool
+	for range 10 {
+		m := p.Get(100)
+		for i := range 100 {
+			m.Put(i, i)
+		}
+		m.Delete(5)
+		grps := &m.grps[0]
+		p.Put(m)
+		m = p.Get(90)
+		require.Zero(t, m.Len())
+		_, ok := m.Get(1)
+		require.False(t, ok)
+		if &m.grps[0] == grps {
+			reused = true
+			require.Zero(t, m.tombstones)
+			break
+		}
+	}
+	require.True(t, reused)
+
+	m := p.Get(10)
+	m.Watch(1)
+	p.Put(m)
+	require.NotSame(t, m, p.Get(10))
+
+	_, err = NewMapPool[string, int](WithIdentityHash())
+	require.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package swiss
+
+import (
+	"iter"
+	"strings"
+)
+
+// PrefixIndex keeps the keys of a map with string keys in a radix tree, so
+// that the entries whose keys share a prefix can be listed without a scan
+// of the map. It is maintained on every insertion and deletion of a key
+// once created with IndexPrefixes, and costs about one tree node per key.
+// Like Watch, it does not follow a rollback to a checkpoint.
+type PrefixIndex[V any] struct {
+	m    *Map[string, V]
+	root trieNode
+}
+
+// trieNode is a node of a radix tree. The key of a node is the
+// concatenation of the labels from the root, and leaf is set if the key is
+// in the index. Children are sorted by the first byte of their labels, which
+// are distinct and never empty.
+type trieNode struct {
+	label    string
+	leaf     bool
+	children []*trieNode
+}
+
+// IndexPrefixes builds a PrefixIndex over the keys of m. Keys are indexed as
+// stored, after normalization by WithKeyNormalizer.
+func IndexPrefixes[V any](m *Map[string, V]) *PrefixIndex[V] {
+	p := &PrefixIndex[V]{m: m}
+	for k := range m.All() {
+		p.root.insert(k)
+	}
+	m.addIndex(p)
+	return p
+}
+
+// AllWithPrefix returns an iterator over the entries of the map whose keys
+// start with prefix, in lexicographic order of the keys. The map must not be
+// modified during the iteration.
+func (p *PrefixIndex[V]) AllWithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		n, key := p.root.find(prefix)
+		if n == nil {
+			return
+		}
+		n.walk(key, func(key string) bool {
+			v, _ := p.m.Get(key)
+			return yield(key, v)
+		})
+	}
+}
+
+// Close stops maintaining the index, which must not be used afterwards.
+func (p *PrefixIndex[V]) Close() {
+	p.m.removeIndex(p)
+	p.root = trieNode{}
+}
+
+func (p *PrefixIndex[V]) update(op Op, key string, _ V) {
+	switch op {
+	case OpPut:
+		p.root.insert(key)
+	case OpDelete:
+		p.root.remove(key)
+	case OpClear:
+		p.root = trieNode{}
+	}
+}
+
+// child returns the position of the child whose label starts with b, or of
+// the place to insert it, and whether it exists.
+func (n *trieNode) child(b byte) (int, bool) {
+	lo, hi := 0, len(n.children)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if n.children[mid].label[0] < b {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(n.children) && n.children[lo].label[0] == b
+}
+
+func (n *trieNode) insert(key string) {
+	for key != "" {
+		i, ok := n.child(key[0])
+		if !ok {
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = &trieNode{label: key, leaf: true}
+			return
+		}
+		c := n.children[i]
+		l := commonPrefix(c.label, key)
+		if l < len(c.label) {
+			// Split the edge at the end of the common prefix.
+			mid := &trieNode{label: c.label[:l], children: []*trieNode{c}}
+			c.label = c.label[l:]
+			n.children[i] = mid
+			c = mid
+		}
+		n, key = c, key[l:]
+	}
+	n.leaf = true
+}
+
+// remove deletes the key below n, merging nodes left with a single child
+// and dropping those left with none.
+func (n *trieNode) remove(key string) {
+	if key == "" {
+		n.leaf = false
+		return
+	}
+	i, ok := n.child(key[0])
+	if !ok || !strings.HasPrefix(key, n.children[i].label) {
+		return
+	}
+	c := n.children[i]
+	c.remove(key[len(c.label):])
+	switch {
+	case c.leaf:
+	case len(c.children) == 0:
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	case len(c.children) == 1:
+		gc := c.children[0]
+		gc.label = c.label + gc.label
+		n.children[i] = gc
+	}
+}
+
+// find returns the node of the shortest key starting with prefix, together
+// with that key, or nil if no key starts with prefix.
+func (n *trieNode) find(prefix string) (*trieNode, string) {
+	var key string
+	for prefix != "" {
+		i, ok := n.child(prefix[0])
+		if !ok {
+			return nil, ""
+		}
+		c := n.children[i]
+		switch {
+		case strings.HasPrefix(c.label, prefix):
+			return c, key + c.label
+		case strings.HasPrefix(prefix, c.label):
+			key += c.label
+			prefix = prefix[len(c.label):]
+			n = c
+		default:
+			return nil, ""
+		}
+	}
+	return n, key
+}
+
+// walk calls fn for the keys of n and its descendants in lexicographic
+// order, where key is the key of n, and reports whether fn always returned
+// true.
+func (n *trieNode) walk(key string, fn func(string) bool) bool {
+	if n.leaf && !fn(key) {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.walk(key+c.label, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func commonPrefix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
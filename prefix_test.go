@@ -0,0 +1,83 @@
+package swiss
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func prefixKeys[V any](p *PrefixIndex[V], prefix string) []string {
+	var keys []string
+	for k := range p.AllWithPrefix(prefix) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestPrefixIndex(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	m.Put("/a/b", 1)
+	m.Put("/a", 2)
+	p := IndexPrefixes(m)
+	m.Put("/a/c", 3)
+	m.Put("/ab", 4)
+	m.Put("", 5)
+	m.Put("/a/b", 6)
+
+	require.Equal(t, []string{"", "/a", "/a/b", "/a/c", "/ab"}, prefixKeys(p, ""))
+	require.Equal(t, []string{"/a/b", "/a/c"}, prefixKeys(p, "/a/"))
+	require.Equal(t, []string{"/a", "/a/b", "/a/c", "/ab"}, prefixKeys(p, "/a"))
+	require.Empty(t, prefixKeys(p, "/b"))
+	require.Empty(t, prefixKeys(p, "/a/bc"))
+	for k, v := range p.AllWithPrefix("/a/b") {
+		require.Equal(t, "/a/b", k)
+		require.Equal(t, 6, v)
+	}
+
+	m.Delete("/a")
+	m.Delete("/a/c")
+	require.Equal(t, []string{"/a/b", "/ab"}, prefixKeys(p, "/a"))
+	m.Clear()
+	require.Empty(t, prefixKeys(p, ""))
+	m.Put("x", 1)
+	require.Equal(t, []string{"x"}, prefixKeys(p, ""))
+
+	p.Close()
+	m.Put("y", 2)
+	require.Empty(t, m.obs.indexes)
+}
+
+func TestPrefixIndexRandom(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0)
+	p := IndexPrefixes(m)
+	keys := make(map[string]bool)
+	for i := range 5000 {
+		var b strings.Builder
+		for range 1 + rand.Intn(6) {
+			b.WriteByte("abc/"[rand.Intn(4)])
+		}
+		k := b.String()
+		if rand.Intn(3) == 0 {
+			m.Delete(k)
+			delete(keys, k)
+		} else {
+			m.Put(k, i)
+			keys[k] = true
+		}
+	}
+	for _, prefix := range []string{"", "a", "ab", "a/", "/", "cc", "abc/a"} {
+		var expected []string
+		for k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				expected = append(expected, k)
+			}
+		}
+		slices.Sort(expected)
+		require.Equal(t, expected, prefixKeys(p, prefix), "prefix %q", prefix)
+	}
+}
@@ -0,0 +1,412 @@
+package swiss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+// A protobuf map field such as
+//
+//	map<string, int64> counts = 3;
+//
+// is encoded as a repeated field of entry messages with the key as field 1
+// and the value as field 2. MarshalProto and UnmarshalProto read and write
+// this encoding entry by entry, without an intermediate builtin map or
+// generated code, with ProtoCodecs matching the declared key and value
+// types.
+
+// ErrInvalidProto is returned by UnmarshalProto for malformed or truncated
+// input. Errors of the reader are wrapped as well.
+var ErrInvalidProto = errors.New("swiss: invalid protobuf encoding")
+
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+
+	maxProtoField = 1<<29 - 1
+)
+
+// ProtoCodec encodes keys or values of type T as a protobuf field of one of
+// the scalar types, or of a message type with ProtoMessage.
+type ProtoCodec[T any] struct {
+	wire int
+	// encode appends the varint, fixed or length-delimited payload of v,
+	// the latter without its length.
+	encode func(b []byte, v T) ([]byte, error)
+	// decode decodes a varint or fixed payload from x, or a length-delimited
+	// one from b.
+	decode func(x uint64, b []byte) (T, error)
+}
+
+func varintCodec[T any](to func(T) uint64, from func(uint64) T) ProtoCodec[T] {
+	return ProtoCodec[T]{
+		wire:   protoVarint,
+		encode: func(b []byte, v T) ([]byte, error) { return binary.AppendUvarint(b, to(v)), nil },
+		decode: func(x uint64, _ []byte) (T, error) { return from(x), nil },
+	}
+}
+
+func fixed32Codec[T any](to func(T) uint32, from func(uint32) T) ProtoCodec[T] {
+	return ProtoCodec[T]{
+		wire:   protoFixed32,
+		encode: func(b []byte, v T) ([]byte, error) { return binary.LittleEndian.AppendUint32(b, to(v)), nil },
+		decode: func(x uint64, _ []byte) (T, error) { return from(uint32(x)), nil },
+	}
+}
+
+func fixed64Codec[T any](to func(T) uint64, from func(uint64) T) ProtoCodec[T] {
+	return ProtoCodec[T]{
+		wire:   protoFixed64,
+		encode: func(b []byte, v T) ([]byte, error) { return binary.LittleEndian.AppendUint64(b, to(v)), nil },
+		decode: func(x uint64, _ []byte) (T, error) { return from(x), nil },
+	}
+}
+
+// ProtoInt32 returns the codec of the protobuf int32 type.
+func ProtoInt32() ProtoCodec[int32] {
+	return varintCodec(func(v int32) uint64 { return uint64(v) }, func(x uint64) int32 { return int32(x) })
+}
+
+// ProtoInt64 returns the codec of the protobuf int64 type.
+func ProtoInt64() ProtoCodec[int64] {
+	return varintCodec(func(v int64) uint64 { return uint64(v) }, func(x uint64) int64 { return int64(x) })
+}
+
+// ProtoUint32 returns the codec of the protobuf uint32 type.
+func ProtoUint32() ProtoCodec[uint32] {
+	return varintCodec(func(v uint32) uint64 { return uint64(v) }, func(x uint64) uint32 { return uint32(x) })
+}
+
+// ProtoUint64 returns the codec of the protobuf uint64 type.
+func ProtoUint64() ProtoCodec[uint64] {
+	return varintCodec(func(v uint64) uint64 { return v }, func(x uint64) uint64 { return x })
+}
+
+// ProtoSint32 returns the codec of the protobuf sint32 type.
+func ProtoSint32() ProtoCodec[int32] {
+	return varintCodec(
+		func(v int32) uint64 { return uint64(uint32(v<<1) ^ uint32(v>>31)) },
+		func(x uint64) int32 { return int32(uint32(x)>>1) ^ -int32(x&1) },
+	)
+}
+
+// ProtoSint64 returns the codec of the protobuf sint64 type.
+func ProtoSint64() ProtoCodec[int64] {
+	return varintCodec(
+		func(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) },
+		func(x uint64) int64 { return int64(x>>1) ^ -int64(x&1) },
+	)
+}
+
+// ProtoBool returns the codec of the protobuf bool type.
+func ProtoBool() ProtoCodec[bool] {
+	return varintCodec(
+		func(v bool) uint64 {
+			if v {
+				return 1
+			}
+			return 0
+		},
+		func(x uint64) bool { return x != 0 },
+	)
+}
+
+// ProtoFixed32 returns the codec of the protobuf fixed32 type.
+func ProtoFixed32() ProtoCodec[uint32] {
+	return fixed32Codec(func(v uint32) uint32 { return v }, func(x uint32) uint32 { return x })
+}
+
+// ProtoFixed64 returns the codec of the protobuf fixed64 type.
+func ProtoFixed64() ProtoCodec[uint64] {
+	return fixed64Codec(func(v uint64) uint64 { return v }, func(x uint64) uint64 { return x })
+}
+
+// ProtoSfixed32 returns the codec of the protobuf sfixed32 type.
+func ProtoSfixed32() ProtoCodec[int32] {
+	return fixed32Codec(func(v int32) uint32 { return uint32(v) }, func(x uint32) int32 { return int32(x) })
+}
+
+// ProtoSfixed64 returns the codec of the protobuf sfixed64 type.
+func ProtoSfixed64() ProtoCodec[int64] {
+	return fixed64Codec(func(v int64) uint64 { return uint64(v) }, func(x uint64) int64 { return int64(x) })
+}
+
+// ProtoFloat returns the codec of the protobuf float type.
+func ProtoFloat() ProtoCodec[float32] {
+	return fixed32Codec(math.Float32bits, math.Float32frombits)
+}
+
+// ProtoDouble returns the codec of the protobuf double type.
+func ProtoDouble() ProtoCodec[float64] {
+	return fixed64Codec(math.Float64bits, math.Float64frombits)
+}
+
+// ProtoString returns the codec of the protobuf string type. Like the
+// protobuf runtime, it rejects strings that are not valid UTF-8 when
+// decoding.
+func ProtoString() ProtoCodec[string] {
+	return ProtoCodec[string]{
+		wire:   protoBytes,
+		encode: func(b []byte, v string) ([]byte, error) { return append(b, v...), nil },
+		decode: func(_ uint64, b []byte) (string, error) {
+			if !utf8.Valid(b) {
+				return "", errors.New("string is not valid UTF-8")
+			}
+			return string(b), nil
+		},
+	}
+}
+
+// ProtoText returns a codec encoding T as a protobuf string with its
+// MarshalText and UnmarshalText methods, so that typed keys such as
+// netip.Addr can be used with a map<string, ...> field.
+func ProtoText[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}]() ProtoCodec[T] {
+	return ProtoCodec[T]{
+		wire: protoBytes,
+		encode: func(b []byte, v T) ([]byte, error) {
+			text, err := v.MarshalText()
+			return append(b, text...), err
+		},
+		decode: func(_ uint64, b []byte) (T, error) {
+			var v T
+			if !utf8.Valid(b) {
+				return v, errors.New("string is not valid UTF-8")
+			}
+			err := PT(&v).UnmarshalText(b)
+			return v, err
+		},
+	}
+}
+
+// ProtoBytes returns the codec of the protobuf bytes type.
+func ProtoBytes() ProtoCodec[[]byte] {
+	return ProtoCodec[[]byte]{
+		wire:   protoBytes,
+		encode: func(b []byte, v []byte) ([]byte, error) { return append(b, v...), nil },
+		decode: func(_ uint64, b []byte) ([]byte, error) { return bytes.Clone(b), nil },
+	}
+}
+
+// ProtoMessage returns the codec of a message type, with marshal and
+// unmarshal converting between T and the encoded message, such as
+// proto.Marshal and proto.Unmarshal of generated types. A missing value is
+// decoded as the zero T without calling unmarshal.
+func ProtoMessage[T any](marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error)) ProtoCodec[T] {
+	return ProtoCodec[T]{
+		wire: protoBytes,
+		encode: func(b []byte, v T) ([]byte, error) {
+			msg, err := marshal(v)
+			return append(b, msg...), err
+		},
+		decode: func(_ uint64, b []byte) (T, error) { return unmarshal(b) },
+	}
+}
+
+// appendField appends v as field num.
+func (c ProtoCodec[T]) appendField(b []byte, num int, v T) ([]byte, error) {
+	b = binary.AppendUvarint(b, uint64(num)<<3|uint64(c.wire))
+	if c.wire != protoBytes {
+		return c.encode(b, v)
+	}
+	start := len(b)
+	b, err := c.encode(b, v)
+	if err != nil {
+		return b, err
+	}
+	var n [binary.MaxVarintLen64]byte
+	return slices.Insert(b, start, binary.AppendUvarint(n[:0], uint64(len(b)-start))...), nil
+}
+
+// MarshalProto writes the entries of the map to w as the protobuf map field
+// with number field, encoding keys with kc and values with vc. The output
+// may be embedded in a message by writing its other fields before or after
+// it. Entries are written as the map is iterated, through a buffer flushed
+// before MarshalProto returns.
+func (m *Map[K, V]) MarshalProto(w io.Writer, field int, kc ProtoCodec[K], vc ProtoCodec[V]) error {
+	if field < 1 || field > maxProtoField {
+		return fmt.Errorf("swiss: invalid protobuf field number %d", field)
+	}
+	bw := bufio.NewWriter(w)
+	var entry, prefix []byte
+	var err error
+	for k, v := range m.All() {
+		if entry, err = kc.appendField(entry[:0], 1, k); err != nil {
+			return fmt.Errorf("swiss: protobuf key: %w", err)
+		}
+		if entry, err = vc.appendField(entry, 2, v); err != nil {
+			return fmt.Errorf("swiss: protobuf value: %w", err)
+		}
+		prefix = binary.AppendUvarint(prefix[:0], uint64(field)<<3|protoBytes)
+		prefix = binary.AppendUvarint(prefix, uint64(len(entry)))
+		bw.Write(prefix)
+		bw.Write(entry)
+	}
+	return bw.Flush()
+}
+
+// UnmarshalProto reads a protobuf message from r until EOF and puts the
+// entries of its map field with number field into the map, decoding keys
+// with kc and values with vc. Other fields are skipped, so r may hold the
+// output of MarshalProto or a complete message containing the map field.
+// Missing keys and values are decoded as zero values and later entries
+// overwrite earlier ones with the same key, as in protobuf. Existing entries
+// are kept unless overwritten. It stops at the first malformed field,
+// returning an error wrapping ErrInvalidProto; the entries before it have
+// been put. If the growth gate of the map denies growth, UnmarshalProto
+// returns ErrFull.
+func (m *Map[K, V]) UnmarshalProto(r io.Reader, field int, kc ProtoCodec[K], vc ProtoCodec[V]) error {
+	if field < 1 || field > maxProtoField {
+		return fmt.Errorf("swiss: invalid protobuf field number %d", field)
+	}
+	br := bufio.NewReader(r)
+	var entry bytes.Buffer
+	for {
+		tag, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return protoError(err)
+		}
+		num, wire := tag>>3, int(tag&7)
+		if num != uint64(field) || wire != protoBytes {
+			if err := skipProto(br, num, wire); err != nil {
+				return err
+			}
+			continue
+		}
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return protoError(err)
+		}
+		entry.Reset()
+		// Copy instead of allocating n bytes up front, which may be bogus.
+		if _, err := io.CopyN(&entry, br, int64(min(n, math.MaxInt64))); err != nil {
+			return protoError(err)
+		}
+		key, value, err := decodeProtoEntry(entry.Bytes(), kc, vc)
+		if err != nil {
+			return err
+		}
+		if err := m.TryPut(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeProtoEntry decodes the key and value of a map entry message.
+func decodeProtoEntry[K, V any](b []byte, kc ProtoCodec[K], vc ProtoCodec[V]) (K, V, error) {
+	var key K
+	var value V
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return key, value, protoError(io.ErrUnexpectedEOF)
+		}
+		b = b[n:]
+		num, wire := tag>>3, int(tag&7)
+		x, payload, rest, err := consumeProto(b, num, wire)
+		if err != nil {
+			return key, value, err
+		}
+		b = rest
+		switch {
+		case num == 1 && wire == kc.wire:
+			if key, err = kc.decode(x, payload); err != nil {
+				return key, value, fmt.Errorf("%w: key: %w", ErrInvalidProto, err)
+			}
+		case num == 2 && wire == vc.wire:
+			if value, err = vc.decode(x, payload); err != nil {
+				return key, value, fmt.Errorf("%w: value: %w", ErrInvalidProto, err)
+			}
+		case num == 1 || num == 2:
+			return key, value, fmt.Errorf("%w: wire type %d of map entry field %d", ErrInvalidProto, wire, num)
+		}
+	}
+	return key, value, nil
+}
+
+// consumeProto splits the payload of a field with the given wire type off
+// b, returning varint and fixed payloads as x and length-delimited ones as
+// payload.
+func consumeProto(b []byte, num uint64, wire int) (x uint64, payload, rest []byte, err error) {
+	if num == 0 {
+		return 0, nil, nil, fmt.Errorf("%w: field number 0", ErrInvalidProto)
+	}
+	switch wire {
+	case protoVarint:
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, nil, nil, protoError(io.ErrUnexpectedEOF)
+		}
+		return x, nil, b[n:], nil
+	case protoFixed64:
+		if len(b) < 8 {
+			return 0, nil, nil, protoError(io.ErrUnexpectedEOF)
+		}
+		return binary.LittleEndian.Uint64(b), nil, b[8:], nil
+	case protoFixed32:
+		if len(b) < 4 {
+			return 0, nil, nil, protoError(io.ErrUnexpectedEOF)
+		}
+		return uint64(binary.LittleEndian.Uint32(b)), nil, b[4:], nil
+	case protoBytes:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return 0, nil, nil, protoError(io.ErrUnexpectedEOF)
+		}
+		return 0, b[n : n+int(l)], b[n+int(l):], nil
+	}
+	return 0, nil, nil, fmt.Errorf("%w: unsupported wire type %d", ErrInvalidProto, wire)
+}
+
+// skipProto skips the payload of a field with the given wire type in r.
+func skipProto(r *bufio.Reader, num uint64, wire int) error {
+	if num == 0 {
+		return fmt.Errorf("%w: field number 0", ErrInvalidProto)
+	}
+	var n uint64
+	switch wire {
+	case protoVarint:
+		_, err := binary.ReadUvarint(r)
+		return protoError(err)
+	case protoFixed64:
+		n = 8
+	case protoFixed32:
+		n = 4
+	case protoBytes:
+		var err error
+		if n, err = binary.ReadUvarint(r); err != nil {
+			return protoError(err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported wire type %d", ErrInvalidProto, wire)
+	}
+	_, err := io.CopyN(io.Discard, r, int64(min(n, math.MaxInt64)))
+	return protoError(err)
+}
+
+// protoError wraps a read error into ErrInvalidProto, treating EOF as
+// truncated input. It returns nil for a nil error.
+func protoError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidProto, err)
+}
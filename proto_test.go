@@ -0,0 +1,156 @@
+package swiss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"maps"
+	"math"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalProtoWireFormat(t *testing.T) {
+	t.Parallel()
+	// map<string, int32> m = 3; with m = {"a": 1}
+	m := New[string, int32](0)
+	m.Put("a", 1)
+	var buf bytes.Buffer
+	require.NoError(t, m.MarshalProto(&buf, 3, ProtoString(), ProtoInt32()))
+	require.Equal(t, []byte{0x1a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x01}, buf.Bytes())
+
+	// Negative int32 values are sign-extended to ten bytes.
+	m.Put("a", -1)
+	buf.Reset()
+	require.NoError(t, m.MarshalProto(&buf, 3, ProtoString(), ProtoInt32()))
+	require.Len(t, buf.Bytes(), 2+3+11)
+
+	require.Error(t, m.MarshalProto(&buf, 0, ProtoString(), ProtoInt32()))
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	t.Parallel()
+	m := New[int64, float64](0)
+	for i := range int64(1000) {
+		m.Put(i*i*(1-2*(i%2)), float64(i)/3)
+	}
+	m.Put(math.MinInt64, math.Inf(-1))
+	m.Put(math.MaxInt64, math.NaN())
+	for _, kc := range []ProtoCodec[int64]{ProtoInt64(), ProtoSint64(), ProtoSfixed64()} {
+		var buf bytes.Buffer
+		require.NoError(t, m.MarshalProto(&buf, 7, kc, ProtoDouble()))
+		l := New[int64, float64](0)
+		require.NoError(t, l.UnmarshalProto(&buf, 7, kc, ProtoDouble()))
+		require.Equal(t, m.Len(), l.Len())
+		for k, v := range m.All() {
+			got, ok := l.Get(k)
+			require.True(t, ok)
+			if math.IsNaN(v) {
+				require.True(t, math.IsNaN(got))
+			} else {
+				require.Equal(t, v, got)
+			}
+		}
+	}
+	var msg bytes.Buffer
+	require.NoError(t, m.MarshalProto(&msg, 7, ProtoInt64(), ProtoDouble()))
+	full := New[int64, float64](0, WithGrowthGate(BudgetGate(0)))
+	require.ErrorIs(t, full.UnmarshalProto(&msg, 7, ProtoInt64(), ProtoDouble()), ErrFull)
+	require.Equal(t, full.Cap(), full.Len())
+
+	s := New[int32, []byte](0)
+	s.Put(-5, []byte("x"))
+	s.Put(math.MinInt32, []byte{})
+	for _, kc := range []ProtoCodec[int32]{ProtoInt32(), ProtoSint32(), ProtoSfixed32()} {
+		var buf bytes.Buffer
+		require.NoError(t, s.MarshalProto(&buf, 1, kc, ProtoBytes()))
+		l := New[int32, []byte](0)
+		require.NoError(t, l.UnmarshalProto(&buf, 1, kc, ProtoBytes()))
+		requireSameEntries(t, s, l)
+	}
+
+	type point struct{ X, Y uint32 }
+	pc := ProtoMessage(
+		func(p point) ([]byte, error) {
+			b, _ := ProtoUint32().appendField(nil, 1, p.X)
+			return ProtoFixed32().appendField(b, 2, p.Y)
+		},
+		func(b []byte) (point, error) {
+			if len(b) != 7 {
+				return point{}, errors.New("bad point")
+			}
+			return point{X: uint32(b[1]), Y: binary.LittleEndian.Uint32(b[3:])}, nil
+		},
+	)
+	p := New[bool, point](0)
+	p.Put(true, point{1, 2})
+	p.Put(false, point{3, 4})
+	var buf bytes.Buffer
+	require.NoError(t, p.MarshalProto(&buf, 2, ProtoBool(), pc))
+	l := New[bool, point](0)
+	require.NoError(t, l.UnmarshalProto(&buf, 2, ProtoBool(), pc))
+	requireSameEntries(t, p, l)
+}
+
+func TestProtoTextKeys(t *testing.T) {
+	t.Parallel()
+	m := New[netip.Addr, int32](0)
+	m.Put(netip.MustParseAddr("10.0.0.1"), 1)
+	m.Put(netip.MustParseAddr("::1"), 2)
+	var buf bytes.Buffer
+	require.NoError(t, m.MarshalProto(&buf, 3, ProtoText[netip.Addr](), ProtoInt32()))
+
+	// The keys are plain strings on the wire.
+	s := New[string, int32](0)
+	require.NoError(t, s.UnmarshalProto(bytes.NewReader(buf.Bytes()), 3, ProtoString(), ProtoInt32()))
+	require.Equal(t, map[string]int32{"10.0.0.1": 1, "::1": 2}, maps.Collect(s.All()))
+
+	loaded := New[netip.Addr, int32](0)
+	require.NoError(t, loaded.UnmarshalProto(bytes.NewReader(buf.Bytes()), 3, ProtoText[netip.Addr](), ProtoInt32()))
+	requireSameEntries(t, m, loaded)
+
+	s.Put("not an address", 3)
+	buf.Reset()
+	require.NoError(t, s.MarshalProto(&buf, 3, ProtoString(), ProtoInt32()))
+	err := New[netip.Addr, int32](0).UnmarshalProto(bytes.NewReader(buf.Bytes()), 3, ProtoText[netip.Addr](), ProtoInt32())
+	require.ErrorIs(t, err, ErrInvalidProto)
+}
+
+func TestUnmarshalProtoMessage(t *testing.T) {
+	t.Parallel()
+	msg := []byte{
+		0x08, 0x96, 0x01, // 1: varint 150
+		0x1a, 0x03, 0x0a, 0x01, 'a', // 3: {key: "a"}, missing value
+		0x15, 1, 2, 3, 4, // 2: fixed32
+		0x1a, 0x07, 0x10, 0x02, 0x0a, 0x01, 'b', 0x18, 0x05, // 3: {value: 2, key: "b", 3: 5}
+		0x22, 0x02, 'h', 'i', // 4: string
+		0x19, 1, 2, 3, 4, 5, 6, 7, 8, // 3: fixed64, not an entry
+		0x1a, 0x04, 0x0a, 0x00, 0x10, 0x03, // 3: {key: "", value: 3}
+		0x1a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x04, // 3: {key: "a", value: 4}
+	}
+	m := New[string, uint64](0)
+	m.Put("old", 9)
+	require.NoError(t, m.UnmarshalProto(bytes.NewReader(msg), 3, ProtoString(), ProtoUint64()))
+	require.Equal(t, 4, m.Len())
+	for k, v := range map[string]uint64{"a": 4, "b": 2, "": 3, "old": 9} {
+		got, ok := m.Get(k)
+		require.True(t, ok, k)
+		require.Equal(t, v, got, k)
+	}
+
+	for _, bad := range [][]byte{
+		{0x1a},                               // truncated length
+		{0x1a, 0x05, 0x0a},                   // truncated entry
+		{0x1a, 0x02, 0x08, 0x01},             // key with the wrong wire type
+		{0x1a, 0x03, 0x0a, 0x01, 0xff},       // invalid UTF-8
+		{0x1a, 0x02, 0x0a, 0x05},             // key longer than the entry
+		{0x0b, 0x0c},                         // group
+		{0x00, 0x01},                         // field number 0
+		{0x08, 0xff, 0xff, 0xff, 0xff, 0xff}, // truncated varint
+	} {
+		err := New[string, uint64](0).UnmarshalProto(bytes.NewReader(bad), 3, ProtoString(), ProtoUint64())
+		require.ErrorIs(t, err, ErrInvalidProto, "%x", bad)
+	}
+}
@@ -0,0 +1,115 @@
+package swiss
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of events per key with token buckets: every
+// key may use burst tokens at once, refilled at rate tokens per second.
+// Buckets are refilled lazily when their key is used and a bucket that has
+// refilled completely is equivalent to no bucket at all, so Reap can drop
+// idle keys without changing any decision. RateLimiter is safe for
+// concurrent use.
+type RateLimiter[K comparable] struct {
+	mu      sync.Mutex
+	buckets *Map[K, tokenBucket]
+	rate    float64
+	burst   float64
+	now     func() time.Time
+	cursor  int // next group to be examined by Reap
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   int64 // unix nanoseconds of the last refill
+}
+
+// NewRateLimiter creates a RateLimiter allowing rate events per second and
+// bursts of up to burst events for every key.
+func NewRateLimiter[K comparable](rate float64, burst int) *RateLimiter[K] {
+	return &RateLimiter[K]{
+		buckets: New[K, tokenBucket](0),
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether an event for the key may happen now and, if so,
+// consumes a token.
+func (l *RateLimiter[K]) Allow(key K) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events for the key may happen now and, if so,
+// consumes n tokens.
+func (l *RateLimiter[K]) AllowN(key K, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UnixNano()
+	allowed := false
+	l.buckets.Do(key, func(b *tokenBucket, exists bool) (tokenBucket, bool, bool) {
+		tokens := l.burst
+		if exists {
+			tokens = l.refill(*b, now)
+		}
+		if allowed = tokens >= float64(n); allowed {
+			tokens -= float64(n)
+		}
+		// A full bucket needs no entry.
+		return tokenBucket{tokens: tokens, last: now}, true, tokens >= l.burst
+	})
+	return allowed
+}
+
+// Tokens returns the number of tokens currently available for the key.
+func (l *RateLimiter[K]) Tokens(key K) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets.Get(key)
+	if !ok {
+		return l.burst
+	}
+	return l.refill(b, l.now().UnixNano())
+}
+
+// Len returns the number of keys with a bucket that was not full when last
+// used.
+func (l *RateLimiter[K]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buckets.Len()
+}
+
+// Reap examines up to budget buckets, continuing from where the previous
+// call stopped, and removes those that have refilled completely. It returns
+// the number of removed buckets.
+func (l *RateLimiter[K]) Reap(budget int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UnixNano()
+	var removed, examined int
+	for visited := 0; examined < budget && visited < len(l.buckets.grps); visited++ {
+		if l.cursor >= len(l.buckets.grps) {
+			l.cursor = 0
+		}
+		group := &l.buckets.grps[l.cursor]
+		mask := group.maskFull()
+		for mask != 0 {
+			i := mask.first()
+			if l.refill(group.slts[i].value, now) >= l.burst {
+				l.buckets.deleteAt(group, i)
+				removed++
+			}
+			examined++
+			mask = mask.rmfirst()
+		}
+		l.cursor++
+	}
+	return removed
+}
+
+func (l *RateLimiter[K]) refill(b tokenBucket, now int64) float64 {
+	return min(b.tokens+float64(now-b.last)/float64(time.Second)*l.rate, l.burst)
+}
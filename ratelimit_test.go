@@ -0,0 +1,38 @@
+package swiss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	l := NewRateLimiter[string](2, 3)
+	l.now = clock.Now
+	for range 3 {
+		require.True(t, l.Allow("a"))
+	}
+	require.False(t, l.Allow("a"))
+	require.True(t, l.Allow("b"))
+	require.False(t, l.AllowN("b", 3))
+	clock.Advance(500 * time.Millisecond)
+	require.True(t, l.Allow("a"))
+	require.False(t, l.Allow("a"))
+	require.InDelta(t, 3, l.Tokens("b"), 1e-9)
+	require.True(t, l.AllowN("b", 3))
+	require.InDelta(t, 3, l.Tokens("c"), 1e-9)
+	require.Equal(t, 2, l.Len())
+
+	clock.Advance(time.Second)
+	require.Zero(t, l.Reap(10))
+	clock.Advance(time.Second)
+	require.Equal(t, 2, l.Reap(10))
+	require.Zero(t, l.Len())
+	for range 3 {
+		require.True(t, l.Allow("a"))
+	}
+	require.False(t, l.Allow("a"))
+}
@@ -0,0 +1,50 @@
+package swiss
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// RCU publishes a read-mostly map using read-copy-update: readers load the
+// current map with a single atomic operation and never block, while writers
+// clone it, apply their changes to the private copy and atomically swap it
+// in. Writers are serialized, and every update costs a full copy of the
+// map, so RCU suits maps that are read far more often than they change.
+type RCU[K comparable, V any] struct {
+	cur atomic.Pointer[Map[K, V]]
+	mu  sync.Mutex
+}
+
+var errRCUOptions = errors.New("swiss: RCU does not support maps updated by reads: " +
+	"WithMetadata, WithDeferredGrowth or WithLazyClear")
+
+// NewRCU creates an RCU publishing m. The RCU takes ownership of m, which
+// must not be modified by the caller afterwards. NewRCU panics if m was
+// created with WithMetadata, WithDeferredGrowth or WithLazyClear, whose
+// reads update the map and would race between readers of Load.
+func NewRCU[K comparable, V any](m *Map[K, V]) *RCU[K, V] {
+	if m.meta != nil || m.deferGrowth || m.gens != nil {
+		panic(errRCUOptions)
+	}
+	r := &RCU[K, V]{}
+	r.cur.Store(m)
+	return r
+}
+
+// Load returns the current map. The result is shared with other readers and
+// must not be modified. It stays valid, but stale, after later updates.
+func (r *RCU[K, V]) Load() *Map[K, V] {
+	return r.cur.Load()
+}
+
+// Update clones the current map, calls fn with the clone and publishes it.
+// Concurrent updates are serialized, so fn always sees the result of the
+// previous update.
+func (r *RCU[K, V]) Update(fn func(m *Map[K, V])) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.cur.Load().Clone()
+	fn(m)
+	r.cur.Store(m)
+}
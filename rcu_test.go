@@ -0,0 +1,84 @@
+package swiss
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapClone(t *testing.T) {
+	t.Parallel()
+	size := 1000
+	m := New[int, int](size, WithFilter())
+	for i := range size {
+		m.Put(i, i)
+	}
+	c := m.Clone()
+	for i := range size / 2 {
+		c.Delete(i)
+		c.Put(i+size, i)
+	}
+	require.Equal(t, size, m.Len())
+	require.Equal(t, size, c.Len())
+	for i := range size {
+		value, ok := m.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, value)
+		_, ok = m.Get(i + size)
+		require.False(t, ok)
+	}
+	for i := range size / 2 {
+		_, ok := c.Get(i)
+		require.False(t, ok)
+		value, ok := c.Get(i + size)
+		require.True(t, ok)
+		require.Equal(t, i, value)
+	}
+}
+
+func TestRCU(t *testing.T) {
+	t.Parallel()
+	r := NewRCU(New[int, int](0))
+	var wg sync.WaitGroup
+	writers, updates := 4, 100
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range updates {
+				r.Update(func(m *Map[int, int]) {
+					m.Put(w*updates+i, i)
+				})
+			}
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range updates {
+				m := r.Load()
+				n := m.Len()
+				var cnt int
+				for range m.All() {
+					cnt++
+				}
+				assert.Equal(t, n, cnt)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, writers*updates, r.Load().Len())
+}
+
+func TestRCURejectsMutatingReads(t *testing.T) {
+	t.Parallel()
+	for _, opt := range []Option{WithMetadata(), WithDeferredGrowth(), WithLazyClear()} {
+		require.PanicsWithError(t, errRCUOptions.Error(), func() {
+			NewRCU(New[int, int](0, opt))
+		})
+	}
+	require.NotPanics(t, func() { NewRCU(New[int, int](0, WithFilter())) })
+}
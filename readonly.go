@@ -0,0 +1,32 @@
+package swiss
+
+import "iter"
+
+// ReadOnly returns a view of the map without methods to modify it, to be
+// handed to code that must not write. The view is not a copy: it reflects
+// later changes of the map, and the usual rules for concurrent use of the
+// map apply to it.
+func (m *Map[K, V]) ReadOnly() ReadOnlyMap[K, V] {
+	return ReadOnlyMap[K, V]{m: m}
+}
+
+// ReadOnlyMap is a read-only view of a Map, see Map.ReadOnly. The zero
+// value is not usable.
+type ReadOnlyMap[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+// Get retrieves the value associated with the key.
+func (r ReadOnlyMap[K, V]) Get(key K) (V, bool) {
+	return r.m.Get(key)
+}
+
+// Len returns the number of entries in the map.
+func (r ReadOnlyMap[K, V]) Len() int {
+	return r.m.Len()
+}
+
+// All returns an iterator over all key-value pairs of the map, see Map.All.
+func (r ReadOnlyMap[K, V]) All() iter.Seq2[K, V] {
+	return r.m.All()
+}
@@ -0,0 +1,28 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	m := New[int, string](0)
+	m.Put(1, "one")
+	ro := m.ReadOnly()
+	require.Equal(t, 1, ro.Len())
+	v, ok := ro.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "one", v)
+
+	m.Put(2, "two")
+	m.Delete(1)
+	require.Equal(t, 1, ro.Len())
+	_, ok = ro.Get(1)
+	require.False(t, ok)
+	for k, v := range ro.All() {
+		require.Equal(t, 2, k)
+		require.Equal(t, "two", v)
+	}
+}
@@ -0,0 +1,137 @@
+// Package ring implements a consistent hashing ring on top of the swiss map.
+// Every node is placed on the ring at a number of virtual positions (vnodes)
+// and a key is owned by the first vnode found clockwise from the key's hash.
+// Adding or removing a node only moves the keys owned by that node's vnodes.
+//
+// Hashes are computed with hash.GetHashFuncDeterministic and a fixed seed,
+// so rings with the same seed, vnodes and nodes place every key on the same
+// node in every process, as needed to route requests or shard data between
+// processes. Like that function, placement of keys or nodes containing
+// integers depends on the byte order of the platform, while strings are
+// placed the same everywhere.
+package ring
+
+import (
+	"iter"
+	"slices"
+	"unsafe"
+
+	"github.com/crn4/swiss"
+	"github.com/crn4/swiss/hash"
+)
+
+// Ring maps keys of type K to nodes of type N. It is not safe for concurrent
+// use.
+type Ring[K, N comparable] struct {
+	nodes    *swiss.Map[N, struct{}]
+	points   []point[N]
+	keyhash  hash.HFunc
+	nodehash hash.HFunc
+	seed     uintptr
+	vnodes   int
+}
+
+type point[N comparable] struct {
+	hash uint64
+	node N
+}
+
+// New creates an empty ring that places every node at vnodes virtual
+// positions. A non-positive vnodes value is treated as 1.
+func New[K, N comparable](vnodes int) *Ring[K, N] {
+	return NewSeeded[K, N](vnodes, 0)
+}
+
+// NewSeeded is like New, but hashes keys and nodes with the given seed.
+// Rings agree on placement only if they use the same seed.
+func NewSeeded[K, N comparable](vnodes int, seed uint64) *Ring[K, N] {
+	return &Ring[K, N]{
+		nodes:    swiss.New[N, struct{}](0),
+		keyhash:  hash.GetHashFuncDeterministic[K](),
+		nodehash: hash.GetHashFuncDeterministic[N](),
+		seed:     uintptr(seed),
+		vnodes:   max(vnodes, 1),
+	}
+}
+
+// Add places the given nodes on the ring. Nodes already on the ring are
+// ignored.
+func (r *Ring[K, N]) Add(nodes ...N) {
+	for _, node := range nodes {
+		if _, ok := r.nodes.Get(node); ok {
+			continue
+		}
+		for i := range r.vnodes {
+			hash := uint64(r.nodehash(unsafe.Pointer(&node), r.seed+uintptr(i)))
+			r.points = append(r.points, point[N]{hash: hash, node: node})
+		}
+		r.nodes.Put(node, struct{}{})
+	}
+	slices.SortFunc(r.points, func(a, b point[N]) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return 0
+	})
+}
+
+// Remove takes the node off the ring. Keys it owned are redistributed to
+// the nodes that follow its vnodes.
+func (r *Ring[K, N]) Remove(node N) {
+	if _, ok := r.nodes.Get(node); !ok {
+		return
+	}
+	r.nodes.Delete(node)
+	r.points = slices.DeleteFunc(r.points, func(p point[N]) bool {
+		return p.node == node
+	})
+}
+
+// Get returns the node owning the key. It returns false if the ring is
+// empty.
+func (r *Ring[K, N]) Get(key K) (N, bool) {
+	if len(r.points) == 0 {
+		var node N
+		return node, false
+	}
+	hash := uint64(r.keyhash(unsafe.Pointer(&key), r.seed))
+	i, _ := slices.BinarySearchFunc(r.points, hash, func(p point[N], hash uint64) int {
+		switch {
+		case p.hash < hash:
+			return -1
+		case p.hash > hash:
+			return 1
+		}
+		return 0
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node, true
+}
+
+// Has reports whether the node is on the ring.
+func (r *Ring[K, N]) Has(node N) bool {
+	_, ok := r.nodes.Get(node)
+	return ok
+}
+
+// Len returns the number of nodes on the ring.
+func (r *Ring[K, N]) Len() int {
+	return r.nodes.Len()
+}
+
+// Nodes returns an iterator over the nodes on the ring, in no particular
+// order.
+func (r *Ring[K, N]) Nodes() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		for node := range r.nodes.All() {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingEmpty(t *testing.T) {
+	t.Parallel()
+	r := New[int, string](10)
+	_, ok := r.Get(1)
+	assert.False(t, ok)
+	assert.Zero(t, r.Len())
+}
+
+func TestRingAddRemove(t *testing.T) {
+	t.Parallel()
+	r := New[int, string](100)
+	nodes := []string{"a", "b", "c", "d"}
+	r.Add(nodes...)
+	r.Add("a")
+	require.Equal(t, len(nodes), r.Len())
+	require.Len(t, r.points, len(nodes)*100)
+	for _, node := range nodes {
+		require.True(t, r.Has(node))
+	}
+	size := 10000
+	owners := make(map[int]string, size)
+	counts := make(map[string]int)
+	for key := range size {
+		node, ok := r.Get(key)
+		require.True(t, ok)
+		owners[key] = node
+		counts[node]++
+	}
+	for _, node := range nodes {
+		assert.Greater(t, counts[node], size/len(nodes)/2, "node %s is underloaded", node)
+	}
+	r.Remove("b")
+	require.False(t, r.Has("b"))
+	require.Len(t, r.points, (len(nodes)-1)*100)
+	for key, owner := range owners {
+		node, _ := r.Get(key)
+		if owner != "b" {
+			require.Equal(t, owner, node, "key %d moved", key)
+		} else {
+			require.NotEqual(t, "b", node)
+		}
+	}
+	r.Remove("b")
+	require.Equal(t, len(nodes)-1, r.Len())
+}
+
+func TestRingNodes(t *testing.T) {
+	t.Parallel()
+	r := New[string, string](0)
+	expected := make(map[string]bool)
+	for i := range 10 {
+		node := "node-" + strconv.Itoa(i)
+		r.Add(node)
+		expected[node] = true
+	}
+	actual := make(map[string]bool)
+	for node := range r.Nodes() {
+		actual[node] = true
+	}
+	assert.Equal(t, expected, actual)
+	assert.Len(t, r.points, 10)
+}
+
+func TestRingStablePlacement(t *testing.T) {
+	t.Parallel()
+	// Placement must not depend on the process, so these are fixed.
+	golden := map[uint64]map[string]string{
+		0: {
+			"alice": "node-b", "bob": "node-c", "carol": "node-c", "dave": "node-a",
+			"erin": "node-b", "frank": "node-b", "grace": "node-b", "heidi": "node-c",
+		},
+		42: {
+			"alice": "node-a", "bob": "node-c", "carol": "node-c", "dave": "node-a",
+			"erin": "node-a", "frank": "node-c", "grace": "node-b", "heidi": "node-c",
+		},
+	}
+	for seed, placement := range golden {
+		r := NewSeeded[string, string](50, seed)
+		r.Add("node-c", "node-a")
+		r.Add("node-b")
+		for key, expected := range placement {
+			node, ok := r.Get(key)
+			require.True(t, ok)
+			assert.Equal(t, expected, node, "key %s with seed %d", key, seed)
+		}
+	}
+	r := New[string, string](50)
+	r.Add("node-a", "node-b", "node-c")
+	node, _ := r.Get("alice")
+	assert.Equal(t, golden[0]["alice"], node)
+}
@@ -0,0 +1,250 @@
+package swiss
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// SafeMap is a Map guarded by a single RWMutex, safe for concurrent use.
+// Readers share the lock and writers take it exclusively, which is the
+// simplest and usually the fastest choice while contention stays low.
+type SafeMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  Map[K, V]
+	// len mirrors m.Len() after every write, so Len never takes the lock.
+	len atomic.Int64
+	// calls holds the running computations of GetOrCompute by key, guarded
+	// by mu. It is created on first use.
+	calls *Map[K, *computeCall[V]]
+}
+
+type computeCall[V any] struct {
+	done  chan struct{}
+	value V
+	ok    bool
+}
+
+// NewSafeMap creates a new SafeMap with the specified initial size. It
+// accepts the same options as New.
+func NewSafeMap[K comparable, V any](size int, opts ...Option) *SafeMap[K, V] {
+	return &SafeMap[K, V]{m: *New[K, V](size, opts...)}
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (s *SafeMap[K, V]) Put(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Put(key, value)
+	s.len.Store(int64(s.m.Len()))
+}
+
+// TryPut is like Put, but returns ErrFull instead of panicking if the growth
+// gate denies growing the map.
+func (s *SafeMap[K, V]) TryPut(key K, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.len.Store(int64(s.m.Len()))
+	return s.m.TryPut(key, value)
+}
+
+// Get retrieves the value associated with the key.
+func (s *SafeMap[K, V]) Get(key K) (V, bool) {
+	defer s.rlock()()
+	return s.m.Get(key)
+}
+
+// GetOrCompute returns the value associated with the key, calling fn and
+// storing its result first if the key is missing. fn runs without the lock
+// and at most once at a time per key: concurrent calls for a key being
+// computed wait for the first one and share its result, while calls for
+// other keys proceed in parallel. If fn panics, the panic propagates to the
+// caller that ran it, nothing is stored, and a waiting call runs fn again.
+func (s *SafeMap[K, V]) GetOrCompute(key K, fn func() V) V {
+	for {
+		if v, ok := s.Get(key); ok {
+			return v
+		}
+		s.mu.Lock()
+		if v, ok := s.m.Get(key); ok {
+			s.mu.Unlock()
+			return v
+		}
+		if s.calls == nil {
+			s.calls = New[K, *computeCall[V]](0)
+		}
+		if c, ok := s.calls.Get(key); ok {
+			s.mu.Unlock()
+			<-c.done
+			if c.ok {
+				return c.value
+			}
+			continue
+		}
+		c := &computeCall[V]{done: make(chan struct{})}
+		s.calls.Put(key, c)
+		s.mu.Unlock()
+		return s.compute(key, c, fn)
+	}
+}
+
+// compute runs fn for the call c of GetOrCompute and stores its result.
+func (s *SafeMap[K, V]) compute(key K, c *computeCall[V], fn func() V) V {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.calls.Delete(key)
+		close(c.done)
+		if c.ok {
+			s.m.Put(key, c.value)
+			s.len.Store(int64(s.m.Len()))
+		}
+	}()
+	c.value = fn()
+	c.ok = true
+	return c.value
+}
+
+// Modify calls fn with a pointer to the value associated with the key, under
+// the exclusive lock, and reports whether the key is present. fn must not
+// use the map.
+func (s *SafeMap[K, V]) Modify(key K, fn func(value *V)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Modify(key, fn)
+}
+
+// Do runs Map.Do under the exclusive lock.
+func (s *SafeMap[K, V]) Do(key K, fn func(v *V, exists bool) (newV V, write, del bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Do(key, fn)
+	s.len.Store(int64(s.m.Len()))
+}
+
+// Delete removes the key from the map.
+func (s *SafeMap[K, V]) Delete(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Delete(key)
+	s.len.Store(int64(s.m.Len()))
+}
+
+// Clear removes all entries from the map.
+func (s *SafeMap[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Clear()
+	s.len.Store(0)
+}
+
+// Len returns the number of entries in the map. It does not take the lock:
+// the result is exact once concurrent writers are done, but while they are
+// running it may lag behind writes that have not returned yet.
+func (s *SafeMap[K, V]) Len() int {
+	return int(s.len.Load())
+}
+
+// Cap returns the capacity of the map.
+func (s *SafeMap[K, V]) Cap() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Cap()
+}
+
+// Available returns the number of new keys that fit before the map
+// rehashes, see Map.Available.
+func (s *SafeMap[K, V]) Available() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Available()
+}
+
+// Version returns the modification counter of the map, see Map.Version.
+func (s *SafeMap[K, V]) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Version()
+}
+
+// Hash returns the seeded hash of the key as computed by the map.
+func (s *SafeMap[K, V]) Hash(key K) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Hash(key)
+}
+
+// All returns an iterator over all entries of the map. The iterator holds
+// the read lock until it returns, so the loop body must not modify the map.
+func (s *SafeMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		defer s.rlock()()
+		for k, v := range s.m.All() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Sample returns up to n random entries of the map, see Map.Sample.
+func (s *SafeMap[K, V]) Sample(n int) []Pair[K, V] {
+	defer s.rlock()()
+	return s.m.Sample(n)
+}
+
+// Clone returns a copy of the map as a plain, unsynchronized Map.
+func (s *SafeMap[K, V]) Clone() *Map[K, V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Clone()
+}
+
+// Batch calls fn with a view of the map under a single acquisition of the
+// exclusive lock, so a group of operations pays for locking once and is seen
+// by other goroutines as a whole. The view must not be used after fn
+// returns, and fn must not call methods of s.
+func (s *SafeMap[K, V]) Batch(fn func(tx BatchView[K, V])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() { s.len.Store(int64(s.m.Len())) }()
+	fn(BatchView[K, V]{m: &s.m})
+}
+
+// BatchView gives access to the map of a SafeMap inside Batch.
+type BatchView[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+// Get retrieves the value associated with the key.
+func (tx BatchView[K, V]) Get(key K) (V, bool) {
+	return tx.m.Get(key)
+}
+
+// Put inserts or updates a key-value pair in the map.
+func (tx BatchView[K, V]) Put(key K, value V) {
+	tx.m.Put(key, value)
+}
+
+// Delete removes the key from the map.
+func (tx BatchView[K, V]) Delete(key K) {
+	tx.m.Delete(key)
+}
+
+// Len returns the number of entries in the map.
+func (tx BatchView[K, V]) Len() int {
+	return tx.m.Len()
+}
+
+// rlock acquires the lock for a read and returns the matching unlock. Reads
+// take the exclusive lock when they update state of the map: metadata of
+// maps created with WithMetadata, and the iterator count of maps created
+// with WithDeferredGrowth.
+func (s *SafeMap[K, V]) rlock() func() {
+	if s.m.meta != nil || s.m.deferGrowth {
+		s.mu.Lock()
+		return s.mu.Unlock
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
@@ -0,0 +1,149 @@
+package swiss
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeMap(t *testing.T) {
+	t.Parallel()
+	for _, opts := range [][]Option{nil, {WithMetadata(), WithDeferredGrowth()}} {
+		m := NewSafeMap[int, int](0, opts...)
+		var wg sync.WaitGroup
+		for w := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					k := w*1000 + i
+					m.Put(k, k)
+					m.Get(k)
+					m.Modify(k, func(v *int) { *v = -*v })
+					if i%2 == 0 {
+						m.Delete(k)
+					}
+					if i%100 == 0 {
+						for range m.All() {
+						}
+						m.Len()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 4000, m.Len())
+		for k, v := range m.All() {
+			require.Equal(t, -k, v)
+			require.Equal(t, 1, k%2)
+		}
+		c := m.Clone()
+		require.Equal(t, 4000, c.Len())
+		require.NoError(t, m.TryPut(-1, 1))
+		m.Do(-1, func(v *int, exists bool) (int, bool, bool) {
+			return 0, false, exists
+		})
+		require.Equal(t, 4000, m.Len())
+		require.Equal(t, c.Hash(5), m.Hash(5))
+		require.NotZero(t, m.Version())
+		require.GreaterOrEqual(t, m.Cap(), m.Len())
+		m.Clear()
+		require.Zero(t, m.Len())
+	}
+}
+
+func TestSafeMapLen(t *testing.T) {
+	t.Parallel()
+	m := NewSafeMap[int, int](0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 10000 {
+			m.Put(i, i)
+		}
+	}()
+	prev := 0
+	for {
+		n := m.Len()
+		require.GreaterOrEqual(t, n, prev)
+		require.LessOrEqual(t, n, 10000)
+		prev = n
+		select {
+		case <-done:
+			require.Equal(t, 10000, m.Len())
+			m.Delete(0)
+			require.Equal(t, 9999, m.Len())
+			return
+		default:
+		}
+	}
+}
+
+func TestSafeMapBatch(t *testing.T) {
+	t.Parallel()
+	m := NewSafeMap[int, int](0)
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Batch(func(tx BatchView[int, int]) {
+				for i := range 1000 {
+					tx.Put(w*1000+i, i)
+				}
+				for i := 0; i < 1000; i += 2 {
+					tx.Delete(w*1000 + i)
+				}
+				v, ok := tx.Get(w*1000 + 1)
+				assert.True(t, ok)
+				assert.Equal(t, 1, v)
+			})
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 2000, m.Len())
+	m.Batch(func(tx BatchView[int, int]) {
+		require.Equal(t, 2000, tx.Len())
+		tx.Put(-1, -1)
+	})
+	require.Equal(t, 2001, m.Len())
+}
+
+func TestSafeMapGetOrCompute(t *testing.T) {
+	t.Parallel()
+	m := NewSafeMap[int, int](0)
+	var calls [4]atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k := w % 4
+			v := m.GetOrCompute(k, func() int {
+				calls[k].Add(1)
+				<-release
+				return k * 10
+			})
+			assert.Equal(t, k*10, v)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for k := range calls {
+		require.EqualValues(t, 1, calls[k].Load())
+	}
+	require.Equal(t, 4, m.Len())
+	require.Equal(t, 30, m.GetOrCompute(3, func() int { panic("cached") }))
+
+	// A panicking fn stores nothing and lets the next caller compute.
+	require.Panics(t, func() { m.GetOrCompute(5, func() int { panic("fail") }) })
+	_, ok := m.Get(5)
+	require.False(t, ok)
+	require.Equal(t, 50, m.GetOrCompute(5, func() int { return 50 }))
+}
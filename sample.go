@@ -0,0 +1,54 @@
+package swiss
+
+import "math/rand"
+
+// Sample returns up to n distinct entries of the map chosen uniformly at
+// random, in random order. Small samples are drawn by probing random slots
+// and skipping the empty ones, so their cost depends on n and the load
+// factor rather than on the size of the map. Samples of more than half of
+// the entries fall back to a single scan of the table. Fewer than n entries
+// are returned if the map holds fewer.
+func (m *Map[K, V]) Sample(n int) []Pair[K, V] {
+	size := m.Len()
+	n = min(n, size)
+	if n <= 0 {
+		return nil
+	}
+	if 2*n > size {
+		return m.sampleScan(n)
+	}
+	res := make([]Pair[K, V], 0, n)
+	seen := make(map[int]struct{}, n)
+	nslots := len(m.grps) * grpssz
+	for len(res) < n {
+		pos := rand.Intn(nslots)
+		ngrp, i := uint32(pos/grpssz), uint32(pos%grpssz)
+		if m.stale(ngrp) || m.grps[ngrp].maskFull()&(0x80<<(i*8)) == 0 {
+			continue
+		}
+		if _, ok := seen[pos]; ok {
+			continue
+		}
+		seen[pos] = struct{}{}
+		s := &m.grps[ngrp].slts[i]
+		res = append(res, Pair[K, V]{Key: s.key, Value: s.value})
+	}
+	return res
+}
+
+// sampleScan draws n entries by reservoir sampling over all full slots.
+func (m *Map[K, V]) sampleScan(n int) []Pair[K, V] {
+	res := make([]Pair[K, V], 0, n)
+	var seen int
+	m.scan(func(s *slot[K, V]) bool {
+		seen++
+		if len(res) < n {
+			res = append(res, Pair[K, V]{Key: s.key, Value: s.value})
+		} else if j := rand.Intn(seen); j < n {
+			res[j] = Pair[K, V]{Key: s.key, Value: s.value}
+		}
+		return true
+	})
+	rand.Shuffle(len(res), func(i, j int) { res[i], res[j] = res[j], res[i] })
+	return res
+}
@@ -0,0 +1,59 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	require.Empty(t, m.Sample(10))
+	for i := range 100 {
+		m.Put(i, -i)
+	}
+	for _, n := range []int{1, 10, 50, 51, 100, 1000} {
+		sample := m.Sample(n)
+		require.Len(t, sample, min(n, 100))
+		seen := make(map[int]bool)
+		for _, p := range sample {
+			require.Equal(t, -p.Key, p.Value)
+			require.False(t, seen[p.Key])
+			seen[p.Key] = true
+		}
+	}
+	require.Empty(t, m.Sample(0))
+
+	// Every key is drawn about equally often, by both strategies.
+	for _, n := range []int{10, 80} {
+		counts := make([]int, 100)
+		for range 2000 {
+			for _, p := range m.Sample(n) {
+				counts[p.Key]++
+			}
+		}
+		expected := 2000 * n / 100
+		for _, c := range counts {
+			require.InDelta(t, expected, c, float64(expected)/3)
+		}
+	}
+}
+
+func TestSampleLazyClear(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithLazyClear())
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	m.Clear()
+	for i := range 10 {
+		m.Put(-i, i)
+	}
+	sample := m.Sample(3)
+	require.Len(t, sample, 3)
+	for _, p := range sample {
+		require.Equal(t, -p.Key, p.Value)
+	}
+	require.Len(t, m.Sample(10), 10)
+}
@@ -0,0 +1,94 @@
+package swiss
+
+import (
+	"iter"
+	"slices"
+)
+
+// Set is a set of keys backed by a Map with empty values, so it shares the
+// layout and the options of the map.
+type Set[K comparable] struct {
+	m Map[K, struct{}]
+}
+
+// NewSet creates a new set with the specified initial size. It accepts the
+// same options as New.
+func NewSet[K comparable](size int, opts ...Option) *Set[K] {
+	return &Set[K]{m: *New[K, struct{}](size, opts...)}
+}
+
+// Add inserts the key into the set.
+func (s *Set[K]) Add(key K) {
+	s.m.Put(key, struct{}{})
+}
+
+// Has reports whether the key is in the set.
+func (s *Set[K]) Has(key K) bool {
+	_, ok := s.m.Get(key)
+	return ok
+}
+
+// Delete removes the key from the set.
+func (s *Set[K]) Delete(key K) {
+	s.m.Delete(key)
+}
+
+// Clear removes all keys from the set.
+func (s *Set[K]) Clear() {
+	s.m.Clear()
+}
+
+// Len returns the number of keys in the set.
+func (s *Set[K]) Len() int {
+	return s.m.Len()
+}
+
+// All returns an iterator over all keys of the set.
+func (s *Set[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Clone returns a copy of the set.
+func (s *Set[K]) Clone() *Set[K] {
+	return &Set[K]{m: *s.m.Clone()}
+}
+
+// KeySet returns the set of keys of the map. The set is built by copying the
+// groups of the map slot by slot, with the same seed and hash function, so
+// no key is hashed again. The set does not share memory with the map.
+func (m *Map[K, V]) KeySet() *Set[K] {
+	m.settle()
+	s := &Set[K]{m: Map[K, struct{}]{
+		grps:          make([]group[K, struct{}], len(m.grps)),
+		hashfn:        m.hashfn,
+		hkind:         m.hkind,
+		seed:          m.seed,
+		len:           m.len,
+		cap:           m.cap,
+		tombstones:    m.tombstones,
+		ngroups:       m.ngroups,
+		h1shift:       m.h1shift,
+		h2shift:       m.h2shift,
+		h2mask:        m.h2mask,
+		filter:        slices.Clone(m.filter),
+		deterministic: m.deterministic,
+		normalize:     m.normalize,
+	}}
+	if m.gens != nil {
+		s.m.gens = make([]uint32, len(m.grps))
+	}
+	for i := range m.grps {
+		src, dst := &m.grps[i], &s.m.grps[i]
+		dst.cntrl = src.cntrl
+		for j := range src.slts {
+			dst.slts[j].key = src.slts[j].key
+		}
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	s := NewSet[int](0)
+	for i := range 1000 {
+		s.Add(i)
+		s.Add(i)
+	}
+	for i := 0; i < 1000; i += 2 {
+		s.Delete(i)
+	}
+	require.Equal(t, 500, s.Len())
+	for i := range 1000 {
+		require.Equal(t, i%2 == 1, s.Has(i))
+	}
+	var cnt int
+	for k := range s.All() {
+		require.Equal(t, 1, k%2)
+		cnt++
+	}
+	require.Equal(t, 500, cnt)
+	c := s.Clone()
+	s.Clear()
+	require.Zero(t, s.Len())
+	require.Equal(t, 500, c.Len())
+}
+
+func TestKeySet(t *testing.T) {
+	t.Parallel()
+	m := New[string, int](0, WithFilter())
+	expected := make(map[string]bool)
+	for range 10_000 {
+		k := genRandomString(8)
+		m.Put(k, 1)
+		expected[k] = true
+		if randn.Intn(4) == 0 {
+			m.Delete(k)
+			delete(expected, k)
+		}
+	}
+	s := m.KeySet()
+	require.Equal(t, len(expected), s.Len())
+	for k := range expected {
+		require.True(t, s.Has(k))
+	}
+	for k := range s.All() {
+		require.True(t, expected[k])
+	}
+	require.False(t, s.Has("missing key"))
+	s.Add("new")
+	_, ok := m.Get("new")
+	require.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package swiss
+
+// Union returns a new map with the entries of both m and other. For keys
+// present in both maps the value is merge(a, b), where a comes from m and b
+// from other; a nil merge keeps the value of other. The larger map is
+// copied group by group, so only the entries of the smaller one are hashed.
+func (m *Map[K, V]) Union(other *Map[K, V], merge func(a, b V) V) *Map[K, V] {
+	if m.Len() >= other.Len() {
+		res := m.Clone()
+		for k, b := range other.All() {
+			if a, ok := res.Get(k); ok && merge != nil {
+				b = merge(a, b)
+			}
+			res.Put(k, b)
+		}
+		return res
+	}
+	res := other.Clone()
+	for k, a := range m.All() {
+		if b, ok := res.Get(k); ok {
+			if merge != nil {
+				res.Put(k, merge(a, b))
+			}
+			continue
+		}
+		res.Put(k, a)
+	}
+	return res
+}
+
+// Intersect returns a new map with the entries of m whose keys are also
+// present in other. Only the smaller map is scanned.
+func (m *Map[K, V]) Intersect(other *Map[K, V]) *Map[K, V] {
+	res := m.emptyLike(min(m.Len(), other.Len()))
+	if m.Len() <= other.Len() {
+		for k, v := range m.All() {
+			if _, ok := other.Get(k); ok {
+				res.Put(k, v)
+			}
+		}
+		return res
+	}
+	for k := range other.All() {
+		if v, ok := m.Get(k); ok {
+			res.Put(k, v)
+		}
+	}
+	return res
+}
+
+// Subtract returns a new map with the entries of m whose keys are not
+// present in other.
+func (m *Map[K, V]) Subtract(other *Map[K, V]) *Map[K, V] {
+	if other.Len() < m.Len()/2 {
+		res := m.Clone()
+		for k := range other.All() {
+			res.Delete(k)
+		}
+		return res
+	}
+	res := m.emptyLike(m.Len())
+	for k, v := range m.All() {
+		if _, ok := other.Get(k); !ok {
+			res.Put(k, v)
+		}
+	}
+	return res
+}
+
+// emptyLike returns an empty map with room for size entries that uses the
+// same hash function, seed and options as m.
+func (m *Map[K, V]) emptyLike(size int) *Map[K, V] {
+	ngroups := groupsnum(size)
+	c := *m
+	c.grps = make([]group[K, V], ngroups)
+	c.alloc, c.release = nil, nil
+	c.adviseHugePages()
+	c.ngroups = uint32(ngroups)
+	c.cap = grpload * ngroups
+	c.len, c.tombstones = 0, 0
+	c.iterators, c.growPending = 0, false
+	c.cp = nil
+	c.dirty = nil
+	c.obs = nil
+	if m.filter != nil {
+		c.filter, c.filterDeletes = newFilter(ngroups), 0
+	}
+	if m.gens != nil {
+		c.gens, c.gen = make([]uint32, ngroups), 0
+	}
+	if m.meta != nil {
+		c.meta = make([]slotMeta, ngroups*grpssz)
+	}
+	c.groups(func(g *group[K, V]) bool {
+		g.cntrl = emptyContol
+		return true
+	})
+	if m.sums != nil {
+		c.sums = &checksums{verifyReads: m.sums.verifyReads}
+		c.resetSums()
+	}
+	c.copyIndexes(m)
+	return &c
+}
+
+// SubsetOf reports whether every key of m is present in other with a value
+// equal according to eq. A nil eq only compares keys. It stops at the first
+// violation.
+func (m *Map[K, V]) SubsetOf(other *Map[K, V], eq func(a, b V) bool) bool {
+	if m.Len() > other.Len() {
+		return false
+	}
+	for k, a := range m.All() {
+		b, ok := other.Get(k)
+		if !ok || (eq != nil && !eq(a, b)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SupersetOf reports whether other is a subset of m, see SubsetOf.
+func (m *Map[K, V]) SupersetOf(other *Map[K, V], eq func(a, b V) bool) bool {
+	return other.SubsetOf(m, eq)
+}
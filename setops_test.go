@@ -0,0 +1,67 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOperations(t *testing.T) {
+	t.Parallel()
+	build := func(from, to int) *Map[int, int] {
+		m := New[int, int](0, WithFilter())
+		for i := from; i < to; i++ {
+			m.Put(i, i)
+		}
+		return m
+	}
+	sum := func(a, b int) int { return a + b }
+	check := func(m *Map[int, int], from, to int, value func(k int) int) {
+		t.Helper()
+		require.Equal(t, to-from, m.Len())
+		for i := from; i < to; i++ {
+			v, ok := m.Get(i)
+			require.True(t, ok)
+			require.Equal(t, value(i), v)
+		}
+	}
+	small, large := build(0, 100), build(50, 1000)
+	for _, pair := range [][2]*Map[int, int]{{small, large}, {large, small}} {
+		a, b := pair[0], pair[1]
+		check(a.Union(b, sum), 0, 1000, func(k int) int {
+			if k >= 50 && k < 100 {
+				return 2 * k
+			}
+			return k
+		})
+		check(a.Union(b, nil), 0, 1000, func(k int) int { return k })
+		check(a.Intersect(b), 50, 100, func(k int) int { return k })
+	}
+	check(small.Subtract(large), 0, 50, func(k int) int { return k })
+	check(large.Subtract(small), 100, 1000, func(k int) int { return k })
+	require.Equal(t, 100, small.Len())
+	require.Equal(t, 950, large.Len())
+	require.Zero(t, small.Subtract(small).Len())
+}
+
+func TestSubsetOf(t *testing.T) {
+	t.Parallel()
+	eq := func(a, b int) bool { return a == b }
+	small, large := New[int, int](0), New[int, int](0)
+	for i := range 1000 {
+		large.Put(i, i)
+		if i%3 == 0 {
+			small.Put(i, i)
+		}
+	}
+	require.True(t, small.SubsetOf(large, eq))
+	require.True(t, large.SupersetOf(small, eq))
+	require.False(t, large.SubsetOf(small, eq))
+	require.True(t, small.SubsetOf(small, eq))
+	small.Put(3, -3)
+	require.False(t, small.SubsetOf(large, eq))
+	require.True(t, small.SubsetOf(large, nil))
+	small.Put(5000, 0)
+	require.False(t, small.SubsetOf(large, nil))
+	require.True(t, New[int, int](0).SubsetOf(small, eq))
+}
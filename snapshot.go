@@ -0,0 +1,396 @@
+package swiss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
+)
+
+// Snapshots store the groups of a map as raw memory behind a small header,
+// so they can be opened without deserialization, straight from a go:embed
+// variable or a memory-mapped file. The layout is:
+//
+//	header     64 bytes, see snapshotHeader
+//	type names key and value type names separated by a zero byte
+//	padding    up to the next multiple of 64 bytes
+//	groups     the groups of the table, as laid out in memory
+//
+// Integers are written in the byte order of the platform and the groups use
+// the memory layout of the compiler, so snapshots can only be opened on the
+// same architecture they were saved on. Keys are placed with a stable hash
+// function, so snapshots can be opened by other processes.
+//
+// Snapshots are limited to key and value types without pointers. Keys must
+// also be hashable as plain memory, which excludes floating point numbers
+// and structs with padding.
+
+var (
+	// ErrUnsupportedType is returned when saving or opening a snapshot, or
+	// creating an off-heap map, for key or value types that are not
+	// supported.
+	ErrUnsupportedType = errors.New("swiss: unsupported type")
+	// ErrInvalidSnapshot is returned when a snapshot is malformed or was
+	// saved for different types or on a different architecture.
+	ErrInvalidSnapshot = errors.New("swiss: invalid snapshot")
+)
+
+const (
+	snapshotVersion = 1
+	snapshotEndian  = 0x01020304
+	snapshotAlign   = 64
+)
+
+var snapshotMagic = [8]byte{'S', 'W', 'I', 'S', 'S', 'R', 'O', 0}
+
+type snapshotHeader struct {
+	Magic     [8]byte
+	Version   uint32
+	Endian    uint32
+	KeySize   uint32
+	ValueSize uint32
+	GroupSize uint32
+	NamesLen  uint32
+	Groups    uint64
+	Len       uint64
+	Seed      uint64
+	DataOff   uint64
+}
+
+// ReadOnlyTable is an immutable map backed by a snapshot. It is safe for
+// concurrent use and must not outlive the data it has been opened from.
+type ReadOnlyTable[K comparable, V any] struct {
+	m     Map[K, V]
+	close func() error
+}
+
+// Save writes the map to w in the snapshot format. It returns
+// ErrUnsupportedType if the key or value type cannot be stored in a
+// snapshot.
+func (m *Map[K, V]) Save(w io.Writer) error {
+	hashfn, err := snapshotHashFunc[K, V]()
+	if err != nil {
+		return err
+	}
+	t := newMap[K, V](m.Len(), hashfn, defaultOptions())
+	for k, v := range m.All() {
+		t.Put(k, v)
+	}
+	return t.writeSnapshot(w)
+}
+
+// Load reads a snapshot written by Save into a new map.
+func Load[K comparable, V any](r io.Reader) (*Map[K, V], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t, err := OpenReadOnly[K, V](data)
+	if err != nil {
+		return nil, err
+	}
+	m := New[K, V](t.Len())
+	for k, v := range t.All() {
+		m.Put(k, v)
+	}
+	return m, nil
+}
+
+// OpenReadOnly opens a snapshot written by Save without copying it, unless
+// data is not suitably aligned. The returned table references data, which
+// must not be modified afterwards.
+func OpenReadOnly[K comparable, V any](data []byte) (*ReadOnlyTable[K, V], error) {
+	hashfn, err := snapshotHashFunc[K, V]()
+	if err != nil {
+		return nil, err
+	}
+	var h snapshotHeader
+	if _, err := binary.Decode(data, binary.NativeEndian, &h); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if err := h.validate(data, snapshotNames[K, V]()); err != nil {
+		return nil, err
+	}
+	var g group[K, V]
+	var k K
+	var v V
+	if uintptr(h.KeySize) != unsafe.Sizeof(k) || uintptr(h.ValueSize) != unsafe.Sizeof(v) ||
+		uintptr(h.GroupSize) != unsafe.Sizeof(g) {
+		return nil, fmt.Errorf("%w: type sizes do not match", ErrInvalidSnapshot)
+	}
+	raw := data[h.DataOff : h.DataOff+h.Groups*uint64(h.GroupSize)]
+	var grps []group[K, V]
+	if uintptr(unsafe.Pointer(unsafe.SliceData(raw)))%unsafe.Alignof(g) == 0 {
+		grps = unsafe.Slice((*group[K, V])(unsafe.Pointer(unsafe.SliceData(raw))), h.Groups)
+	} else {
+		grps = make([]group[K, V], h.Groups)
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(grps))), len(raw)), raw)
+	}
+	if err := validateControls(grps, int(h.Len)); err != nil {
+		return nil, err
+	}
+	t := &ReadOnlyTable[K, V]{m: Map[K, V]{
+		grps:    grps,
+		hashfn:  hashfn,
+		seed:    uintptr(h.Seed),
+		len:     int(h.Len),
+		cap:     int(h.Groups) * grpload,
+		ngroups: uint32(h.Groups),
+	}}
+	t.m.setSplit(AbseilSplit)
+	return t, nil
+}
+
+// OpenReadOnlyFile opens a snapshot file written by Save by mapping it into
+// memory read-only. The pages are shared through the page cache, so any
+// number of processes can open the same file while paying for its memory
+// only once. The table must be closed with Close, after which it must not be
+// used. On platforms without mmap the file is read into memory.
+func OpenReadOnlyFile[K comparable, V any](path string) (*ReadOnlyTable[K, V], error) {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := OpenReadOnly[K, V](data)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	t.close = release
+	return t, nil
+}
+
+// Close releases the file mapping of a table opened with OpenReadOnlyFile.
+// It is a no-op for tables opened with OpenReadOnly.
+func (t *ReadOnlyTable[K, V]) Close() error {
+	if t.close == nil {
+		return nil
+	}
+	err := t.close()
+	t.close = nil
+	return err
+}
+
+// Get retrieves the value associated with the key.
+func (t *ReadOnlyTable[K, V]) Get(key K) (V, bool) {
+	return t.m.Get(key)
+}
+
+// Len returns the number of entries in the table.
+func (t *ReadOnlyTable[K, V]) Len() int {
+	return t.m.Len()
+}
+
+// All returns an iterator over all entries of the table.
+func (t *ReadOnlyTable[K, V]) All() iter.Seq2[K, V] {
+	return t.m.All()
+}
+
+// SnapshotInfo describes a snapshot without knowledge of its key and value
+// types, for tools inspecting snapshot files.
+type SnapshotInfo struct {
+	// KeyType and ValueType are the type names recorded by Save.
+	KeyType, ValueType string
+	// KeySize, ValueSize and GroupSize are the sizes in bytes of a key, a
+	// value and a group on the platform the snapshot was saved on.
+	KeySize, ValueSize, GroupSize int
+	Groups                        int
+	Len                           int
+	Tombstones                    int
+	Seed                          uint64
+	grps                          []byte
+}
+
+// InspectSnapshot parses the header of a snapshot written by Save and counts
+// its tombstones. Unlike OpenReadOnly it accepts any key and value types; the
+// entries are only available as raw memory, see Entries.
+func InspectSnapshot(data []byte) (*SnapshotInfo, error) {
+	var h snapshotHeader
+	if _, err := binary.Decode(data, binary.NativeEndian, &h); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	hsize := uint64(binary.Size(snapshotHeader{}))
+	if hsize+uint64(h.NamesLen) > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: truncated header", ErrInvalidSnapshot)
+	}
+	names := string(data[hsize : hsize+uint64(h.NamesLen)])
+	if err := h.validate(data, names); err != nil {
+		return nil, err
+	}
+	ktype, vtype, ok := strings.Cut(names, "\x00")
+	if !ok || uint64(h.GroupSize) < 8+grpssz*(uint64(h.KeySize)+uint64(h.ValueSize)) {
+		return nil, fmt.Errorf("%w: inconsistent type sizes", ErrInvalidSnapshot)
+	}
+	s := &SnapshotInfo{
+		KeyType:   ktype,
+		ValueType: vtype,
+		KeySize:   int(h.KeySize),
+		ValueSize: int(h.ValueSize),
+		GroupSize: int(h.GroupSize),
+		Groups:    int(h.Groups),
+		Len:       int(h.Len),
+		Seed:      h.Seed,
+		grps:      data[h.DataOff : h.DataOff+h.Groups*uint64(h.GroupSize)],
+	}
+	for i := range s.Groups {
+		cntrl := binary.NativeEndian.Uint64(s.grps[i*s.GroupSize:])
+		for j := range grpssz {
+			if byte(cntrl>>(8*j)) == kDeleted {
+				s.Tombstones++
+			}
+		}
+	}
+	return s, nil
+}
+
+// Cap returns the number of entries the snapshot has room for.
+func (s *SnapshotInfo) Cap() int {
+	return s.Groups * grpload
+}
+
+// LoadFactor returns the ratio of entries to slots of the snapshot.
+func (s *SnapshotInfo) LoadFactor() float64 {
+	return float64(s.Len) / float64(s.Groups*grpssz)
+}
+
+// Entries returns an iterator over the raw memory of the entries of the
+// snapshot in storage order. The key holds KeySize bytes. The value is
+// everything following the key in its slot: the ValueSize bytes of the
+// value, surrounded by whatever padding the compiler inserted to align it.
+// The slices alias the snapshot data.
+func (s *SnapshotInfo) Entries() iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		slotSize := (s.GroupSize - 8) / grpssz
+		for i := range s.Groups {
+			g := s.grps[i*s.GroupSize : (i+1)*s.GroupSize]
+			cntrl := binary.NativeEndian.Uint64(g)
+			for j := range grpssz {
+				if byte(cntrl>>(8*j))&kEmpty != 0 {
+					continue
+				}
+				slot := g[8+j*slotSize : 8+(j+1)*slotSize]
+				if !yield(slot[:s.KeySize:s.KeySize], slot[s.KeySize:]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (m *Map[K, V]) writeSnapshot(w io.Writer) error {
+	var g group[K, V]
+	var k K
+	var v V
+	names := snapshotNames[K, V]()
+	h := snapshotHeader{
+		Magic:     snapshotMagic,
+		Version:   snapshotVersion,
+		Endian:    snapshotEndian,
+		KeySize:   uint32(unsafe.Sizeof(k)),
+		ValueSize: uint32(unsafe.Sizeof(v)),
+		GroupSize: uint32(unsafe.Sizeof(g)),
+		NamesLen:  uint32(len(names)),
+		Groups:    uint64(len(m.grps)),
+		Len:       uint64(m.Len()),
+		Seed:      uint64(m.seed),
+		DataOff:   alignUp(uint64(binary.Size(snapshotHeader{})+len(names)), snapshotAlign),
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, h.DataOff))
+	if err := binary.Write(buf, binary.NativeEndian, &h); err != nil {
+		return err
+	}
+	buf.WriteString(names)
+	buf.Write(make([]byte, h.DataOff-uint64(buf.Len())))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(m.grps))), len(m.grps)*int(h.GroupSize))
+	_, err := w.Write(raw)
+	return err
+}
+
+func (h *snapshotHeader) validate(data []byte, names string) error {
+	switch {
+	case h.Magic != snapshotMagic:
+		return fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	case h.Version != snapshotVersion:
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, h.Version)
+	case h.Endian != snapshotEndian:
+		return fmt.Errorf("%w: byte order mismatch", ErrInvalidSnapshot)
+	}
+	hsize := uint64(binary.Size(snapshotHeader{}))
+	if hsize+uint64(h.NamesLen) > uint64(len(data)) || h.DataOff < hsize+uint64(h.NamesLen) {
+		return fmt.Errorf("%w: truncated header", ErrInvalidSnapshot)
+	}
+	if stored := string(data[hsize : hsize+uint64(h.NamesLen)]); stored != names {
+		return fmt.Errorf("%w: snapshot types %q do not match %q", ErrInvalidSnapshot, stored, names)
+	}
+	// Bound Groups by the data after DataOff, which cannot overflow, rather
+	// than computing the end of the data.
+	if h.GroupSize == 0 || h.DataOff > uint64(len(data)) || h.Groups == 0 ||
+		h.Groups > (uint64(len(data))-h.DataOff)/uint64(h.GroupSize) {
+		return fmt.Errorf("%w: truncated data", ErrInvalidSnapshot)
+	}
+	if h.Len > h.Groups*grpload {
+		return fmt.Errorf("%w: length %d exceeds capacity", ErrInvalidSnapshot, h.Len)
+	}
+	return nil
+}
+
+// validateControls checks that every control byte of the groups is empty,
+// deleted or a 7-bit h2, that they hold n entries and that at least one slot
+// is empty, since lookups only stop at an empty slot.
+func validateControls[K comparable, V any](grps []group[K, V], n int) error {
+	var full int
+	var empty bool
+	for i := range grps {
+		cntrl := grps[i].cntrl
+		for j := range grpssz {
+			switch b := byte(cntrl >> (8 * j)); {
+			case b == kEmpty:
+				empty = true
+			case b == kDeleted:
+			case b&kEmpty == 0:
+				full++
+			default:
+				return fmt.Errorf("%w: bad control byte %#x", ErrInvalidSnapshot, b)
+			}
+		}
+	}
+	switch {
+	case full != n:
+		return fmt.Errorf("%w: %d entries instead of %d", ErrInvalidSnapshot, full, n)
+	case !empty:
+		return fmt.Errorf("%w: no empty slot", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+func snapshotHashFunc[K comparable, V any]() (hash.HFunc, error) {
+	if !pointerFree(reflect.TypeFor[K]()) {
+		return nil, fmt.Errorf("%w: key type %v contains pointers", ErrUnsupportedType, reflect.TypeFor[K]())
+	}
+	if !pointerFree(reflect.TypeFor[V]()) {
+		return nil, fmt.Errorf("%w: value type %v contains pointers", ErrUnsupportedType, reflect.TypeFor[V]())
+	}
+	hashfn := hash.GetHashFuncStable[K]()
+	if hashfn == nil {
+		return nil, fmt.Errorf("%w: key type %v cannot be hashed as memory", ErrUnsupportedType, reflect.TypeFor[K]())
+	}
+	return hashfn, nil
+}
+
+func snapshotNames[K comparable, V any]() string {
+	return reflect.TypeFor[K]().String() + "\x00" + reflect.TypeFor[V]().String()
+}
+
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) &^ (align - 1)
+}
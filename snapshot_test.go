@@ -0,0 +1,271 @@
+package swiss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/crn4/swiss/hash"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotKey struct {
+	A uint64
+	B [4]uint16
+}
+
+type snapshotValue struct {
+	F float64
+	S [3]byte
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	t.Parallel()
+	for _, size := range []int{0, 1, 100, 100_000} {
+		m := New[snapshotKey, snapshotValue](0)
+		for i, key := range genIntKeys(size) {
+			m.Put(snapshotKey{A: uint64(key), B: [4]uint16{uint16(i)}}, snapshotValue{F: float64(i), S: [3]byte{byte(i)}})
+		}
+		var buf bytes.Buffer
+		require.NoError(t, m.Save(&buf))
+		require.Zero(t, buf.Len()%grpssz)
+		loaded, err := Load[snapshotKey, snapshotValue](bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, m.Len(), loaded.Len())
+		for k, v := range m.All() {
+			value, ok := loaded.Get(k)
+			require.True(t, ok)
+			require.Equal(t, v, value)
+		}
+	}
+}
+
+func TestSnapshotOpenReadOnly(t *testing.T) {
+	t.Parallel()
+	size := 10_000
+	m := New[uint32, int64](size)
+	for i := range size {
+		m.Put(uint32(i*7), int64(i))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, m.Save(&buf))
+	data := buf.Bytes()
+	misaligned := make([]byte, len(data)+1)[1:]
+	copy(misaligned, data)
+	for _, data := range [][]byte{data, misaligned} {
+		table, err := OpenReadOnly[uint32, int64](data)
+		require.NoError(t, err)
+		require.Equal(t, size, table.Len())
+		for i := range size {
+			value, ok := table.Get(uint32(i * 7))
+			require.True(t, ok)
+			require.Equal(t, int64(i), value)
+			_, ok = table.Get(uint32(i*7 + 1))
+			require.False(t, ok)
+		}
+		var cnt int
+		for k, v := range table.All() {
+			require.Equal(t, uint32(v*7), k)
+			cnt++
+		}
+		require.Equal(t, size, cnt)
+	}
+}
+
+func TestSnapshotOpenReadOnlyFile(t *testing.T) {
+	t.Parallel()
+	m := New[uint64, [2]uint32](0)
+	for i := range 10_000 {
+		m.Put(uint64(i), [2]uint32{uint32(i), 1})
+	}
+	path := filepath.Join(t.TempDir(), "table.swiss")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, m.Save(f))
+	require.NoError(t, f.Close())
+
+	tables := make([]*ReadOnlyTable[uint64, [2]uint32], 2)
+	for i := range tables {
+		tables[i], err = OpenReadOnlyFile[uint64, [2]uint32](path)
+		require.NoError(t, err)
+	}
+	for _, table := range tables {
+		require.Equal(t, m.Len(), table.Len())
+		for k, v := range m.All() {
+			value, ok := table.Get(k)
+			require.True(t, ok)
+			require.Equal(t, v, value)
+		}
+		require.NoError(t, table.Close())
+		require.NoError(t, table.Close())
+	}
+
+	_, err = OpenReadOnlyFile[uint64, [2]uint32](filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = OpenReadOnlyFile[uint32, [2]uint32](path)
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+	empty := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	_, err = OpenReadOnlyFile[uint64, [2]uint32](empty)
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	// A file whose control bytes were damaged is rejected.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	info, err := InspectSnapshot(data)
+	require.NoError(t, err)
+	off := len(data) - len(info.grps)
+	data[off] = 0xAA
+	damaged := filepath.Join(t.TempDir(), "damaged")
+	require.NoError(t, os.WriteFile(damaged, data, 0o600))
+	_, err = OpenReadOnlyFile[uint64, [2]uint32](damaged)
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	assert.ErrorIs(t, New[string, int](0).Save(&buf), ErrUnsupportedType)
+	assert.ErrorIs(t, New[int, *int](0).Save(&buf), ErrUnsupportedType)
+	assert.ErrorIs(t, New[float64, int](0).Save(&buf), ErrUnsupportedType)
+	assert.ErrorIs(t, New[*int, int](0).Save(&buf), ErrUnsupportedType)
+	assert.ErrorIs(t, New[struct {
+		a uint8
+		b uint64
+	}, int](0).Save(&buf), ErrUnsupportedType)
+	assert.Zero(t, buf.Len())
+
+	m := New[int, int](0)
+	m.Put(1, 1)
+	require.NoError(t, m.Save(&buf))
+	data := buf.Bytes()
+	_, err := OpenReadOnly[int, int](data)
+	require.NoError(t, err)
+	_, err = OpenReadOnly[int, uint](data)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = OpenReadOnly[uint, int](data)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = OpenReadOnly[int, int](data[:len(data)-1])
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = OpenReadOnly[int, int](data[:10])
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	corrupted := bytes.Clone(data)
+	corrupted[0] = 'X'
+	_, err = OpenReadOnly[int, int](corrupted)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	// Control bytes must be consistent with the length and leave an empty
+	// slot for lookups to stop at.
+	var h snapshotHeader
+	_, err = binary.Decode(data, binary.NativeEndian, &h)
+	require.NoError(t, err)
+	controls := func(set func(b byte) byte) []byte {
+		corrupted := bytes.Clone(data)
+		for i := range h.Groups {
+			off := h.DataOff + i*uint64(h.GroupSize)
+			for j := off; j < off+grpssz; j++ {
+				corrupted[j] = set(corrupted[j])
+			}
+		}
+		return corrupted
+	}
+	for _, set := range []func(b byte) byte{
+		func(b byte) byte { return b | 0x90 },
+		func(byte) byte { return 0x01 },
+	} {
+		_, err = OpenReadOnly[int, int](controls(set))
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	}
+	_, err = OpenReadOnly[int, int](controls(func(b byte) byte {
+		if b == kEmpty {
+			return kDeleted
+		}
+		return b
+	}))
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+func TestStableHashIsFixed(t *testing.T) {
+	t.Parallel()
+	if bits.UintSize != 64 || binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("golden value is computed for 64-bit little-endian platforms")
+	}
+	hashfn := hash.GetHashFuncStable[[3]uint32]()
+	key := [3]uint32{1, 2, 3}
+	require.Equal(t, uint64(0xef6d6a5da0b236a9), uint64(hashfn(unsafe.Pointer(&key), 42)))
+}
+
+func TestInspectSnapshot(t *testing.T) {
+	t.Parallel()
+	m := New[uint32, snapshotValue](0)
+	for i := range 100 {
+		m.Put(uint32(i), snapshotValue{S: [3]byte{byte(i)}})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, m.Save(&buf))
+	info, err := InspectSnapshot(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "uint32", info.KeyType)
+	assert.Equal(t, "swiss.snapshotValue", info.ValueType)
+	assert.Equal(t, 4, info.KeySize)
+	assert.Equal(t, int(unsafe.Sizeof(snapshotValue{})), info.ValueSize)
+	assert.Equal(t, int(unsafe.Sizeof(group[uint32, snapshotValue]{})), info.GroupSize)
+	assert.Equal(t, 100, info.Len)
+	assert.Zero(t, info.Tombstones)
+	assert.Equal(t, info.Groups*grpload, info.Cap())
+	assert.InDelta(t, 100/float64(info.Groups*grpssz), info.LoadFactor(), 1e-9)
+
+	seen := make(map[uint32]bool)
+	off := unsafe.Offsetof(slot[uint32, snapshotValue]{}.value) - 4
+	for k, v := range info.Entries() {
+		key := binary.NativeEndian.Uint32(k)
+		assert.Equal(t, byte(key), v[off+unsafe.Offsetof(snapshotValue{}.S)])
+		seen[key] = true
+	}
+	assert.Len(t, seen, 100)
+
+	_, err = InspectSnapshot(buf.Bytes()[:100])
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = InspectSnapshot(buf.Bytes()[:10])
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+func TestSnapshotCorruptHeader(t *testing.T) {
+	t.Parallel()
+	m := New[uint64, uint64](0)
+	for i := range uint64(100) {
+		m.Put(i, i)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, m.Save(&buf))
+	var h snapshotHeader
+	_, err := binary.Decode(buf.Bytes(), binary.NativeEndian, &h)
+	require.NoError(t, err)
+
+	for _, corrupt := range []func(h *snapshotHeader){
+		// DataOff+Groups*GroupSize wraps around to a small value.
+		func(h *snapshotHeader) { h.DataOff = -h.Groups * uint64(h.GroupSize) },
+		func(h *snapshotHeader) { h.DataOff = math.MaxUint64 },
+		func(h *snapshotHeader) { h.GroupSize = 0 },
+		func(h *snapshotHeader) { h.Groups = math.MaxUint64 / uint64(h.GroupSize) },
+		// KeySize+ValueSize wraps around in 32 bits.
+		func(h *snapshotHeader) { h.KeySize, h.ValueSize = math.MaxUint32, 1 },
+	} {
+		data := bytes.Clone(buf.Bytes())
+		c := h
+		corrupt(&c)
+		_, err := binary.Encode(data, binary.NativeEndian, &c)
+		require.NoError(t, err)
+		_, err = OpenReadOnly[uint64, uint64](data)
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+		_, err = InspectSnapshot(data)
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	}
+}
@@ -0,0 +1,30 @@
+package swiss
+
+// CacheStats holds the counters kept by the caches of this package since
+// they were created or their statistics were last reset.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions counts entries removed to make room for others.
+	Evictions uint64
+	// Expirations counts entries removed because they expired.
+	Expirations uint64
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 if there
+// were none.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// lookup counts a hit or a miss.
+func (s *CacheStats) lookup(hit bool) {
+	if hit {
+		s.Hits++
+	} else {
+		s.Misses++
+	}
+}
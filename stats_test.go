@@ -0,0 +1,121 @@
+package swiss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// admitLFU puts the key until it is admitted, since repeated puts raise its
+// frequency, and fails the test if that takes implausibly long.
+func admitLFU(t *testing.T, lfu *LFU[int, int], k, v int) {
+	t.Helper()
+	for range 256 {
+		if lfu.Put(k, v) {
+			return
+		}
+	}
+	t.Fatalf("key %d never admitted", k)
+}
+
+func TestCacheStats(t *testing.T) {
+	t.Parallel()
+	fifo := NewFIFO[int, int](2, nil)
+	lfu := NewLFU[int, int](2, nil)
+	cost := NewCostCache[int, int](2, func(int, int) int64 { return 1 }, nil)
+	type cache interface {
+		Get(int) (int, bool)
+		Stats() CacheStats
+		ResetStats()
+	}
+	for _, c := range []struct {
+		cache
+		put func(k, v int)
+	}{
+		{fifo, fifo.Put},
+		{lfu, func(k, v int) { admitLFU(t, lfu, k, v) }},
+		{cost, func(k, v int) { cost.Put(k, v) }},
+	} {
+		c.put(1, 1)
+		c.Get(1)
+		c.Get(1)
+		c.Get(2)
+		c.put(2, 2)
+		c.Get(2)
+		c.Get(2)
+		c.put(3, 3)
+		require.Equal(t, CacheStats{Hits: 4, Misses: 1, Evictions: 1}, c.Stats())
+		require.InDelta(t, 0.8, c.Stats().HitRatio(), 1e-9)
+		c.ResetStats()
+		require.Zero(t, c.Stats())
+		require.Zero(t, c.Stats().HitRatio())
+	}
+
+	m, clock := newTestTTLMap[int, int](0, time.Minute)
+	for i := range 4 {
+		m.Put(i, i)
+	}
+	m.Get(0)
+	m.Get(5)
+	clock.Advance(time.Minute)
+	m.Get(0)
+	m.Sweep(10)
+	require.Equal(t, CacheStats{Hits: 1, Misses: 2, Expirations: 4}, m.Stats())
+	m.ResetStats()
+	require.Zero(t, m.Stats())
+}
+
+func TestCachePeek(t *testing.T) {
+	t.Parallel()
+	cost := NewCostCache[int, int](2, func(int, int) int64 { return 1 }, nil)
+	cost.Put(1, 1)
+	cost.Put(2, 2)
+	v, ok := cost.Peek(1)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	cost.Put(3, 3)
+	// Peek did not make 1 recently used.
+	_, ok = cost.Peek(1)
+	require.False(t, ok)
+	require.Zero(t, cost.Stats().Hits+cost.Stats().Misses)
+
+	lfu := NewLFU[int, int](64, nil)
+	for i := range 64 {
+		lfu.Put(i, i)
+		if i > 0 {
+			lfu.Get(i)
+		}
+	}
+	for range 10 {
+		lfu.Peek(0)
+	}
+	admitLFU(t, lfu, 100, 100)
+	_, ok = lfu.Peek(0)
+	require.False(t, ok)
+	v, ok = lfu.Peek(100)
+	require.True(t, ok)
+	require.Equal(t, 100, v)
+
+	fifo := NewFIFO[int, int](1, nil)
+	fifo.Put(1, 1)
+	v, ok = fifo.Peek(1)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.Zero(t, fifo.Stats())
+
+	m, clock := newTestTTLMap[int, int](0, time.Minute)
+	m.StaleWhileRevalidate(time.Minute, func(int) (int, error) {
+		t.Fatal("Peek must not revalidate")
+		return 0, nil
+	})
+	m.Put(1, 1)
+	v, ok = m.Peek(1)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	clock.Advance(time.Minute)
+	_, ok = m.Peek(1)
+	require.False(t, ok)
+	require.Equal(t, 1, m.Len())
+	require.Zero(t, m.Stats())
+}
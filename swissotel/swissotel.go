@@ -0,0 +1,104 @@
+// Package swissotel reports metrics of swiss maps and caches through
+// OpenTelemetry instruments: the number of entries, the load factor, the
+// duration of rehashes and the hit ratio of caches. It lives in its own
+// module so that the swiss package does not depend on OpenTelemetry.
+package swissotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/crn4/swiss"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName is the instrumentation scope of the meter used by Metrics.
+const ScopeName = "github.com/crn4/swiss/swissotel"
+
+// Table is implemented by the maps of the swiss package that report their
+// length and capacity, such as *swiss.Map and *swiss.SafeMap.
+type Table interface {
+	Len() int
+	Cap() int
+}
+
+// Cache is implemented by the caches of the swiss package.
+type Cache interface {
+	Stats() swiss.CacheStats
+}
+
+// Metrics holds the instruments shared by all observed maps and caches,
+// which are told apart by the swiss.name attribute.
+type Metrics struct {
+	meter    metric.Meter
+	entries  metric.Int64ObservableGauge
+	load     metric.Float64ObservableGauge
+	rehash   metric.Float64Histogram
+	hitRatio metric.Float64ObservableGauge
+}
+
+// New creates the instruments with a meter of the provider.
+func New(provider metric.MeterProvider) (*Metrics, error) {
+	meter := provider.Meter(ScopeName)
+	x := &Metrics{meter: meter}
+	var err error
+	if x.entries, err = meter.Int64ObservableGauge("swiss.entries",
+		metric.WithDescription("Number of entries in the map."),
+		metric.WithUnit("{entry}")); err != nil {
+		return nil, err
+	}
+	if x.load, err = meter.Float64ObservableGauge("swiss.load_factor",
+		metric.WithDescription("Number of entries divided by the capacity of the map."),
+		metric.WithUnit("1")); err != nil {
+		return nil, err
+	}
+	if x.rehash, err = meter.Float64Histogram("swiss.rehash.duration",
+		metric.WithDescription("Duration of rehashes of the map."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if x.hitRatio, err = meter.Float64ObservableGauge("swiss.cache.hit_ratio",
+		metric.WithDescription("Fraction of cache lookups that were hits."),
+		metric.WithUnit("1")); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// ObserveMap reports the number of entries and the load factor of t under
+// the given name until the registration is unregistered. Observations are
+// made from the goroutine collecting the metrics, so t must be safe for
+// concurrent use, like swiss.SafeMap, unless the caller serializes
+// collections with its own use of the map.
+func (x *Metrics) ObserveMap(name string, t Table) (metric.Registration, error) {
+	attrs := metric.WithAttributes(attribute.String("swiss.name", name))
+	return x.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		n, c := t.Len(), t.Cap()
+		o.ObserveInt64(x.entries, int64(n), attrs)
+		if c > 0 {
+			o.ObserveFloat64(x.load, float64(n)/float64(c), attrs)
+		}
+		return nil
+	}, x.entries, x.load)
+}
+
+// ObserveCache reports the hit ratio of c under the given name until the
+// registration is unregistered. Like ObserveMap, it reads c from the
+// collecting goroutine.
+func (x *Metrics) ObserveCache(name string, c Cache) (metric.Registration, error) {
+	attrs := metric.WithAttributes(attribute.String("swiss.name", name))
+	return x.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveFloat64(x.hitRatio, c.Stats().HitRatio(), attrs)
+		return nil
+	}, x.hitRatio)
+}
+
+// GrowCallback returns a callback for swiss.WithGrowCallback recording the
+// duration of every rehash of the map under the given name.
+func (x *Metrics) GrowCallback(name string) func(oldCap, newCap int, dur time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("swiss.name", name))
+	return func(_, _ int, dur time.Duration) {
+		x.rehash.Record(context.Background(), dur.Seconds(), attrs)
+	}
+}
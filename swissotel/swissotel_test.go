@@ -0,0 +1,63 @@
+package swissotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crn4/swiss"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	res := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			res[m.Name] = m.Data
+		}
+	}
+	return res
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	reader := sdkmetric.NewManualReader()
+	x, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	m := swiss.NewSafeMap[int, int](0, swiss.WithGrowCallback(x.GrowCallback("users")))
+	for i := range 100 {
+		m.Put(i, i)
+	}
+	reg, err := x.ObserveMap("users", m)
+	require.NoError(t, err)
+	c := swiss.NewFIFO[int, int](10, nil)
+	c.Put(1, 1)
+	c.Get(1)
+	c.Get(2)
+	creg, err := x.ObserveCache("sessions", c)
+	require.NoError(t, err)
+
+	data := collect(t, reader)
+	entries := data["swiss.entries"].(metricdata.Gauge[int64]).DataPoints
+	require.Len(t, entries, 1)
+	require.EqualValues(t, 100, entries[0].Value)
+	name, _ := entries[0].Attributes.Value("swiss.name")
+	require.Equal(t, "users", name.AsString())
+	load := data["swiss.load_factor"].(metricdata.Gauge[float64]).DataPoints
+	require.InDelta(t, 100/float64(m.Cap()), load[0].Value, 1e-9)
+	rehash := data["swiss.rehash.duration"].(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, rehash, 1)
+	require.NotZero(t, rehash[0].Count)
+	ratio := data["swiss.cache.hit_ratio"].(metricdata.Gauge[float64]).DataPoints
+	require.InDelta(t, 0.5, ratio[0].Value, 1e-9)
+
+	require.NoError(t, reg.Unregister())
+	require.NoError(t, creg.Unregister())
+	data = collect(t, reader)
+	require.NotContains(t, data, "swiss.entries")
+	require.NotContains(t, data, "swiss.cache.hit_ratio")
+}
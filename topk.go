@@ -0,0 +1,137 @@
+package swiss
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// TopK tracks the k most frequent keys of a stream. Every key offered is
+// counted exactly in a swiss map, while a min-heap of size k keeps the
+// current heavy hitters, so Offer costs one map update plus O(log k) heap
+// work. TopK is not safe for concurrent use.
+type TopK[K comparable] struct {
+	counts *Map[K, topkEntry]
+	heap   topkHeap[K]
+	k      int
+}
+
+type topkEntry struct {
+	count uint64
+	pos   int // position in the heap, -1 if the key is not in it
+}
+
+// NewTopK creates a TopK tracking the k most frequent keys. A non-positive k
+// is treated as 1.
+func NewTopK[K comparable](k int) *TopK[K] {
+	k = max(k, 1)
+	t := &TopK[K]{
+		counts: New[K, topkEntry](0),
+		k:      k,
+	}
+	t.heap = topkHeap[K]{
+		items:  make([]Pair[K, uint64], 0, k),
+		counts: t.counts,
+	}
+	return t
+}
+
+// Offer counts one occurrence of the key.
+func (t *TopK[K]) Offer(key K) {
+	t.OfferN(key, 1)
+}
+
+// OfferN counts n occurrences of the key.
+func (t *TopK[K]) OfferN(key K, n uint64) {
+	e, ok := t.counts.Get(key)
+	if !ok {
+		e.pos = -1
+	}
+	e.count += n
+	switch {
+	case e.pos >= 0:
+		t.heap.items[e.pos].Value = e.count
+		t.counts.Put(key, e)
+		heap.Fix(&t.heap, e.pos)
+	case len(t.heap.items) < t.k:
+		t.counts.Put(key, e)
+		heap.Push(&t.heap, Pair[K, uint64]{Key: key, Value: e.count})
+	case e.count > t.heap.items[0].Value:
+		evicted := t.heap.items[0].Key
+		t.heap.update(evicted, -1)
+		e.pos = 0
+		t.counts.Put(key, e)
+		t.heap.items[0] = Pair[K, uint64]{Key: key, Value: e.count}
+		heap.Fix(&t.heap, 0)
+	default:
+		t.counts.Put(key, e)
+	}
+}
+
+// Count returns how many times the key has been offered.
+func (t *TopK[K]) Count(key K) uint64 {
+	e, _ := t.counts.Get(key)
+	return e.count
+}
+
+// Result returns the current top keys with their counts, most frequent
+// first. Keys with equal counts are returned in no particular order.
+func (t *TopK[K]) Result() []Pair[K, uint64] {
+	res := slices.Clone(t.heap.items)
+	slices.SortFunc(res, func(a, b Pair[K, uint64]) int {
+		switch {
+		case a.Value > b.Value:
+			return -1
+		case a.Value < b.Value:
+			return 1
+		}
+		return 0
+	})
+	return res
+}
+
+// Len returns the number of distinct keys offered so far.
+func (t *TopK[K]) Len() int {
+	return t.counts.Len()
+}
+
+// Reset forgets all counted keys.
+func (t *TopK[K]) Reset() {
+	t.counts.Clear()
+	t.heap.items = t.heap.items[:0]
+}
+
+// topkHeap is a min-heap on counts that keeps the heap positions stored in
+// the counts map up to date.
+type topkHeap[K comparable] struct {
+	items  []Pair[K, uint64]
+	counts *Map[K, topkEntry]
+}
+
+func (h *topkHeap[K]) Len() int { return len(h.items) }
+
+func (h *topkHeap[K]) Less(i, j int) bool { return h.items[i].Value < h.items[j].Value }
+
+func (h *topkHeap[K]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.update(h.items[i].Key, i)
+	h.update(h.items[j].Key, j)
+}
+
+func (h *topkHeap[K]) Push(x any) {
+	item := x.(Pair[K, uint64])
+	h.items = append(h.items, item)
+	h.update(item.Key, len(h.items)-1)
+}
+
+func (h *topkHeap[K]) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	h.update(item.Key, -1)
+	return item
+}
+
+func (h *topkHeap[K]) update(key K, pos int) {
+	e, _ := h.counts.Get(key)
+	e.pos = pos
+	h.counts.Put(key, e)
+}
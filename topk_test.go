@@ -0,0 +1,55 @@
+package swiss
+
+import (
+	randn "math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopK(t *testing.T) {
+	t.Parallel()
+	k := 10
+	topk := NewTopK[uint64](k)
+	zipf := randn.NewZipf(randn.New(randn.NewSource(1)), 1.2, 1, 100_000)
+	expected := make(map[uint64]uint64)
+	for range 1000_000 {
+		key := zipf.Uint64()
+		topk.Offer(key)
+		expected[key]++
+	}
+	require.Equal(t, len(expected), topk.Len())
+	counts := make([]uint64, 0, len(expected))
+	for key, cnt := range expected {
+		require.Equal(t, cnt, topk.Count(key))
+		counts = append(counts, cnt)
+	}
+	slices.Sort(counts)
+	slices.Reverse(counts)
+	res := topk.Result()
+	require.Len(t, res, k)
+	for i, p := range res {
+		assert.Equal(t, counts[i], p.Value)
+		assert.Equal(t, expected[p.Key], p.Value)
+	}
+	for i, p := range topk.heap.items {
+		e, _ := topk.counts.Get(p.Key)
+		require.Equal(t, i, e.pos)
+	}
+}
+
+func TestTopKOfferN(t *testing.T) {
+	t.Parallel()
+	topk := NewTopK[string](2)
+	topk.OfferN("a", 5)
+	topk.OfferN("b", 3)
+	topk.OfferN("c", 4)
+	require.Equal(t, []Pair[string, uint64]{{"a", 5}, {"c", 4}}, topk.Result())
+	topk.OfferN("b", 3)
+	require.Equal(t, []Pair[string, uint64]{{"b", 6}, {"a", 5}}, topk.Result())
+	topk.Reset()
+	assert.Empty(t, topk.Result())
+	assert.Zero(t, topk.Count("a"))
+}
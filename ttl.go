@@ -0,0 +1,262 @@
+package swiss
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLMap is a map whose entries expire a duration after they have been put,
+// either the default of the map or one given per entry with PutTTL. Expired
+// entries are never returned: they are removed lazily when
+// accessed, and an optional background sweeper incrementally scans the
+// groups to reclaim entries that are never read again. TTLMap is safe for
+// concurrent use.
+type TTLMap[K comparable, V any] struct {
+	mu      sync.Mutex
+	items   *Map[K, ttlEntry[V]]
+	ttl     time.Duration
+	now     func() time.Time
+	cursor  int        // next group to be examined by Sweep
+	smu     sync.Mutex // serializes Start and Stop
+	sweeper *sweeper
+
+	// loader refreshes expired entries that are served stale for up to
+	// maxStale, see StaleWhileRevalidate. refreshing holds the keys being
+	// refreshed, each with a sequence number identifying the refresh.
+	loader     func(K) (V, error)
+	maxStale   time.Duration
+	refreshing *Map[K, uint64]
+	refreshSeq uint64
+
+	stats CacheStats
+}
+
+type ttlEntry[V any] struct {
+	value    V
+	deadline int64 // unix nanoseconds
+	ttl      time.Duration
+}
+
+type sweeper struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTTLMap creates a TTLMap with the specified initial size whose entries
+// expire ttl after they have been put, unless put with PutTTL.
+func NewTTLMap[K comparable, V any](size int, ttl time.Duration) *TTLMap[K, V] {
+	return &TTLMap[K, V]{
+		items: New[K, ttlEntry[V]](size),
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Put inserts or updates the value for the key and restarts its lifetime
+// with the default TTL of the map.
+func (m *TTLMap[K, V]) Put(key K, value V) {
+	m.PutTTL(key, value, m.ttl)
+}
+
+// PutTTL is like Put, but the entry expires ttl after it has been put. The
+// TTL also applies to the values reloaded for the entry, see
+// StaleWhileRevalidate.
+func (m *TTLMap[K, V]) PutTTL(key K, value V, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items.Put(key, m.entry(value, ttl))
+	if m.refreshing != nil {
+		m.refreshing.Delete(key)
+	}
+}
+
+// entry returns the entry for a value put now. It must be called with mu
+// held.
+func (m *TTLMap[K, V]) entry(value V, ttl time.Duration) ttlEntry[V] {
+	return ttlEntry[V]{value: value, deadline: m.now().Add(ttl).UnixNano(), ttl: ttl}
+}
+
+// Get returns the value for the key if it is present and has not expired.
+// With StaleWhileRevalidate, an expired entry is still returned while it is
+// being refreshed.
+func (m *TTLMap[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.items.Get(key)
+	if !ok {
+		m.stats.Misses++
+		var res V
+		return res, false
+	}
+	if now := m.now().UnixNano(); e.deadline <= now {
+		if now < e.deadline+int64(m.maxStale) {
+			m.revalidate(key, e.ttl)
+			m.stats.Hits++
+			return e.value, true
+		}
+		m.items.Delete(key)
+		m.stats.Misses++
+		m.stats.Expirations++
+		var res V
+		return res, false
+	}
+	m.stats.Hits++
+	return e.value, true
+}
+
+// Peek returns the value for the key if it is present and has not expired.
+// Unlike Get, it never removes or revalidates an expired entry and is not
+// counted in the statistics.
+func (m *TTLMap[K, V]) Peek(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.items.Get(key)
+	if !ok || e.deadline <= m.now().UnixNano() {
+		var res V
+		return res, false
+	}
+	return e.value, true
+}
+
+// Delete removes the key from the map.
+func (m *TTLMap[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items.Delete(key)
+	if m.refreshing != nil {
+		m.refreshing.Delete(key)
+	}
+}
+
+// StaleWhileRevalidate makes Get keep serving an expired entry for up to
+// maxStale after its expiration while a single background goroutine reloads
+// it with loader, instead of letting every reader miss at once. If the
+// loader fails, the entry stays stale and the next Get starts a new refresh.
+// A Put or Delete of the key during a refresh wins over its result. Entries
+// are only removed, by Get or Sweep, once they are stale for longer than
+// maxStale.
+func (m *TTLMap[K, V]) StaleWhileRevalidate(maxStale time.Duration, loader func(key K) (V, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loader, m.maxStale = loader, maxStale
+	m.refreshing = New[K, uint64](0)
+}
+
+// revalidate starts a refresh of the key, whose entries live for ttl, unless
+// one is already running. It must be called with mu held.
+func (m *TTLMap[K, V]) revalidate(key K, ttl time.Duration) {
+	if _, ok := m.refreshing.Get(key); ok {
+		return
+	}
+	m.refreshSeq++
+	seq := m.refreshSeq
+	m.refreshing.Put(key, seq)
+	go func() {
+		value, err := m.loader(key)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if cur, ok := m.refreshing.Get(key); !ok || cur != seq {
+			return
+		}
+		m.refreshing.Delete(key)
+		if err == nil {
+			m.items.Put(key, m.entry(value, ttl))
+		}
+	}()
+}
+
+// Len returns the number of entries in the map, including expired entries
+// that have not been reclaimed yet.
+func (m *TTLMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.items.Len()
+}
+
+// Stats returns the hit, miss and expiration counters of the map. Stale
+// values served while being revalidated count as hits.
+func (m *TTLMap[K, V]) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// ResetStats sets all counters of the map to zero.
+func (m *TTLMap[K, V]) ResetStats() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = CacheStats{}
+}
+
+// Sweep examines up to budget entries, continuing from where the previous
+// call stopped, and removes the expired ones. It returns the number of
+// removed entries.
+func (m *TTLMap[K, V]) Sweep(budget int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Entries served stale are kept until they are stale for too long.
+	limit := m.now().UnixNano() - int64(m.maxStale)
+	var removed, examined int
+	for visited := 0; examined < budget && visited < len(m.items.grps); visited++ {
+		if m.cursor >= len(m.items.grps) {
+			m.cursor = 0
+		}
+		group := &m.items.grps[m.cursor]
+		mask := group.maskFull()
+		for mask != 0 {
+			i := mask.first()
+			if group.slts[i].value.deadline <= limit {
+				m.items.deleteAt(group, i)
+				m.stats.Expirations++
+				removed++
+			}
+			examined++
+			mask = mask.rmfirst()
+		}
+		m.cursor++
+	}
+	return removed
+}
+
+// Start launches a background goroutine calling Sweep(budget) every
+// interval. A sweeper that is already running is stopped first. Stop must be
+// called to release the goroutine.
+func (m *TTLMap[K, V]) Start(interval time.Duration, budget int) {
+	m.smu.Lock()
+	defer m.smu.Unlock()
+	m.stop()
+	s := &sweeper{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	m.sweeper = s
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				m.Sweep(budget)
+			}
+		}
+	}()
+}
+
+// Stop stops the background sweeper and waits for it to exit. It is a no-op
+// if the sweeper is not running.
+func (m *TTLMap[K, V]) Stop() {
+	m.smu.Lock()
+	defer m.smu.Unlock()
+	m.stop()
+}
+
+func (m *TTLMap[K, V]) stop() {
+	if s := m.sweeper; s != nil {
+		close(s.stop)
+		<-s.done
+		m.sweeper = nil
+	}
+}
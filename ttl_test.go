@@ -0,0 +1,200 @@
+package swiss
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestTTLMap[K comparable, V any](size int, ttl time.Duration) (*TTLMap[K, V], *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	m := NewTTLMap[K, V](size, ttl)
+	m.now = clock.Now
+	return m, clock
+}
+
+func TestTTLMapExpiration(t *testing.T) {
+	t.Parallel()
+	m, clock := newTestTTLMap[string, int](0, time.Minute)
+	m.Put("a", 1)
+	clock.Advance(30 * time.Second)
+	m.Put("b", 2)
+	value, ok := m.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+	clock.Advance(30 * time.Second)
+	_, ok = m.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 1, m.Len())
+	value, ok = m.Get("b")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+	m.Put("b", 3)
+	clock.Advance(45 * time.Second)
+	value, ok = m.Get("b")
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+	m.Delete("b")
+	require.Zero(t, m.Len())
+}
+
+func TestTTLMapPutTTL(t *testing.T) {
+	t.Parallel()
+	m, clock := newTestTTLMap[string, int](0, time.Minute)
+	m.PutTTL("short", 1, time.Second)
+	m.PutTTL("long", 2, time.Hour)
+	m.Put("default", 3)
+	clock.Advance(time.Second)
+	_, ok := m.Get("short")
+	require.False(t, ok)
+	clock.Advance(time.Minute)
+	_, ok = m.Get("default")
+	require.False(t, ok)
+	value, ok := m.Peek("long")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	m.PutTTL("short", 4, time.Second)
+	require.Zero(t, m.Sweep(10))
+	clock.Advance(time.Second)
+	require.Equal(t, 1, m.Sweep(10))
+	require.Equal(t, 1, m.Len())
+	clock.Advance(time.Hour)
+	require.Equal(t, 1, m.Sweep(10))
+	require.Zero(t, m.Len())
+}
+
+func TestTTLMapSweep(t *testing.T) {
+	t.Parallel()
+	size := 1000
+	m, clock := newTestTTLMap[int, int](size, time.Minute)
+	for i := range size {
+		m.Put(i, i)
+	}
+	clock.Advance(time.Minute)
+	for i := size; i < 2*size; i++ {
+		m.Put(i, i)
+	}
+	var removed, calls int
+	for removed < size {
+		n := m.Sweep(100)
+		require.LessOrEqual(t, n, 100+grpssz)
+		removed += n
+		calls++
+		require.Less(t, calls, 1000)
+	}
+	require.Equal(t, size, removed)
+	require.Equal(t, size, m.Len())
+	for i := size; i < 2*size; i++ {
+		_, ok := m.Get(i)
+		require.True(t, ok)
+	}
+}
+
+func TestTTLMapSweeper(t *testing.T) {
+	t.Parallel()
+	m, clock := newTestTTLMap[int, int](0, time.Second)
+	for i := range 100 {
+		m.Put(i, i)
+	}
+	clock.Advance(time.Second)
+	m.Start(time.Millisecond, 10)
+	m.Start(time.Millisecond, 10)
+	assert.Eventually(t, func() bool { return m.Len() == 0 }, 5*time.Second, time.Millisecond)
+	m.Stop()
+	m.Stop()
+}
+
+func TestTTLMapStaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+	m, clock := newTestTTLMap[string, int](0, time.Minute)
+	var calls atomic.Int32
+	release := make(chan error)
+	m.StaleWhileRevalidate(time.Minute, func(key string) (int, error) {
+		calls.Add(1)
+		return len(key) * 10, <-release
+	})
+	m.Put("a", 1)
+	clock.Advance(time.Minute)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, ok := m.Get("a")
+			assert.True(t, ok)
+			assert.Equal(t, 1, value)
+		}()
+	}
+	wg.Wait()
+	release <- errors.New("unavailable")
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.refreshing.Len() == 0
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(1), calls.Load())
+	value, ok := m.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+	release <- nil
+	require.Eventually(t, func() bool {
+		value, _ := m.Get("a")
+		return value == 10
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(2), calls.Load())
+
+	// Writes during a refresh win over its result.
+	clock.Advance(time.Minute)
+	m.Get("a")
+	m.Delete("a")
+	release <- nil
+	m.Put("b", 2)
+	clock.Advance(time.Minute)
+	m.Get("b")
+	m.Put("b", 3)
+	release <- nil
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.refreshing.Len() == 0
+	}, time.Second, time.Millisecond)
+	_, ok = m.Get("a")
+	require.False(t, ok)
+	value, ok = m.Get("b")
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+
+	// Entries stale for longer than maxStale are removed.
+	clock.Advance(90 * time.Second)
+	require.Zero(t, m.Sweep(10))
+	clock.Advance(time.Minute)
+	require.Equal(t, 1, m.Sweep(10))
+	m.Put("c", 4)
+	clock.Advance(2 * time.Minute)
+	_, ok = m.Get("c")
+	require.False(t, ok)
+	require.Zero(t, m.Len())
+}
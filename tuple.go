@@ -0,0 +1,15 @@
+package swiss
+
+import "github.com/crn4/swiss/hash"
+
+// NewPairKeyed creates a map keyed by pairs of values, hashed with the
+// dedicated mixing of hash.Pair. It accepts the same options as New.
+func NewPairKeyed[A, B comparable, V any](size int, opts ...Option) *Map[hash.Pair[A, B], V] {
+	return New[hash.Pair[A, B], V](size, opts...)
+}
+
+// NewTripleKeyed creates a map keyed by triples of values, hashed with the
+// dedicated mixing of hash.Triple. It accepts the same options as New.
+func NewTripleKeyed[A, B, C comparable, V any](size int, opts ...Option) *Map[hash.Triple[A, B, C], V] {
+	return New[hash.Triple[A, B, C], V](size, opts...)
+}
@@ -0,0 +1,56 @@
+package swiss
+
+import (
+	"testing"
+
+	"github.com/crn4/swiss/hash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleKeys(t *testing.T) {
+	t.Parallel()
+	m := NewPairKeyed[uint64, uint64, int](0)
+	for i := range uint64(100) {
+		for j := range uint64(100) {
+			m.Put(hash.NewPair(i, j), int(i*100+j))
+		}
+	}
+	require.Equal(t, 10000, m.Len())
+	for i := range uint64(100) {
+		for j := range uint64(100) {
+			v, ok := m.Get(hash.NewPair(i, j))
+			require.True(t, ok)
+			require.Equal(t, int(i*100+j), v)
+		}
+	}
+
+	s := NewTripleKeyed[string, int32, float64, bool](0)
+	s.Put(hash.NewTriple("a", int32(1), 0.0), true)
+	ok, _ := s.Get(hash.NewTriple("a", int32(1), -0.0))
+	require.True(t, ok)
+	_, found := s.Get(hash.NewTriple("a", int32(2), 0.0))
+	require.False(t, found)
+
+	// Distinct pairs get distinct hashes, including swapped components.
+	hashes := make(map[uint64]bool)
+	for i := range uint64(1000) {
+		hashes[m.Hash(hash.NewPair(i, i+1))] = true
+		hashes[m.Hash(hash.NewPair(i+1, i))] = true
+	}
+	require.Len(t, hashes, 2000)
+}
+
+func TestTupleKeysSpread(t *testing.T) {
+	t.Parallel()
+	// A component equal to one of the mixing constants must not cancel the
+	// seed out of the hash and send all keys to the same group.
+	const prime2 = 0xc2b2ae3d27d4eb4f
+	pairs := NewPairKeyed[int64, uint64, int](0)
+	triples := NewTripleKeyed[int64, uint64, int64, int](0)
+	hashes := make(map[uint64]bool)
+	for i := range int64(1000) {
+		hashes[pairs.Hash(hash.NewPair(i, uint64(prime2)))] = true
+		hashes[triples.Hash(hash.NewTriple(i, uint64(prime2), int64(0)))] = true
+	}
+	require.Len(t, hashes, 2000)
+}
@@ -0,0 +1,211 @@
+package swiss
+
+import (
+	"iter"
+	"math/rand"
+)
+
+// U64Set is a set of uint64 keys, such as numeric identifiers, with the
+// table layout of Map but without its generality: a group is a control word
+// followed by eight keys, keys are hashed with a single multiplication, and
+// the code is not instantiated per type. Every key of a group is compared
+// against the control word at once, so a lookup usually compares a single
+// key. U64Set is not safe for concurrent use.
+type U64Set struct {
+	grps       []u64Group
+	seed       uint64
+	len        int // full slots and tombstones
+	tombstones int
+	cap        int
+}
+
+type u64Group struct {
+	cntrl control
+	keys  [grpssz]uint64
+}
+
+// NewU64Set creates a set taking size keys without rehashing.
+func NewU64Set(size int) *U64Set {
+	s := &U64Set{seed: rand.Uint64()}
+	s.init(groupsnum(max(size, 0)))
+	return s
+}
+
+func (s *U64Set) init(ngroups int) {
+	s.grps = make([]u64Group, ngroups)
+	for i := range s.grps {
+		s.grps[i].cntrl = emptyContol
+	}
+	s.len, s.tombstones = 0, 0
+	s.cap = ngroups * grpload
+}
+
+// hash returns the home group and the H2 control byte of the key. The
+// multiplication spreads the key into the high bits, which select the group
+// by a multiply-shift range reduction instead of a division.
+func (s *U64Set) hash(key uint64) (uint32, uint8) {
+	h := (key ^ s.seed) * 0x9e3779b97f4a7c15
+	return uint32((h >> 32) * uint64(len(s.grps)) >> 32), uint8(h>>25) & 0x7f
+}
+
+// Add inserts the key and reports whether it was new.
+func (s *U64Set) Add(key uint64) bool {
+	ngrp, h2 := s.hash(key)
+	cgrp, ci, candidate := uint32(0), uint32(0), false
+	for {
+		g := &s.grps[ngrp]
+		equal := g.cntrl.match(uintptr(h2))
+		for equal != 0 {
+			if g.keys[equal.first()] == key {
+				return false
+			}
+			equal = equal.rmfirst()
+		}
+		if empty := g.cntrl.maskEmpty(); empty != 0 {
+			if candidate {
+				s.grps[cgrp].cntrl.set(ci, h2)
+				s.grps[cgrp].keys[ci] = key
+				s.tombstones--
+				return true
+			}
+			i := empty.first()
+			g.cntrl.set(i, h2)
+			g.keys[i] = key
+			s.len++
+			if s.len > s.cap {
+				s.rehash()
+			}
+			return true
+		}
+		if !candidate && s.tombstones > 0 {
+			// Without empty slots, these are the tombstones.
+			if deleted := g.cntrl.maskEmptyOrDeleted(); deleted != 0 {
+				cgrp, ci, candidate = ngrp, deleted.first(), true
+			}
+		}
+		if ngrp++; int(ngrp) == len(s.grps) {
+			ngrp = 0
+		}
+	}
+}
+
+// Has reports whether the key is in the set.
+func (s *U64Set) Has(key uint64) bool {
+	ngrp, h2 := s.hash(key)
+	for {
+		g := &s.grps[ngrp]
+		equal := g.cntrl.match(uintptr(h2))
+		for equal != 0 {
+			if g.keys[equal.first()] == key {
+				return true
+			}
+			equal = equal.rmfirst()
+		}
+		if g.cntrl.maskEmpty() != 0 {
+			return false
+		}
+		if ngrp++; int(ngrp) == len(s.grps) {
+			ngrp = 0
+		}
+	}
+}
+
+// Delete removes the key and reports whether it was present.
+func (s *U64Set) Delete(key uint64) bool {
+	ngrp, h2 := s.hash(key)
+	for {
+		g := &s.grps[ngrp]
+		equal := g.cntrl.match(uintptr(h2))
+		for equal != 0 {
+			i := equal.first()
+			if g.keys[i] == key {
+				if g.cntrl.maskEmpty() != 0 {
+					g.cntrl.set(i, kEmpty)
+					s.len--
+				} else {
+					g.cntrl.set(i, kDeleted)
+					s.tombstones++
+				}
+				return true
+			}
+			equal = equal.rmfirst()
+		}
+		if g.cntrl.maskEmpty() != 0 {
+			return false
+		}
+		if ngrp++; int(ngrp) == len(s.grps) {
+			ngrp = 0
+		}
+	}
+}
+
+// AddAll inserts all keys and returns the number of new ones.
+func (s *U64Set) AddAll(keys []uint64) int {
+	var n int
+	for _, key := range keys {
+		if s.Add(key) {
+			n++
+		}
+	}
+	return n
+}
+
+// ContainsAll reports whether all keys are in the set. It stops at the
+// first missing key.
+func (s *U64Set) ContainsAll(keys []uint64) bool {
+	for _, key := range keys {
+		if !s.Has(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of keys in the set.
+func (s *U64Set) Len() int {
+	return s.len - s.tombstones
+}
+
+// Clear removes all keys from the set, keeping its capacity.
+func (s *U64Set) Clear() {
+	for i := range s.grps {
+		s.grps[i].cntrl = emptyContol
+	}
+	s.len, s.tombstones = 0, 0
+}
+
+// All returns an iterator over all keys of the set, in no particular order.
+func (s *U64Set) All() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for i := range s.grps {
+			g := &s.grps[i]
+			mask := g.cntrl.maskFull()
+			for mask != 0 {
+				if !yield(g.keys[mask.first()]) {
+					return
+				}
+				mask = mask.rmfirst()
+			}
+		}
+	}
+}
+
+// rehash reinserts all keys into a table twice as large, or of the same
+// size if at least half of the used slots are tombstones.
+func (s *U64Set) rehash() {
+	old := s.grps
+	// u64Group has the layout of group[uint64, struct{}].
+	ngroups := groupsnum(newsize(s.cap, s.tombstones))
+	if ngroups > maxGroups[uint64, struct{}]() {
+		panic(errTooLarge)
+	}
+	s.init(ngroups)
+	for i := range old {
+		g := &old[i]
+		mask := g.cntrl.maskFull()
+		for mask != 0 {
+			s.Add(g.keys[mask.first()])
+			mask = mask.rmfirst()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package swiss
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestU64Set(t *testing.T) {
+	t.Parallel()
+	s := NewU64Set(0)
+	expected := make(map[uint64]bool)
+	for range 200_000 {
+		// Few distinct keys, so that inserts and deletes hit each other.
+		key := uint64(rand.Intn(50_000)) << (rand.Intn(4) * 16)
+		if rand.Intn(3) == 0 {
+			require.Equal(t, expected[key], s.Delete(key))
+			delete(expected, key)
+		} else {
+			require.Equal(t, !expected[key], s.Add(key))
+			expected[key] = true
+		}
+	}
+	require.Equal(t, len(expected), s.Len())
+	var keys []uint64
+	for key := range expected {
+		require.True(t, s.Has(key))
+		keys = append(keys, key)
+	}
+	require.True(t, s.ContainsAll(keys))
+	require.False(t, s.ContainsAll(append(keys, 1<<63+1)))
+	var all []uint64
+	for key := range s.All() {
+		all = append(all, key)
+	}
+	slices.Sort(keys)
+	slices.Sort(all)
+	require.Equal(t, keys, all)
+
+	s.Clear()
+	require.Zero(t, s.Len())
+	require.False(t, s.Has(keys[0]))
+	require.Equal(t, 3, s.AddAll([]uint64{1, 2, 2, 3, 1}))
+	require.Equal(t, 3, s.Len())
+}
+
+func TestU64SetTombstones(t *testing.T) {
+	t.Parallel()
+	s := NewU64Set(1000)
+	capacity := s.cap
+	// Churn through many more keys than fit, keeping the set small: reused
+	// tombstones and same-size rehashes keep the table from growing.
+	for i := range uint64(100_000) {
+		s.Add(i)
+		if i >= 500 {
+			require.True(t, s.Delete(i-500))
+		}
+	}
+	require.Equal(t, 500, s.Len())
+	require.Equal(t, capacity, s.cap)
+}
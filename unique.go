@@ -0,0 +1,25 @@
+package swiss
+
+import "unique"
+
+// Maps keyed by unique.Handle hash and compare the handle's pointer rather
+// than the value it stands for, so lookups cost the same for long strings as
+// for integers and every distinct key is stored only once. The helpers
+// below intern plain keys on the way in.
+
+// PutInterned interns the key and stores the value under its handle.
+func PutInterned[T comparable, V any](m *Map[unique.Handle[T], V], key T, value V) unique.Handle[T] {
+	h := unique.Make(key)
+	m.Put(h, value)
+	return h
+}
+
+// GetInterned returns the value stored under the handle of the key.
+func GetInterned[T comparable, V any](m *Map[unique.Handle[T], V], key T) (V, bool) {
+	return m.Get(unique.Make(key))
+}
+
+// DeleteInterned removes the handle of the key from the map.
+func DeleteInterned[T comparable, V any](m *Map[unique.Handle[T], V], key T) {
+	m.Delete(unique.Make(key))
+}
@@ -0,0 +1,34 @@
+package swiss
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unique"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/crn4/swiss/hash"
+)
+
+func TestInterned(t *testing.T) {
+	t.Parallel()
+	require.True(t, hash.RegularMemory(reflect.TypeFor[unique.Handle[string]]()))
+	m := New[unique.Handle[string], int](0)
+	long := strings.Repeat("x", 4096)
+	for i := range 1000 {
+		PutInterned(m, long+string(rune('a'+i%26)), i)
+	}
+	require.Equal(t, 26, m.Len())
+	v, ok := GetInterned(m, strings.Clone(long)+"c")
+	require.True(t, ok)
+	require.Equal(t, 990, v)
+	h := PutInterned(m, "short", -1)
+	v, ok = m.Get(h)
+	require.True(t, ok)
+	require.Equal(t, -1, v)
+	require.Equal(t, m.Hash(h), m.Hash(unique.Make(strings.Clone("short"))))
+	DeleteInterned(m, "short")
+	_, ok = GetInterned(m, "short")
+	require.False(t, ok)
+}
@@ -0,0 +1,175 @@
+package swiss
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+var errIndexType = errors.New("swiss: index does not match the key or value type")
+
+// indexSpec is an index requested by WithIndex.
+type indexSpec struct {
+	name    string
+	newFunc any // func() namedIndex[K, V]
+}
+
+// namedIndex is an index created by WithIndex.
+type namedIndex[K comparable, V any] interface {
+	index[K, V]
+	// keys returns the keys indexed under i, and false if i does not have
+	// the type of the index values.
+	keys(i any) (iter.Seq[K], bool)
+	// empty returns a new empty index with the same extraction function.
+	empty() namedIndex[K, V]
+}
+
+// valueIndex maps index values extracted from the values of a map to the
+// keys holding them. It remembers the index value of every key, since the
+// value is gone by the time a deletion or an update in place is reported.
+type valueIndex[K comparable, V any, I comparable] struct {
+	fn      func(V) I
+	byValue *Map[I, *Set[K]]
+	byKey   *Map[K, I]
+}
+
+// WithIndex makes the map maintain a secondary index named name, mapping the
+// index value fn extracts from every value to the set of keys holding it, so
+// that GetByIndex finds the entries with a given index value without a scan
+// of the map. The key type must be given explicitly, as in
+//
+//	swiss.WithIndex[string]("city", func(u User) string { return u.City })
+//
+// The index follows the same mutations as Watch, so values must not be
+// modified through GetPtr, and it does not follow a rollback to a
+// checkpoint. Clones and the results of set operations have their own
+// indexes. It costs an entry in two auxiliary maps per key. New panics if
+// the types do not match the map or names are used twice.
+func WithIndex[K comparable, V any, I comparable](name string, fn func(V) I) Option {
+	return func(o *options) {
+		o.indexes = append(o.indexes, indexSpec{
+			name: name,
+			newFunc: func() namedIndex[K, V] {
+				return newValueIndex[K](fn)
+			},
+		})
+	}
+}
+
+func newValueIndex[K comparable, V any, I comparable](fn func(V) I) *valueIndex[K, V, I] {
+	return &valueIndex[K, V, I]{
+		fn:      fn,
+		byValue: New[I, *Set[K]](0),
+		byKey:   New[K, I](0),
+	}
+}
+
+// GetByIndex returns an iterator over the entries whose index value in the
+// index named name equals i, in no particular order. It panics if the map
+// has no such index, see WithIndex, or if i does not have the type of its
+// index values. The map must not be modified during the iteration.
+func (m *Map[K, V]) GetByIndex(name string, i any) iter.Seq2[K, V] {
+	var idx namedIndex[K, V]
+	if m.obs != nil {
+		idx = m.obs.named[name]
+	}
+	if idx == nil {
+		panic(fmt.Sprintf("swiss: no index named %q", name))
+	}
+	keys, ok := idx.keys(i)
+	if !ok {
+		panic(fmt.Sprintf("swiss: index %q does not have values of type %T", name, i))
+	}
+	return func(yield func(K, V) bool) {
+		for k := range keys {
+			v, _ := m.Get(k)
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// addNamedIndex makes the map maintain idx under name.
+func (m *Map[K, V]) addNamedIndex(name string, idx namedIndex[K, V]) {
+	o := m.observers()
+	if o.named == nil {
+		o.named = make(map[string]namedIndex[K, V])
+	}
+	o.named[name] = idx
+	m.addIndex(idx)
+}
+
+// copyIndexes gives m empty indexes like the named indexes of src and fills
+// them with the entries of m.
+func (m *Map[K, V]) copyIndexes(src *Map[K, V]) {
+	if src.obs == nil {
+		return
+	}
+	for name, idx := range src.obs.named {
+		idx = idx.empty()
+		for k, v := range m.All() {
+			idx.update(OpPut, k, v)
+		}
+		m.addNamedIndex(name, idx)
+	}
+}
+
+func (x *valueIndex[K, V, I]) update(op Op, key K, value V) {
+	switch op {
+	case OpPut:
+		x.add(key, x.fn(value))
+	case OpUpdate:
+		i := x.fn(value)
+		if old, ok := x.byKey.Get(key); ok {
+			if old == i {
+				return
+			}
+			x.remove(key, old)
+		}
+		x.add(key, i)
+	case OpDelete:
+		if old, ok := x.byKey.Get(key); ok {
+			x.remove(key, old)
+		}
+	case OpClear:
+		x.byValue.Clear()
+		x.byKey.Clear()
+	}
+}
+
+func (x *valueIndex[K, V, I]) add(key K, i I) {
+	x.byKey.Put(key, i)
+	s, ok := x.byValue.Get(i)
+	if !ok {
+		s = NewSet[K](1)
+		x.byValue.Put(i, s)
+	}
+	s.Add(key)
+}
+
+func (x *valueIndex[K, V, I]) remove(key K, i I) {
+	x.byKey.Delete(key)
+	if s, ok := x.byValue.Get(i); ok {
+		s.Delete(key)
+		if s.Len() == 0 {
+			x.byValue.Delete(i)
+		}
+	}
+}
+
+func (x *valueIndex[K, V, I]) keys(i any) (iter.Seq[K], bool) {
+	v, ok := i.(I)
+	if !ok {
+		return nil, false
+	}
+	s, found := x.byValue.Get(v)
+	if !found {
+		return func(func(K) bool) {}, true
+	}
+	return s.All(), true
+}
+
+func (x *valueIndex[K, V, I]) empty() namedIndex[K, V] {
+	return newValueIndex[K](x.fn)
+}
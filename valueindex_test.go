@@ -0,0 +1,111 @@
+package swiss
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type indexedUser struct {
+	Name string
+	City string
+	Age  int
+}
+
+func indexKeys[K comparable, V any](m *Map[K, V], name string, i any) []K {
+	var keys []K
+	for k := range m.GetByIndex(name, i) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestWithIndex(t *testing.T) {
+	t.Parallel()
+	m := New[int, indexedUser](0,
+		WithIndex[int]("city", func(u indexedUser) string { return u.City }),
+		WithIndex[int]("adult", func(u indexedUser) bool { return u.Age >= 18 }),
+	)
+	m.Put(1, indexedUser{"ann", "paris", 30})
+	m.Put(2, indexedUser{"bob", "rome", 12})
+	m.Put(3, indexedUser{"cid", "paris", 17})
+
+	require.ElementsMatch(t, []int{1, 3}, indexKeys(m, "city", "paris"))
+	require.ElementsMatch(t, []int{2}, indexKeys(m, "city", "rome"))
+	require.Empty(t, indexKeys(m, "city", "oslo"))
+	require.ElementsMatch(t, []int{2, 3}, indexKeys(m, "adult", false))
+	for k, u := range m.GetByIndex("adult", true) {
+		require.Equal(t, 1, k)
+		require.Equal(t, "ann", u.Name)
+	}
+
+	// Updates move keys between index values, deletions remove them.
+	m.Put(3, indexedUser{"cid", "rome", 18})
+	require.ElementsMatch(t, []int{1}, indexKeys(m, "city", "paris"))
+	require.ElementsMatch(t, []int{2, 3}, indexKeys(m, "city", "rome"))
+	require.ElementsMatch(t, []int{1, 3}, indexKeys(m, "adult", true))
+	m.Modify(1, func(u *indexedUser) { u.City = "oslo" })
+	require.Empty(t, indexKeys(m, "city", "paris"))
+	require.ElementsMatch(t, []int{1}, indexKeys(m, "city", "oslo"))
+	m.Do(2, func(u *indexedUser, _ bool) (indexedUser, bool, bool) { return *u, false, true })
+	m.Rename(3, 4)
+	require.ElementsMatch(t, []int{4}, indexKeys(m, "city", "rome"))
+
+	c := m.Clone()
+	m.Clear()
+	require.Empty(t, indexKeys(m, "city", "oslo"))
+	require.ElementsMatch(t, []int{1}, indexKeys(c, "city", "oslo"))
+	c.Delete(1)
+	require.Empty(t, indexKeys(c, "city", "oslo"))
+
+	require.Panics(t, func() { m.GetByIndex("name", "ann") })
+	require.Panics(t, func() { m.GetByIndex("city", 1) })
+}
+
+func TestWithIndexRandom(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0, WithIndex[int]("mod", func(v int) int { return v % 10 }))
+	ref := make(map[int]int)
+	for i := range 20000 {
+		k := rand.Intn(3000)
+		if rand.Intn(4) == 0 {
+			m.Delete(k)
+			delete(ref, k)
+		} else {
+			m.Put(k, i)
+			ref[k] = i
+		}
+	}
+	other := New[int, int](0)
+	for k := range 1500 {
+		other.Put(k, 0)
+	}
+	inter := m.Intersect(other)
+	for r := range 10 {
+		var expected, expectedInter []int
+		for k, v := range ref {
+			if v%10 == r {
+				expected = append(expected, k)
+				if k < 1500 {
+					expectedInter = append(expectedInter, k)
+				}
+			}
+		}
+		require.ElementsMatch(t, expected, indexKeys(m, "mod", r))
+		require.ElementsMatch(t, expectedInter, indexKeys(inter, "mod", r))
+	}
+}
+
+func TestWithIndexInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewE[int, string](0, WithIndex[int]("len", func(v int) int { return v }))
+	require.ErrorIs(t, err, errIndexType)
+	_, err = NewE[string, int](0, WithIndex[int]("len", func(v int) int { return v }))
+	require.ErrorIs(t, err, errIndexType)
+	_, err = NewE[int, int](0,
+		WithIndex[int]("x", func(v int) int { return v }),
+		WithIndex[int]("x", func(v int) bool { return v > 0 }),
+	)
+	require.ErrorContains(t, err, "duplicate index name")
+}
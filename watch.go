@@ -0,0 +1,176 @@
+package swiss
+
+import (
+	"errors"
+	"iter"
+	"slices"
+)
+
+// Op is the kind of a mutation reported by Watch and LogChanges.
+type Op uint8
+
+const (
+	// OpPut is the insertion of a new key.
+	OpPut Op = iota
+	// OpUpdate is a change of the value of a present key.
+	OpUpdate
+	// OpDelete is the removal of a key.
+	OpDelete
+	// OpClear is the removal of all keys; Key and Value are zero.
+	OpClear
+)
+
+// Event is a mutation of a map reported by Watch. Value is the new value for
+// OpPut and OpUpdate and zero otherwise.
+type Event[K comparable, V any] struct {
+	Op    Op
+	Key   K
+	Value V
+}
+
+// Change is a mutation recorded by LogChanges, numbered from 1 in the order
+// of the mutations of the map.
+type Change[K comparable, V any] struct {
+	Seq uint64
+	Event[K, V]
+}
+
+// ErrLogGap is returned by Apply and ApplyLog for a change that does not
+// directly follow the last applied one.
+var ErrLogGap = errors.New("swiss: change log is not contiguous")
+
+// observers holds everything notified of the mutations of a map.
+type observers[K comparable, V any] struct {
+	watchers []chan Event[K, V]
+	log      func(Change[K, V])
+	seq      uint64 // number of logged changes
+	applied  uint64 // sequence number of the last change applied by Apply
+	indexes  []index[K, V]
+	named    map[string]namedIndex[K, V]
+}
+
+// index is an auxiliary structure kept up to date with the mutations of a
+// map, such as a PrefixIndex.
+type index[K comparable, V any] interface {
+	update(op Op, key K, value V)
+}
+
+// addIndex makes the map report its mutations to idx.
+func (m *Map[K, V]) addIndex(idx index[K, V]) {
+	o := m.observers()
+	o.indexes = append(o.indexes, idx)
+}
+
+// removeIndex stops reporting mutations to idx.
+func (m *Map[K, V]) removeIndex(idx index[K, V]) {
+	if m.obs != nil {
+		m.obs.indexes = slices.DeleteFunc(m.obs.indexes, func(i index[K, V]) bool { return i == idx })
+	}
+}
+
+func (m *Map[K, V]) observers() *observers[K, V] {
+	if m.obs == nil {
+		m.obs = &observers[K, V]{}
+	}
+	return m.obs
+}
+
+// Watch returns a channel receiving an event for every subsequent mutation
+// of the map, in order. Rehashing is not reported, nor are writes through
+// pointers returned by GetPtr, but writes by Modify and Do are. Events are
+// sent without blocking: a watcher whose buffer of buffer events is full is
+// dropped and its channel closed, after which it should read the map again
+// and start a new watch. Unwatch stops watching.
+func (m *Map[K, V]) Watch(buffer int) <-chan Event[K, V] {
+	ch := make(chan Event[K, V], buffer)
+	o := m.observers()
+	o.watchers = append(o.watchers, ch)
+	return ch
+}
+
+// Unwatch stops a watch started by Watch and closes its channel. It is a
+// no-op if the watcher has already been dropped.
+func (m *Map[K, V]) Unwatch(ch <-chan Event[K, V]) {
+	if m.obs == nil {
+		return
+	}
+	for i, w := range m.obs.watchers {
+		if (<-chan Event[K, V])(w) == ch {
+			close(w)
+			m.obs.watchers = slices.Delete(m.obs.watchers, i, i+1)
+			return
+		}
+	}
+}
+
+// LogChanges calls fn synchronously with every subsequent mutation of the
+// map, numbered by a sequence number, so that a replica applying them in
+// order with Apply or ApplyLog reproduces the map. The same mutations as for
+// Watch are recorded, so values must not be modified through GetPtr. fn
+// typically appends to a ring buffer or writes to a stream and must not
+// modify the map. A nil fn stops logging, after which replicas have to be
+// rebuilt from a copy of the map.
+func (m *Map[K, V]) LogChanges(fn func(Change[K, V])) {
+	m.observers().log = fn
+}
+
+// Apply applies a change recorded by LogChanges on another map. Changes must
+// be applied in order starting with sequence number 1, or with the number
+// following the last change applied; otherwise Apply returns ErrLogGap and
+// leaves the map unchanged.
+func (m *Map[K, V]) Apply(c Change[K, V]) error {
+	o := m.observers()
+	if c.Seq != o.applied+1 {
+		return ErrLogGap
+	}
+	switch c.Op {
+	case OpPut, OpUpdate:
+		m.Put(c.Key, c.Value)
+	case OpDelete:
+		m.Delete(c.Key)
+	case OpClear:
+		m.Clear()
+	}
+	o.applied = c.Seq
+	return nil
+}
+
+// ApplyLog applies the changes yielded by log in order, stopping at the
+// first error.
+func (m *Map[K, V]) ApplyLog(log iter.Seq[Change[K, V]]) error {
+	for c := range log {
+		if err := m.Apply(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Applied returns the sequence number of the last change applied by Apply.
+func (m *Map[K, V]) Applied() uint64 {
+	if m.obs == nil {
+		return 0
+	}
+	return m.obs.applied
+}
+
+func (m *Map[K, V]) notify(op Op, key K, value V) {
+	o := m.obs
+	for _, idx := range o.indexes {
+		idx.update(op, key, value)
+	}
+	ev := Event[K, V]{Op: op, Key: key, Value: value}
+	if o.log != nil {
+		o.seq++
+		o.log(Change[K, V]{Seq: o.seq, Event: ev})
+	}
+	for i := 0; i < len(o.watchers); {
+		select {
+		case o.watchers[i] <- ev:
+			i++
+		default:
+			close(o.watchers[i])
+			o.watchers = slices.Delete(o.watchers, i, i+1)
+		}
+	}
+}
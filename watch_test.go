@@ -0,0 +1,93 @@
+package swiss
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	m := New[int, int](0)
+	m.Put(-1, -1)
+	ch := m.Watch(10000)
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	m.Put(1, 10)
+	m.Modify(2, func(v *int) { *v = 20 })
+	m.Do(3, func(v *int, exists bool) (int, bool, bool) { return 30, true, false })
+	m.Delete(4)
+	m.Delete(-2)
+	m.Clear()
+	m.Unwatch(ch)
+	m.Put(5, 5)
+
+	var events []Event[int, int]
+	for ev := range ch {
+		events = append(events, ev)
+	}
+	require.Len(t, events, 1005)
+	for i := range 1000 {
+		require.Equal(t, Event[int, int]{Op: OpPut, Key: i, Value: i}, events[i])
+	}
+	require.Equal(t, []Event[int, int]{
+		{Op: OpUpdate, Key: 1, Value: 10},
+		{Op: OpUpdate, Key: 2, Value: 20},
+		{Op: OpUpdate, Key: 3, Value: 30},
+		{Op: OpDelete, Key: 4},
+		{Op: OpClear},
+	}, events[1000:])
+
+	// A watcher falling behind is dropped.
+	slow := m.Watch(2)
+	fast := m.Watch(10)
+	for i := range 3 {
+		m.Put(i, i)
+	}
+	var n int
+	for range slow {
+		n++
+	}
+	require.Equal(t, 2, n)
+	require.Len(t, fast, 3)
+	m.Unwatch(slow)
+	m.Unwatch(fast)
+	_, ok := <-fast
+	require.True(t, ok)
+}
+
+func TestChangeLog(t *testing.T) {
+	t.Parallel()
+	primary := New[string, int](0)
+	var log []Change[string, int]
+	primary.LogChanges(func(c Change[string, int]) {
+		log = append(log, c)
+	})
+	replica := New[string, int](0)
+	for i := range 1000 {
+		primary.Put(fmt.Sprint(i), i)
+	}
+	primary.Modify("1", func(v *int) { *v = -1 })
+	primary.Delete("2")
+	require.NoError(t, replica.ApplyLog(slices.Values(log)))
+	require.Equal(t, uint64(1002), replica.Applied())
+	require.Equal(t, primary.Len(), replica.Len())
+	for k, v := range primary.All() {
+		got, ok := replica.Get(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+
+	primary.Clear()
+	primary.Put("a", 1)
+	require.ErrorIs(t, replica.Apply(log[len(log)-1]), ErrLogGap)
+	require.ErrorIs(t, replica.Apply(log[0]), ErrLogGap)
+	require.NoError(t, replica.ApplyLog(slices.Values(log[1002:])))
+	require.Equal(t, 1, replica.Len())
+	v, _ := replica.Get("a")
+	require.Equal(t, 1, v)
+	require.Equal(t, uint64(len(log)), replica.Applied())
+}
@@ -0,0 +1,92 @@
+//go:build go1.24
+
+package swiss
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// WeakValueMap is a map holding its values through weak pointers: an entry
+// is removed automatically once its value is no longer reachable from
+// anywhere else, which makes it suitable for canonicalization caches that
+// must not keep their values alive. Removal happens in a cleanup run by the
+// runtime some time after the value has been collected; until then Get
+// reports the entry as absent. WeakValueMap is safe for concurrent use.
+type WeakValueMap[K comparable, V any] struct {
+	mu    sync.Mutex
+	items *Map[K, weak.Pointer[V]]
+}
+
+// NewWeakValueMap creates a WeakValueMap with the specified initial size.
+func NewWeakValueMap[K comparable, V any](size int) *WeakValueMap[K, V] {
+	return &WeakValueMap[K, V]{items: New[K, weak.Pointer[V]](size)}
+}
+
+// Put associates value with the key. The map does not keep value alive.
+func (m *WeakValueMap[K, V]) Put(key K, value *V) {
+	wp := weak.Make(value)
+	m.mu.Lock()
+	m.items.Put(key, wp)
+	m.mu.Unlock()
+	runtime.AddCleanup(value, m.cleanup, weakEntry[K, V]{key: key, wp: wp})
+}
+
+type weakEntry[K comparable, V any] struct {
+	key K
+	wp  weak.Pointer[V]
+}
+
+// cleanup removes the entry once its value has been collected, unless the
+// key has been associated with another value in the meantime.
+func (m *WeakValueMap[K, V]) cleanup(e weakEntry[K, V]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if wp, ok := m.items.Get(e.key); ok && wp == e.wp {
+		m.items.Delete(e.key)
+	}
+}
+
+// Get returns the value associated with the key, or false if there is none
+// or it has been collected.
+func (m *WeakValueMap[K, V]) Get(key K) (*V, bool) {
+	m.mu.Lock()
+	wp, ok := m.items.Get(key)
+	m.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	v := wp.Value()
+	return v, v != nil
+}
+
+// Delete removes the key from the map.
+func (m *WeakValueMap[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items.Delete(key)
+}
+
+// Len returns the number of entries in the map, including entries whose
+// values have been collected but not cleaned up yet.
+func (m *WeakValueMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.items.Len()
+}
+
+// All returns an iterator over the entries whose values are still alive. The
+// map is locked while iterating, so yield must not call its methods.
+func (m *WeakValueMap[K, V]) All() iter.Seq2[K, *V] {
+	return func(yield func(K, *V) bool) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for k, wp := range m.items.All() {
+			if v := wp.Value(); v != nil && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
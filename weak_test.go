@@ -0,0 +1,55 @@
+//go:build go1.24
+
+package swiss
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeakValueMap(t *testing.T) {
+	t.Parallel()
+	m := NewWeakValueMap[int, [64]byte](0)
+	kept := make([]*[64]byte, 100)
+	for i := range 200 {
+		v := &[64]byte{byte(i)}
+		if i < 100 {
+			kept[i] = v
+		}
+		m.Put(i, v)
+	}
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		return m.Len() == 100
+	}, 5*time.Second, 10*time.Millisecond)
+	for i := range 200 {
+		v, ok := m.Get(i)
+		require.Equal(t, i < 100, ok)
+		if ok {
+			require.Same(t, kept[i], v)
+		}
+	}
+	var cnt int
+	for range m.All() {
+		cnt++
+	}
+	require.Equal(t, 100, cnt)
+
+	// A value collected after its key was reassigned must not remove the
+	// new entry.
+	m.Put(0, &[64]byte{1})
+	m.Put(0, kept[1])
+	runtime.GC()
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	v, ok := m.Get(0)
+	require.True(t, ok)
+	require.Same(t, kept[1], v)
+	m.Delete(0)
+	_, ok = m.Get(0)
+	require.False(t, ok)
+	runtime.KeepAlive(kept)
+}
@@ -0,0 +1,81 @@
+package swiss
+
+import "time"
+
+// WindowCounter counts events per key over a rolling time window. The window
+// is divided into equal buckets, each a map of counts, kept in a ring: the
+// bucket of the current time receives new events and buckets that fall out
+// of the window are cleared and reused, keeping their capacity. Counts are
+// therefore exact at bucket granularity. WindowCounter is not safe for
+// concurrent use.
+type WindowCounter[K comparable] struct {
+	buckets []windowBucket[K]
+	width   int64 // nanoseconds covered by a bucket
+	now     func() time.Time
+}
+
+type windowBucket[K comparable] struct {
+	epoch  int64 // time divided by the bucket width
+	counts *Map[K, uint64]
+}
+
+// NewWindowCounter creates a WindowCounter over the given window, divided
+// into n buckets. A non-positive n is treated as 1, and the window is
+// rounded up to a multiple of n nanoseconds.
+func NewWindowCounter[K comparable](window time.Duration, n int) *WindowCounter[K] {
+	n = max(n, 1)
+	c := &WindowCounter[K]{
+		buckets: make([]windowBucket[K], n),
+		width:   max((int64(window)+int64(n)-1)/int64(n), 1),
+		now:     time.Now,
+	}
+	for i := range c.buckets {
+		c.buckets[i] = windowBucket[K]{epoch: -1, counts: New[K, uint64](0)}
+	}
+	return c
+}
+
+// Add counts one event for the key at the current time.
+func (c *WindowCounter[K]) Add(key K) {
+	c.AddN(key, 1)
+}
+
+// AddN counts n events for the key at the current time.
+func (c *WindowCounter[K]) AddN(key K, n uint64) {
+	epoch := c.now().UnixNano() / c.width
+	b := &c.buckets[epoch%int64(len(c.buckets))]
+	if b.epoch != epoch {
+		b.counts.Clear()
+		b.epoch = epoch
+	}
+	b.counts.Do(key, func(v *uint64, _ bool) (uint64, bool, bool) {
+		return *v + n, true, false
+	})
+}
+
+// Count returns the number of events for the key over the last window,
+// which is rounded up to whole buckets and capped to the window of the
+// counter. The bucket of the current time counts as a whole bucket.
+func (c *WindowCounter[K]) Count(key K, window time.Duration) uint64 {
+	n := min((int64(window)+c.width-1)/c.width, int64(len(c.buckets)))
+	epoch := c.now().UnixNano() / c.width
+	var total uint64
+	for e := epoch - n + 1; e <= epoch; e++ {
+		if e < 0 {
+			continue
+		}
+		if b := &c.buckets[e%int64(len(c.buckets))]; b.epoch == e {
+			v, _ := b.counts.Get(key)
+			total += v
+		}
+	}
+	return total
+}
+
+// Reset removes all counts.
+func (c *WindowCounter[K]) Reset() {
+	for i := range c.buckets {
+		c.buckets[i].counts.Clear()
+		c.buckets[i].epoch = -1
+	}
+}
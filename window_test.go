@@ -0,0 +1,37 @@
+package swiss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowCounter(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := NewWindowCounter[string](time.Minute, 6)
+	c.now = clock.Now
+	for range 5 {
+		c.Add("a")
+		clock.Advance(10 * time.Second)
+	}
+	c.AddN("b", 7)
+	require.Equal(t, uint64(5), c.Count("a", time.Minute))
+	require.Zero(t, c.Count("a", 10*time.Second))
+	require.Equal(t, uint64(1), c.Count("a", 11*time.Second))
+	require.Equal(t, uint64(5), c.Count("a", time.Hour))
+	require.Equal(t, uint64(7), c.Count("b", time.Second))
+	require.Zero(t, c.Count("c", time.Minute))
+
+	clock.Advance(20 * time.Second)
+	require.Equal(t, uint64(3), c.Count("a", time.Minute))
+	require.Equal(t, uint64(7), c.Count("b", time.Minute))
+	clock.Advance(time.Minute)
+	require.Zero(t, c.Count("a", time.Minute))
+	require.Zero(t, c.Count("b", time.Minute))
+
+	c.Add("a")
+	c.Reset()
+	require.Zero(t, c.Count("a", time.Minute))
+}
@@ -0,0 +1,79 @@
+// Package group gives read-only access to the groups of a swiss.Map, for
+// advanced callers that run bulk per-group operations on top of the table
+// layout, such as column stores. It depends on the layout of the table,
+// which may change between versions of the swiss package, and is not
+// covered by its compatibility guarantees.
+package group
+
+import (
+	"iter"
+
+	"github.com/crn4/swiss"
+	"github.com/crn4/swiss/internal/layout"
+)
+
+// Size is the number of slots in every group of a map.
+const Size = layout.GroupSize
+
+// Group is a read-only view of a single group of a map. The view is only
+// valid until the next mutation of the map: Put, Delete, Clear or a rehash
+// may move or overwrite its slots.
+type Group[K comparable, V any] struct {
+	g *layout.Group[K, V]
+}
+
+// All returns an iterator over all groups of the map together with their
+// index. Groups are visited in storage order; swiss.Map.All visits them in
+// the same order, but from a random starting group. Groups of a map created
+// with swiss.WithLazyClear are reset first if they were cleared, so All
+// must not run concurrently with other reads of such a map.
+func All[K comparable, V any](m *swiss.Map[K, V]) iter.Seq2[int, Group[K, V]] {
+	return func(yield func(int, Group[K, V]) bool) {
+		grps := layout.Groups(m).([]layout.Group[K, V])
+		for i := range grps {
+			if !yield(i, Group[K, V]{g: &grps[i]}) {
+				return
+			}
+		}
+	}
+}
+
+// Full returns a mask with bit i set if slot i holds an entry.
+func (g Group[K, V]) Full() uint8 {
+	return g.mask(func(c byte) bool { return c&layout.Empty == 0 })
+}
+
+// Empty returns a mask with bit i set if slot i holds the empty control
+// byte, as opposed to an entry or a tombstone.
+func (g Group[K, V]) Empty() uint8 {
+	return g.mask(func(c byte) bool { return c == layout.Empty })
+}
+
+// Deleted returns a mask with bit i set if slot i holds a tombstone.
+func (g Group[K, V]) Deleted() uint8 {
+	return g.mask(func(c byte) bool { return c == layout.Deleted })
+}
+
+// Key returns the key stored in slot i. The result is only meaningful if
+// bit i is set in Full. It panics if i is out of [0, Size).
+func (g Group[K, V]) Key(i int) K {
+	return g.g.Slots[i].Key
+}
+
+// Value returns the value stored in slot i. The result is only meaningful
+// if bit i is set in Full. It panics if i is out of [0, Size).
+func (g Group[K, V]) Value(i int) V {
+	return g.g.Slots[i].Value
+}
+
+// mask returns a mask with bit i set if the control byte of slot i
+// satisfies match.
+func (g Group[K, V]) mask(match func(c byte) bool) uint8 {
+	var res uint8
+	for i := range Size {
+		if match(byte(g.g.Control >> (8 * i))) {
+			res |= 1 << i
+		}
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/crn4/swiss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupsVisitation(t *testing.T) {
+	t.Parallel()
+	size := 1000
+	m := swiss.New[int, int](size)
+	for i := range size {
+		m.Put(i, i*2)
+	}
+	for i := 0; i < size; i += 3 {
+		m.Delete(i)
+	}
+	var cnt, ngroups int
+	for i, g := range All(m) {
+		require.Equal(t, ngroups, i)
+		ngroups++
+		full := g.Full()
+		require.Zero(t, full&g.Empty())
+		require.Zero(t, full&g.Deleted())
+		require.Zero(t, g.Empty()&g.Deleted())
+		require.Equal(t, uint8(0xFF), full|g.Empty()|g.Deleted())
+		for j := range Size {
+			if full&(1<<j) == 0 {
+				continue
+			}
+			require.Equal(t, g.Key(j)*2, g.Value(j))
+			cnt++
+		}
+	}
+	assert.GreaterOrEqual(t, ngroups*Size, m.Cap())
+	assert.Equal(t, m.Len(), cnt)
+}
+
+func TestGroupsLazyClear(t *testing.T) {
+	t.Parallel()
+	m := swiss.New[int, string](100, swiss.WithLazyClear())
+	for i := range 100 {
+		m.Put(i, "x")
+	}
+	m.Clear()
+	m.Put(1, "y")
+	var cnt int
+	for _, g := range All(m) {
+		for j := range Size {
+			if g.Full()&(1<<j) != 0 {
+				require.Equal(t, 1, g.Key(j))
+				require.Equal(t, "y", g.Value(j))
+				cnt++
+			}
+		}
+	}
+	require.Equal(t, 1, cnt)
+}